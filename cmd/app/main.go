@@ -3,65 +3,376 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	gcpfirestore "cloud.google.com/go/firestore"
 	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v4/pgxpool"
 	_ "github.com/joho/godotenv/autoload"
+	usersv1 "github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/api/users/v1"
 	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/auth"
 	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/firestore"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/mail"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/oauth"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/oidc"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/postgres"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/ratelimit"
 	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/users"
 	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/validator"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/webauthn"
 	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
 )
 
 func main() {
 	log.Logger = log.With().Caller().Logger()
 
+	ctx := context.Background()
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			pool := initPostgres(ctx)
+			defer pool.Close()
+
+			err := postgres.Migrate(ctx, pool)
+			if err != nil {
+				log.Fatal().Err(err).Msg("Error running the Postgres migrations")
+			}
+
+			log.Info().Msg("Postgres migrations applied")
+			return
+		case "backfill-canonical-keys":
+			var backfilled int
+			var err error
+
+			if os.Getenv("STORAGE_BACKEND") == "postgres" {
+				pool := initPostgres(ctx)
+				defer pool.Close()
+
+				backfilled, err = users.NewPostgresUserRepository(pool).BackfillCanonicalKeys(ctx)
+			} else {
+				firestoreClient := initFirestore(ctx)
+				defer firestoreClient.Close()
+
+				backfilled, err = users.NewFirestoreUserRepository(firestoreClient).BackfillCanonicalKeys(ctx)
+			}
+			if err != nil {
+				log.Fatal().Err(err).Msg("Error backfilling the Users' canonical keys")
+			}
+
+			log.Info().Msgf("Backfilled the canonical keys of %d Users", backfilled)
+			return
+		default:
+			log.Fatal().Msgf("Unknown command '%s'", os.Args[1])
+		}
+	}
+
 	port, err := strconv.Atoi(os.Getenv("PORT"))
 	if err != nil {
 		log.Fatal().Err(err).Msg("Environment variable 'PORT' must be set and set to an integer")
 	}
 
-	firestoreProjectId := os.Getenv("FIRESTORE_PROJECT_ID")
-	if len(firestoreProjectId) == 0 {
-		log.Fatal().Err(err).Msg("Environment variable 'FIRESTORE_PROJECT_ID' must be set and not be empty")
+	keyRing := initKeyRing()
+
+	jwtSecondsToExpire, err := strconv.Atoi(os.Getenv("JWT_SECONDS_TO_EXPIRE"))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Environment variable 'JWT_SECONDS_TO_EXPIRE' must be set and not be empty")
 	}
 
-	ctx := context.Background()
+	jwtService := auth.NewJwtService(keyRing, jwtSecondsToExpire)
 
-	jwtSecretKey := os.Getenv("JWT_SECRET_KEY")
-	if len(jwtSecretKey) == 0 {
-		log.Fatal().Err(err).Msg("Environment variable 'JWT_SECRET_KEY' must be set and not be empty")
+	refreshTokenSecondsToExpire, err := strconv.Atoi(os.Getenv("REFRESH_TOKEN_SECONDS_TO_EXPIRE"))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Environment variable 'REFRESH_TOKEN_SECONDS_TO_EXPIRE' must be set and set to an integer")
 	}
 
-	jwtSecondsToExpire, err := strconv.Atoi(os.Getenv("JWT_SECONDS_TO_EXPIRE"))
+	tokenRevocationCacheSecondsToExpire, err := strconv.Atoi(os.Getenv("TOKEN_REVOCATION_CACHE_SECONDS_TO_EXPIRE"))
 	if err != nil {
-		log.Fatal().Err(err).Msg("Environment variable 'JWT_SECONDS_TO_EXPIRE' must be set and not be empty")
+		log.Fatal().Err(err).Msg("Environment variable 'TOKEN_REVOCATION_CACHE_SECONDS_TO_EXPIRE' must be set and set to an integer")
 	}
 
-	jwtService := auth.NewJwtService(jwtSecretKey, jwtSecondsToExpire)
+	passwordResetSecondsToExpire, err := strconv.Atoi(os.Getenv("PASSWORD_RESET_SECONDS_TO_EXPIRE"))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Environment variable 'PASSWORD_RESET_SECONDS_TO_EXPIRE' must be set and set to an integer")
+	}
 
-	firestoreClient, err := firestore.InitFirestore(ctx, firestoreProjectId)
+	emailVerificationSecondsToExpire, err := strconv.Atoi(os.Getenv("EMAIL_VERIFICATION_SECONDS_TO_EXPIRE"))
 	if err != nil {
-		log.Fatal().Err(err).Msg("Error initializing the Firestore client")
+		log.Fatal().Err(err).Msg("Environment variable 'EMAIL_VERIFICATION_SECONDS_TO_EXPIRE' must be set and set to an integer")
+	}
+
+	mailer := initMailer()
+
+	loginLockout := time.Second * time.Duration(getIntEnv("LOGIN_LOCKOUT_SECONDS", 900))
+	loginBackoffMax := time.Second * time.Duration(getIntEnv("LOGIN_BACKOFF_MAX_SECONDS", 60))
+
+	accountLoginBackoffBase := time.Second * time.Duration(getIntEnv("LOGIN_BACKOFF_BASE_SECONDS", 1))
+	accountLoginThrottlePolicy := auth.NewLoginThrottlePolicy(getIntEnv("LOGIN_MAX_FAILURES_PER_ACCOUNT", 10), loginLockout, accountLoginBackoffBase, loginBackoffMax)
+
+	// Users behind the same NAT share an IP, so IPs don't back off by default.
+	ipLoginBackoffBase := time.Second * time.Duration(getIntEnv("LOGIN_IP_BACKOFF_BASE_SECONDS", 0))
+	ipLoginThrottlePolicy := auth.NewLoginThrottlePolicy(getIntEnv("LOGIN_MAX_FAILURES_PER_IP", 100), loginLockout, ipLoginBackoffBase, loginBackoffMax)
+
+	totpIssuer := os.Getenv("TOTP_ISSUER")
+	if len(totpIssuer) == 0 {
+		totpIssuer = "Conduit"
+	}
+
+	twoFactorChallengeSecondsToExpire := getIntEnv("TWO_FACTOR_CHALLENGE_SECONDS_TO_EXPIRE", 300)
+
+	relyingParty := initRelyingParty()
+
+	oauthProviders := initOauthProviders()
+
+	oauthCallbackBaseUrl := os.Getenv("OAUTH_CALLBACK_BASE_URL")
+	if len(oauthCallbackBaseUrl) == 0 {
+		oauthCallbackBaseUrl = fmt.Sprintf("http://localhost:%d", port)
+	}
+
+	oauthStateSecondsToExpire := getIntEnv("OAUTH_STATE_SECONDS_TO_EXPIRE", 600)
+
+	oidcIssuer := os.Getenv("OIDC_ISSUER")
+	if len(oidcIssuer) == 0 {
+		oidcIssuer = fmt.Sprintf("http://localhost:%d", port)
+	}
+
+	oidcClients := initOidcClients()
+	for _, oidcClient := range oidcClients {
+		if oidcClient.IsPublic() && !keyRing.SigningKey().IsPublishable() {
+			log.Fatal().Msgf("OpenID Connect client '%s' has no secret, which requires an RSA or Ed25519 JWT signing key to sign its ID tokens with", oidcClient.Id)
+		}
+	}
+
+	oidcCodeSecondsToExpire := getIntEnv("OIDC_CODE_SECONDS_TO_EXPIRE", 60)
+
+	storageBackend := os.Getenv("STORAGE_BACKEND")
+	if len(storageBackend) == 0 {
+		storageBackend = "firestore"
+	}
+
+	var userRepository users.UserRepository
+	var refreshTokenRepository auth.RefreshTokenRepository
+	var tokenRevocationRepository auth.TokenRevocationRepository
+	var followRepository users.FollowRepository
+	var oneTimeTokenRepository users.OneTimeTokenRepository
+	var loginAttemptsRepository auth.LoginAttemptsRepository
+	var totpEnrollmentRepository users.TotpEnrollmentRepository
+	var webauthnCredentialRepository users.WebauthnCredentialRepository
+	var webauthnChallengeRepository users.WebauthnChallengeRepository
+	var oauthStateRepository users.OauthStateRepository
+	var externalIdentityRepository users.ExternalIdentityRepository
+	var authorizationCodeRepository oidc.AuthorizationCodeRepository
+	var apiKeyRepository auth.ApiKeyRepository
+
+	switch storageBackend {
+	case "firestore":
+		firestoreClient := initFirestore(ctx)
+		defer firestoreClient.Close()
+
+		userRepository = users.NewFirestoreUserRepository(firestoreClient)
+		refreshTokenRepository = auth.NewFirestoreRefreshTokenRepository(firestoreClient)
+		tokenRevocationRepository = auth.NewFirestoreTokenRevocationRepository(firestoreClient)
+		followRepository = users.NewFirestoreFollowRepository(firestoreClient)
+		oneTimeTokenRepository = users.NewFirestoreOneTimeTokenRepository(firestoreClient)
+		loginAttemptsRepository = auth.NewFirestoreLoginAttemptsRepository(firestoreClient)
+		totpEnrollmentRepository = users.NewFirestoreTotpEnrollmentRepository(firestoreClient)
+		webauthnCredentialRepository = users.NewFirestoreWebauthnCredentialRepository(firestoreClient)
+		webauthnChallengeRepository = users.NewFirestoreWebauthnChallengeRepository(firestoreClient)
+		oauthStateRepository = users.NewFirestoreOauthStateRepository(firestoreClient)
+		externalIdentityRepository = users.NewFirestoreExternalIdentityRepository(firestoreClient)
+		authorizationCodeRepository = oidc.NewFirestoreAuthorizationCodeRepository(firestoreClient)
+		apiKeyRepository = auth.NewFirestoreApiKeyRepository(firestoreClient)
+	case "postgres":
+		pool := initPostgres(ctx)
+		defer pool.Close()
+
+		if os.Getenv("POSTGRES_RUN_MIGRATIONS") != "false" {
+			err := postgres.Migrate(ctx, pool)
+			if err != nil {
+				log.Fatal().Err(err).Msg("Error running the Postgres migrations")
+			}
+		}
+
+		userRepository = users.NewPostgresUserRepository(pool)
+		refreshTokenRepository = auth.NewPostgresRefreshTokenRepository(pool)
+		tokenRevocationRepository = auth.NewPostgresTokenRevocationRepository(pool)
+		followRepository = users.NewPostgresFollowRepository(pool)
+		oneTimeTokenRepository = users.NewPostgresOneTimeTokenRepository(pool)
+		loginAttemptsRepository = auth.NewPostgresLoginAttemptsRepository(pool)
+		totpEnrollmentRepository = users.NewPostgresTotpEnrollmentRepository(pool)
+		webauthnCredentialRepository = users.NewPostgresWebauthnCredentialRepository(pool)
+		webauthnChallengeRepository = users.NewPostgresWebauthnChallengeRepository(pool)
+		oauthStateRepository = users.NewPostgresOauthStateRepository(pool)
+		externalIdentityRepository = users.NewPostgresExternalIdentityRepository(pool)
+		authorizationCodeRepository = oidc.NewPostgresAuthorizationCodeRepository(pool)
+		apiKeyRepository = auth.NewPostgresApiKeyRepository(pool)
+	case "memory":
+		userRepository = users.NewInMemoryUserRepository()
+		refreshTokenRepository = auth.NewInMemoryRefreshTokenRepository()
+		tokenRevocationRepository = auth.NewInMemoryTokenRevocationRepository()
+		followRepository = users.NewInMemoryFollowRepository()
+		oneTimeTokenRepository = users.NewInMemoryOneTimeTokenRepository()
+		loginAttemptsRepository = auth.NewInMemoryLoginAttemptsRepository()
+		totpEnrollmentRepository = users.NewInMemoryTotpEnrollmentRepository()
+		webauthnCredentialRepository = users.NewInMemoryWebauthnCredentialRepository()
+		webauthnChallengeRepository = users.NewInMemoryWebauthnChallengeRepository()
+		oauthStateRepository = users.NewInMemoryOauthStateRepository()
+		externalIdentityRepository = users.NewInMemoryExternalIdentityRepository()
+		authorizationCodeRepository = oidc.NewInMemoryAuthorizationCodeRepository()
+		apiKeyRepository = auth.NewInMemoryApiKeyRepository()
+	default:
+		log.Fatal().Msgf("Environment variable 'STORAGE_BACKEND' must be one of 'firestore', 'postgres' or 'memory', got '%s'", storageBackend)
+	}
+
+	usersService := users.NewUsersService(*validator.InitValidator(), userRepository, initAdminEmails())
+
+	err = usersService.GrantAdminRoles(ctx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error granting the admin role to the users of 'ADMIN_EMAILS'")
 	}
 
-	defer firestoreClient.Close()
+	profilesService := users.NewProfilesService(usersService, followRepository)
+
+	refreshTokenService := auth.NewRefreshTokenService(refreshTokenRepository, refreshTokenSecondsToExpire)
+
+	tokenRevocationService := auth.NewTokenRevocationService(tokenRevocationRepository, tokenRevocationCacheSecondsToExpire)
+
+	oneTimeTokenService := users.NewOneTimeTokenService(usersService, oneTimeTokenRepository)
+
+	passwordResetService := users.NewPasswordResetService(usersService, oneTimeTokenService, mailer, passwordResetSecondsToExpire, os.Getenv("PASSWORD_RESET_URL"))
+
+	emailVerificationService := users.NewEmailVerificationService(usersService, oneTimeTokenService, mailer, emailVerificationSecondsToExpire, os.Getenv("EMAIL_VERIFICATION_URL"))
+
+	loginThrottleService := auth.NewLoginThrottleService(loginAttemptsRepository, accountLoginThrottlePolicy, ipLoginThrottlePolicy)
+
+	twoFactorService := users.NewTwoFactorService(usersService, oneTimeTokenService, totpEnrollmentRepository, totpIssuer, twoFactorChallengeSecondsToExpire)
+
+	webauthnService := users.NewWebauthnService(usersService, webauthnCredentialRepository, webauthnChallengeRepository, relyingParty)
+
+	oauthService := users.NewOauthService(usersService, oauthStateRepository, externalIdentityRepository, oauthProviders, oauthCallbackBaseUrl, oauthStateSecondsToExpire)
+
+	apiKeyService := auth.NewApiKeyService(apiKeyRepository)
+
+	oidcService := oidc.NewOidcService(oidcIssuer, oidcClients, authorizationCodeRepository, usersService, jwtService, oidcCodeSecondsToExpire)
 
-	usersService := users.NewUsersService(*validator.InitValidator(), *firestoreClient)
+	usersHandlers := users.NewUsersHandlers(usersService, jwtService, refreshTokenService, tokenRevocationService, emailVerificationService, loginThrottleService, twoFactorService)
 
-	usersHandlers := users.NewUsersHandlers(usersService, jwtService)
+	profilesHandlers := users.NewProfilesHandlers(profilesService, usersService)
 
-	authMiddleware := auth.NewAuthMiddleware(jwtService)
+	passwordResetHandlers := users.NewPasswordResetHandlers(passwordResetService, refreshTokenService, tokenRevocationService)
+
+	webauthnHandlers := users.NewWebauthnHandlers(webauthnService, usersService, twoFactorService, jwtService, refreshTokenService)
+
+	oauthHandlers := users.NewOauthHandlers(oauthService, twoFactorService, jwtService, refreshTokenService)
+
+	oidcHandlers := oidc.NewOidcHandlers(oidcService, usersService, os.Getenv("OIDC_LOGIN_URL"))
+
+	apiKeyHandlers := users.NewApiKeyHandlers(apiKeyService, usersService)
+
+	authMiddleware := auth.NewAuthMiddleware(jwtService, tokenRevocationService, apiKeyService)
+
+	jwksHandlers := auth.NewJwksHandlers(jwtService)
+
+	trustedProxies, err := auth.ParseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Environment variable 'TRUSTED_PROXIES' must be a comma separated list of IPs or CIDRs")
+	}
+
+	clientIpResolver := auth.NewClientIpResolver(trustedProxies)
+
+	registrationRateLimiter := initRateLimiter("registration", "RATE_LIMIT_REGISTRATION", "10/1m", ratelimit.ByIp)
+	authRateLimiter := initRateLimiter("auth", "RATE_LIMIT_AUTH", "30/1m", ratelimit.ByIp)
+	apiRateLimiter := initRateLimiter("api", "RATE_LIMIT_API", "600/1m", ratelimit.ByUser)
+
+	registration := func(handler http.HandlerFunc) http.HandlerFunc {
+		return rateLimit(registrationRateLimiter, handler)
+	}
+
+	authentication := func(handler http.HandlerFunc) http.HandlerFunc {
+		return rateLimit(authRateLimiter, handler)
+	}
+
+	// api limits are applied after authentication, so that authenticated users are keyed by user.
+	api := func(handler http.HandlerFunc) http.HandlerFunc {
+		return rateLimit(apiRateLimiter, handler)
+	}
+
+	// admin routes require a session of an admin, as API keys carry no roles.
+	admin := func(handler http.HandlerFunc) http.HandlerFunc {
+		return authMiddleware.AuthenticateSession(api(auth.RequireRole(auth.RoleAdmin)(handler).ServeHTTP))
+	}
 
 	router := chi.NewRouter()
-	router.Post("/users", usersHandlers.RegisterUser)
-	router.Post("/users/login", usersHandlers.Login)
-	router.Get("/users/{username}", usersHandlers.GetUserByUsername)
-	router.Get("/user", authMiddleware.Authenticate(usersHandlers.GetCurrentUser))
-	router.Put("/user", authMiddleware.Authenticate(usersHandlers.UpdateUser))
+	router.Use(clientIpResolver.Middleware)
+	router.Get("/.well-known/jwks.json", api(jwksHandlers.GetJwks))
+	router.Get("/.well-known/openid-configuration", api(oidcHandlers.GetDiscovery))
+	router.Get("/oauth/authorize", authMiddleware.OptionalAuthenticate(api(oidcHandlers.Authorize)))
+	router.Post("/oauth/authorize", authMiddleware.OptionalAuthenticate(api(oidcHandlers.Authorize)))
+	router.Post("/oauth/token", authentication(oidcHandlers.Token))
+	router.Get("/oauth/userinfo", auth.ReadOnly(authMiddleware.Authenticate(api(oidcHandlers.Userinfo))))
+	router.Post("/oauth/userinfo", auth.ReadOnly(authMiddleware.Authenticate(api(oidcHandlers.Userinfo))))
+	router.Post("/users", registration(usersHandlers.RegisterUser))
+	router.Post("/users/login", authentication(usersHandlers.Login))
+	router.Post("/users/login/2fa", authentication(usersHandlers.VerifyTwoFactorLogin))
+	router.Post("/users/login/passkey/begin", authentication(webauthnHandlers.BeginLogin))
+	router.Post("/users/login/passkey/finish", authentication(webauthnHandlers.FinishLogin))
+	router.Get("/users/oauth/{provider}/start", authentication(oauthHandlers.StartLogin))
+	router.Get("/users/oauth/{provider}/callback", authentication(oauthHandlers.Callback))
+	router.Post("/users:batchGet", api(usersHandlers.BatchGetUsers))
+	router.Post("/users/token/refresh", authentication(usersHandlers.RefreshToken))
+	router.Post("/users/password-reset", authentication(passwordResetHandlers.RequestPasswordReset))
+	router.Post("/users/password-reset/confirm", authentication(passwordResetHandlers.ConfirmPasswordReset))
+	router.Post("/users/verify-email", authentication(usersHandlers.VerifyEmail))
+	router.Get("/users/{username}", authMiddleware.OptionalAuthenticate(api(usersHandlers.GetUserByUsername)))
+	router.Put("/users/{username}/roles", admin(usersHandlers.UpdateUserRoles))
+	router.Get("/user", authMiddleware.Authenticate(api(usersHandlers.GetCurrentUser)))
+	router.Put("/user", authMiddleware.AuthenticateSession(api(usersHandlers.UpdateUser)))
+	router.Post("/user/verify-email/resend", authMiddleware.Authenticate(api(usersHandlers.ResendVerificationEmail)))
+	router.Post("/user/2fa/totp", authMiddleware.AuthenticateSession(api(usersHandlers.EnrollTotp)))
+	router.Post("/user/2fa/totp/confirm", authMiddleware.AuthenticateSession(api(usersHandlers.ConfirmTotp)))
+	router.Delete("/user/2fa/totp", authMiddleware.AuthenticateSession(api(usersHandlers.DisableTotp)))
+	router.Get("/user/passkeys", authMiddleware.AuthenticateSession(api(webauthnHandlers.ListPasskeys)))
+	router.Post("/user/passkeys/registration/begin", authMiddleware.AuthenticateSession(api(webauthnHandlers.BeginRegistration)))
+	router.Post("/user/passkeys/registration/finish", authMiddleware.AuthenticateSession(api(webauthnHandlers.FinishRegistration)))
+	router.Get("/user/api-keys", authMiddleware.AuthenticateSession(api(apiKeyHandlers.ListApiKeys)))
+	router.Post("/user/api-keys", authMiddleware.AuthenticateSession(api(apiKeyHandlers.CreateApiKey)))
+	router.Delete("/user/api-keys/{id}", authMiddleware.AuthenticateSession(api(apiKeyHandlers.DeleteApiKey)))
+	router.Post("/user/logout", authMiddleware.AuthenticateSession(api(usersHandlers.Logout)))
+	router.Post("/user/logout-all", authMiddleware.AuthenticateSession(api(usersHandlers.LogoutAll)))
+	router.Get("/profiles/{username}", authMiddleware.OptionalAuthenticate(api(profilesHandlers.GetProfile)))
+	router.Get("/profiles/{username}/followers", authMiddleware.OptionalAuthenticate(api(profilesHandlers.ListFollowers)))
+	router.Get("/profiles/{username}/following", authMiddleware.OptionalAuthenticate(api(profilesHandlers.ListFollowing)))
+	router.Post("/profiles/{username}/follow", authMiddleware.Authenticate(api(profilesHandlers.FollowUser)))
+	router.Delete("/profiles/{username}/follow", authMiddleware.Authenticate(api(profilesHandlers.UnfollowUser)))
+
+	grpcPortEnv := os.Getenv("GRPC_PORT")
+	if len(grpcPortEnv) > 0 {
+		grpcPort, err := strconv.Atoi(grpcPortEnv)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Environment variable 'GRPC_PORT' must be set to an integer")
+		}
+
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", grpcPort))
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Error listening on port %d", grpcPort)
+		}
+
+		grpcServer := grpc.NewServer()
+		usersv1.RegisterUsersServiceServer(grpcServer, users.NewUsersGrpcServer(usersService, profilesService, jwtService, tokenRevocationService))
+
+		go func() {
+			log.Info().Msgf("Starting gRPC server on %s", listener.Addr())
+			log.Fatal().Err(grpcServer.Serve(listener)).Msg("")
+		}()
+	}
 
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%d", port),
@@ -71,3 +382,388 @@ func main() {
 	log.Info().Msgf("Starting server on %s", server.Addr)
 	log.Fatal().Err(server.ListenAndServe()).Msg("")
 }
+
+// initKeyRing loads the JWT keys from JWT_KEYS_DIR or, when not set, from the other JWT_*
+// environment variables, and reloads them on SIGHUP and every JWT_KEYS_RELOAD_SECONDS.
+func initKeyRing() *auth.KeyRing {
+	var loadKeys auth.KeyRingLoader
+
+	jwtKeysDir := os.Getenv("JWT_KEYS_DIR")
+	if len(jwtKeysDir) > 0 {
+		jwtKeyRetirementSeconds := 0
+		if len(os.Getenv("JWT_KEY_RETIREMENT_SECONDS")) > 0 {
+			var err error
+			jwtKeyRetirementSeconds, err = strconv.Atoi(os.Getenv("JWT_KEY_RETIREMENT_SECONDS"))
+			if err != nil {
+				log.Fatal().Err(err).Msg("Environment variable 'JWT_KEY_RETIREMENT_SECONDS' must be set to an integer")
+			}
+		}
+
+		loadKeys = auth.DirKeyRingLoader(jwtKeysDir, os.Getenv("JWT_SIGNING_KEY_ID"), time.Second*time.Duration(jwtKeyRetirementSeconds))
+	} else {
+		jwtPrivateKeyFile := os.Getenv("JWT_PRIVATE_KEY_FILE")
+		jwtSecretKey := os.Getenv("JWT_SECRET_KEY")
+		if len(jwtPrivateKeyFile) == 0 && len(jwtSecretKey) == 0 {
+			log.Fatal().Msg("Environment variable 'JWT_SECRET_KEY' must be set and not be empty when neither 'JWT_KEYS_DIR' nor 'JWT_PRIVATE_KEY_FILE' are set")
+		}
+
+		loadKeys = func() (*auth.SigningKey, []auth.SigningKey, error) {
+			var signingKey auth.SigningKey
+			if len(jwtPrivateKeyFile) > 0 {
+				privateKey, err := auth.LoadKey(jwtPrivateKeyFile, os.Getenv("JWT_KEY_ID"))
+				if err != nil {
+					return nil, nil, err
+				}
+				signingKey = *privateKey
+			} else {
+				signingKey = auth.NewHmacSigningKey(os.Getenv("JWT_KEY_ID"), jwtSecretKey)
+			}
+
+			verificationKeys := []auth.SigningKey{}
+
+			for _, previousSecretKey := range strings.Split(os.Getenv("JWT_PREVIOUS_SECRET_KEYS"), ",") {
+				if len(previousSecretKey) > 0 {
+					verificationKeys = append(verificationKeys, auth.NewHmacSigningKey("", previousSecretKey))
+				}
+			}
+
+			for _, verificationKeyFile := range strings.Split(os.Getenv("JWT_VERIFICATION_KEY_FILES"), ",") {
+				if len(verificationKeyFile) > 0 {
+					verificationKey, err := auth.LoadKey(verificationKeyFile, "")
+					if err != nil {
+						return nil, nil, err
+					}
+					verificationKeys = append(verificationKeys, *verificationKey)
+				}
+			}
+
+			return &signingKey, verificationKeys, nil
+		}
+	}
+
+	keyRing, err := auth.NewKeyRing(loadKeys)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error loading the JWT keys")
+	}
+
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+
+	if len(os.Getenv("JWT_KEYS_RELOAD_SECONDS")) > 0 {
+		jwtKeysReloadSeconds, err := strconv.Atoi(os.Getenv("JWT_KEYS_RELOAD_SECONDS"))
+		if err != nil || jwtKeysReloadSeconds <= 0 {
+			log.Fatal().Err(err).Msg("Environment variable 'JWT_KEYS_RELOAD_SECONDS' must be set to a positive integer")
+		}
+
+		go func() {
+			for range time.Tick(time.Second * time.Duration(jwtKeysReloadSeconds)) {
+				reload <- syscall.SIGHUP
+			}
+		}()
+	}
+
+	go func() {
+		for range reload {
+			err := keyRing.Reload()
+			if err != nil {
+				log.Error().Err(err).Msg("Error reloading the JWT keys, keeping the current ones")
+			}
+		}
+	}()
+
+	return keyRing
+}
+
+// initMailer returns a mailer sending with the sender selected by MAIL_SENDER, from the default
+// templates overridden by the ones of MAIL_TEMPLATES_DIR.
+func initMailer() mail.Mailer {
+	mailFrom := os.Getenv("MAIL_FROM")
+	if len(mailFrom) == 0 {
+		mailFrom = "noreply@localhost"
+	}
+
+	mailSender := os.Getenv("MAIL_SENDER")
+	if len(mailSender) == 0 {
+		mailSender = "stdout"
+	}
+
+	var sender mail.Sender
+
+	switch mailSender {
+	case "stdout":
+		sender = mail.NewStdoutSender(mailFrom)
+	case "file":
+		mailFileDir := os.Getenv("MAIL_FILE_DIR")
+		if len(mailFileDir) == 0 {
+			log.Fatal().Msg("Environment variable 'MAIL_FILE_DIR' must be set and not be empty when 'MAIL_SENDER' is 'file'")
+		}
+
+		sender = mail.NewFileSender(mailFileDir, mailFrom)
+	case "smtp":
+		smtpHost := os.Getenv("SMTP_HOST")
+		if len(smtpHost) == 0 {
+			log.Fatal().Msg("Environment variable 'SMTP_HOST' must be set and not be empty when 'MAIL_SENDER' is 'smtp'")
+		}
+
+		smtpPort := 587
+		if len(os.Getenv("SMTP_PORT")) > 0 {
+			var err error
+			smtpPort, err = strconv.Atoi(os.Getenv("SMTP_PORT"))
+			if err != nil {
+				log.Fatal().Err(err).Msg("Environment variable 'SMTP_PORT' must be set to an integer")
+			}
+		}
+
+		sender = mail.NewSmtpSender(smtpHost, smtpPort, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), mailFrom)
+	default:
+		log.Fatal().Msgf("Environment variable 'MAIL_SENDER' must be one of 'stdout', 'file' or 'smtp', got '%s'", mailSender)
+	}
+
+	templatesFS := mail.DefaultTemplatesFS()
+
+	mailTemplatesDir := os.Getenv("MAIL_TEMPLATES_DIR")
+	if len(mailTemplatesDir) > 0 {
+		templatesFS = mail.OverlayFS(os.DirFS(mailTemplatesDir), templatesFS)
+	}
+
+	mailDefaultLocale := os.Getenv("MAIL_DEFAULT_LOCALE")
+	if len(mailDefaultLocale) == 0 {
+		mailDefaultLocale = "en"
+	}
+
+	return mail.NewMailer(sender, mail.NewTemplates(templatesFS, mailDefaultLocale))
+}
+
+// initRelyingParty returns the WebAuthn relying party of WEBAUTHN_RP_ID, for pages served at the
+// comma separated WEBAUTHN_ORIGINS.
+func initRelyingParty() webauthn.RelyingParty {
+	rpId := os.Getenv("WEBAUTHN_RP_ID")
+	if len(rpId) == 0 {
+		rpId = "localhost"
+	}
+
+	rpName := os.Getenv("WEBAUTHN_RP_NAME")
+	if len(rpName) == 0 {
+		rpName = "Conduit"
+	}
+
+	origins := []string{}
+	for _, origin := range strings.Split(os.Getenv("WEBAUTHN_ORIGINS"), ",") {
+		origin = strings.TrimSpace(origin)
+		if len(origin) > 0 {
+			origins = append(origins, origin)
+		}
+	}
+	if len(origins) == 0 {
+		origins = []string{"http://localhost:3000"}
+	}
+
+	timeout := time.Second * time.Duration(getIntEnv("WEBAUTHN_TIMEOUT_SECONDS", 300))
+
+	return webauthn.NewRelyingParty(rpId, rpName, origins, timeout)
+}
+
+// initOauthProviders returns the social login providers named in the comma separated
+// OAUTH_PROVIDERS, each configured with the OAUTH_<NAME>_* environment variables.
+func initOauthProviders() map[string]oauth.Provider {
+	providers := map[string]oauth.Provider{}
+
+	for _, name := range strings.Split(os.Getenv("OAUTH_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len(name) == 0 {
+			continue
+		}
+
+		for _, r := range name {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+				log.Fatal().Msgf("Environment variable 'OAUTH_PROVIDERS' must only contain letters and digits, got '%s'", name)
+			}
+		}
+
+		envPrefix := fmt.Sprintf("OAUTH_%s_", strings.ToUpper(name))
+
+		clientId := os.Getenv(envPrefix + "CLIENT_ID")
+		if len(clientId) == 0 {
+			log.Fatal().Msgf("Environment variable '%sCLIENT_ID' must be set and not be empty", envPrefix)
+		}
+
+		clientSecret := os.Getenv(envPrefix + "CLIENT_SECRET")
+		if len(clientSecret) == 0 {
+			log.Fatal().Msgf("Environment variable '%sCLIENT_SECRET' must be set and not be empty", envPrefix)
+		}
+
+		providerType := os.Getenv(envPrefix + "TYPE")
+		if len(providerType) == 0 {
+			providerType = "oidc"
+			if name == "github" {
+				providerType = "github"
+			}
+		}
+
+		scopes := strings.Fields(os.Getenv(envPrefix + "SCOPES"))
+
+		switch providerType {
+		case "oidc":
+			issuer := os.Getenv(envPrefix + "ISSUER")
+			if len(issuer) == 0 && name == "google" {
+				issuer = "https://accounts.google.com"
+			}
+			if len(issuer) == 0 {
+				log.Fatal().Msgf("Environment variable '%sISSUER' must be set and not be empty when '%sTYPE' is 'oidc'", envPrefix, envPrefix)
+			}
+
+			if len(scopes) == 0 {
+				scopes = []string{"openid", "email", "profile"}
+			}
+
+			providers[name] = oauth.NewOidcProvider(name, issuer, clientId, clientSecret, scopes)
+		case "github":
+			if len(scopes) == 0 {
+				scopes = []string{"read:user", "user:email"}
+			}
+
+			provider := oauth.NewGithubProvider(name, clientId, clientSecret, scopes)
+
+			if len(os.Getenv(envPrefix+"AUTHORIZATION_ENDPOINT")) > 0 {
+				provider.AuthorizationEndpoint = os.Getenv(envPrefix + "AUTHORIZATION_ENDPOINT")
+			}
+			if len(os.Getenv(envPrefix+"TOKEN_ENDPOINT")) > 0 {
+				provider.TokenEndpoint = os.Getenv(envPrefix + "TOKEN_ENDPOINT")
+			}
+			if len(os.Getenv(envPrefix+"API_URL")) > 0 {
+				provider.ApiUrl = os.Getenv(envPrefix + "API_URL")
+			}
+
+			providers[name] = provider
+		default:
+			log.Fatal().Msgf("Environment variable '%sTYPE' must be one of 'oidc' or 'github', got '%s'", envPrefix, providerType)
+		}
+
+		log.Info().Msgf("Enabled login with OAuth provider %s (%s)", name, providerType)
+	}
+
+	return providers
+}
+
+// initAdminEmails returns the comma separated emails of ADMIN_EMAILS.
+func initAdminEmails() []string {
+	adminEmails := []string{}
+
+	for _, email := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		email = strings.TrimSpace(email)
+		if len(email) > 0 {
+			adminEmails = append(adminEmails, email)
+		}
+	}
+
+	return adminEmails
+}
+
+// initOidcClients returns the OpenID Connect clients with the comma separated ids of OIDC_CLIENTS,
+// each configured with the OIDC_CLIENT_<ID>_* environment variables, where dashes in the id are
+// replaced with underscores.
+func initOidcClients() map[string]oidc.Client {
+	clients := map[string]oidc.Client{}
+
+	for _, clientId := range strings.Split(os.Getenv("OIDC_CLIENTS"), ",") {
+		clientId = strings.TrimSpace(clientId)
+		if len(clientId) == 0 {
+			continue
+		}
+
+		for _, r := range clientId {
+			if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+				log.Fatal().Msgf("Environment variable 'OIDC_CLIENTS' must only contain letters, digits, dashes and underscores, got '%s'", clientId)
+			}
+		}
+
+		envPrefix := fmt.Sprintf("OIDC_CLIENT_%s_", strings.ToUpper(strings.ReplaceAll(clientId, "-", "_")))
+
+		redirectUris := []string{}
+		for _, redirectUri := range strings.Split(os.Getenv(envPrefix+"REDIRECT_URIS"), ",") {
+			redirectUri = strings.TrimSpace(redirectUri)
+			if len(redirectUri) > 0 {
+				redirectUris = append(redirectUris, redirectUri)
+			}
+		}
+		if len(redirectUris) == 0 {
+			log.Fatal().Msgf("Environment variable '%sREDIRECT_URIS' must be set and not be empty", envPrefix)
+		}
+
+		// Clients without a secret are public clients.
+		clients[clientId] = oidc.NewClient(clientId, os.Getenv(envPrefix+"SECRET"), redirectUris)
+	}
+
+	return clients
+}
+
+// initRateLimiter returns the rate limiter of a route group, with the <limit>/<window> policy of
+// the environment variable or, when not set, defaultPolicy. It returns nil if the policy is off.
+func initRateLimiter(name string, policyEnvName string, defaultPolicy string, keyFunc ratelimit.KeyFunc) *ratelimit.RateLimiter {
+	policyString := os.Getenv(policyEnvName)
+	if len(policyString) == 0 {
+		policyString = defaultPolicy
+	}
+
+	if policyString == "off" {
+		return nil
+	}
+
+	policy, err := ratelimit.ParsePolicy(policyString)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Environment variable '%s' must be set to <limit>/<window> or off", policyEnvName)
+	}
+
+	return ratelimit.NewRateLimiter(name, *policy, keyFunc)
+}
+
+func rateLimit(rateLimiter *ratelimit.RateLimiter, handler http.HandlerFunc) http.HandlerFunc {
+	if rateLimiter == nil {
+		return handler
+	}
+
+	return rateLimiter.LimitFunc(handler)
+}
+
+// getIntEnv returns the integer value of the environment variable, or defaultValue if it isn't set.
+func getIntEnv(name string, defaultValue int) int {
+	value := os.Getenv(name)
+	if len(value) == 0 {
+		return defaultValue
+	}
+
+	intValue, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Environment variable '%s' must be set to an integer", name)
+	}
+
+	return intValue
+}
+
+func initFirestore(ctx context.Context) *gcpfirestore.Client {
+	firestoreProjectId := os.Getenv("FIRESTORE_PROJECT_ID")
+	if len(firestoreProjectId) == 0 {
+		log.Fatal().Msg("Environment variable 'FIRESTORE_PROJECT_ID' must be set and not be empty")
+	}
+
+	firestoreClient, err := firestore.InitFirestore(ctx, firestoreProjectId)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error initializing the Firestore client")
+	}
+
+	return firestoreClient
+}
+
+func initPostgres(ctx context.Context) *pgxpool.Pool {
+	postgresUrl := os.Getenv("POSTGRES_URL")
+	if len(postgresUrl) == 0 {
+		log.Fatal().Msg("Environment variable 'POSTGRES_URL' must be set and not be empty")
+	}
+
+	pool, err := postgres.InitPostgres(ctx, postgresUrl)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error initializing the Postgres connection pool")
+	}
+
+	return pool
+}
@@ -0,0 +1,35 @@
+package auth
+
+import "time"
+
+// apiKeyScopes are the scopes API keys can be given. ReadScope lets keys make safe requests, such
+// as GETs, and WriteScope every other request.
+var apiKeyScopes = []string{ReadScope, WriteScope}
+
+// ApiKey is a long-lived key a user creates for automation. Only the hash of the key is stored,
+// and its Id is the prefix of the key, which keys are looked up by.
+type ApiKey struct {
+	Id        string
+	KeyHash   string
+	UserId    string
+	Name      string
+	Scopes    []string
+	CreatedAt time.Time
+	ExpiresAt *time.Time
+}
+
+func NewApiKey(id string, keyHash string, userId string, name string, scopes []string, createdAt time.Time, expiresAt *time.Time) ApiKey {
+	return ApiKey{
+		Id:        id,
+		KeyHash:   keyHash,
+		UserId:    userId,
+		Name:      name,
+		Scopes:    scopes,
+		CreatedAt: createdAt,
+		ExpiresAt: expiresAt,
+	}
+}
+
+func (k *ApiKey) HasScope(scope string) bool {
+	return contains(k.Scopes, scope)
+}
@@ -0,0 +1,10 @@
+package auth
+
+import "context"
+
+type ApiKeyRepository interface {
+	Create(ctx context.Context, apiKey ApiKey) error
+	GetById(ctx context.Context, id string) (*ApiKey, error)
+	ListByUserId(ctx context.Context, userId string) ([]ApiKey, error)
+	Delete(ctx context.Context, userId string, id string) error
+}
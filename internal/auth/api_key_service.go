@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+)
+
+const (
+	// apiKeyPrefix tells API keys apart from JWTs, and makes leaked keys easy to scan for.
+	apiKeyPrefix        = "ck_"
+	maxApiKeyNameLength = 64
+)
+
+type ApiKeyService struct {
+	ApiKeyRepository ApiKeyRepository
+}
+
+func NewApiKeyService(apiKeyRepository ApiKeyRepository) ApiKeyService {
+	return ApiKeyService{
+		ApiKeyRepository: apiKeyRepository,
+	}
+}
+
+// IsApiKey tells whether the token presented in the Authorization header is an API key.
+func IsApiKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix)
+}
+
+// CreateKey creates an API key for the user, with every scope when none is given, and returns
+// the key, which can't be retrieved again.
+func (s *ApiKeyService) CreateKey(ctx context.Context, userId string, name string, scopes []string, expiresAt *time.Time) (*ApiKey, *string, error) {
+	name = strings.TrimSpace(name)
+	if len(name) == 0 {
+		return nil, nil, &custom_errors.InvalidArgumentError{Message: "API key name must not be empty"}
+	}
+	if len(name) > maxApiKeyNameLength {
+		return nil, nil, &custom_errors.InvalidArgumentError{Message: fmt.Sprintf("API key name must contain at most %d characters", maxApiKeyNameLength)}
+	}
+
+	validScopes, err := parseApiKeyScopes(scopes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+
+	if expiresAt != nil && !expiresAt.After(now) {
+		return nil, nil, &custom_errors.InvalidArgumentError{Message: "API key expiry must be in the future"}
+	}
+
+	idBytes := make([]byte, 8)
+	_, err = rand.Read(idBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	id := hex.EncodeToString(idBytes)
+
+	secret, err := NewOpaqueToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := fmt.Sprintf("%s%s_%s", apiKeyPrefix, id, *secret)
+
+	apiKey := NewApiKey(id, HashOpaqueToken(key), userId, name, validScopes, now, expiresAt)
+
+	err = s.ApiKeyRepository.Create(ctx, apiKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &apiKey, &key, nil
+}
+
+func (s *ApiKeyService) ListKeys(ctx context.Context, userId string) ([]ApiKey, error) {
+	return s.ApiKeyRepository.ListByUserId(ctx, userId)
+}
+
+func (s *ApiKeyService) DeleteKey(ctx context.Context, userId string, id string) error {
+	return s.ApiKeyRepository.Delete(ctx, userId, id)
+}
+
+// Authenticate returns the API key of the key presented by a client, which must be unexpired.
+func (s *ApiKeyService) Authenticate(ctx context.Context, key string) (*ApiKey, error) {
+	invalidApiKeyErr := &custom_errors.UnauthenticatedError{Message: "Invalid API key"}
+
+	id, _, ok := strings.Cut(strings.TrimPrefix(key, apiKeyPrefix), "_")
+	if !IsApiKey(key) || !ok {
+		return nil, invalidApiKeyErr
+	}
+
+	apiKey, err := s.ApiKeyRepository.GetById(ctx, id)
+	if err != nil {
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			return nil, invalidApiKeyErr
+		}
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(HashOpaqueToken(key)), []byte(apiKey.KeyHash)) != 1 {
+		return nil, invalidApiKeyErr
+	}
+
+	if apiKey.ExpiresAt != nil && !time.Now().Before(*apiKey.ExpiresAt) {
+		return nil, &custom_errors.UnauthenticatedError{Message: "API key has expired"}
+	}
+
+	return apiKey, nil
+}
+
+// parseApiKeyScopes returns the scopes deduplicated and in a stable order.
+func parseApiKeyScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return append([]string{}, apiKeyScopes...), nil
+	}
+
+	for _, scope := range scopes {
+		if !contains(apiKeyScopes, scope) {
+			return nil, &custom_errors.InvalidArgumentError{Message: fmt.Sprintf("API key scope must be one of %s, got '%s'", strings.Join(apiKeyScopes, ", "), scope)}
+		}
+	}
+
+	validScopes := []string{}
+	for _, apiKeyScope := range apiKeyScopes {
+		if contains(scopes, apiKeyScope) {
+			validScopes = append(validScopes, apiKeyScope)
+		}
+	}
+
+	return validScopes, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
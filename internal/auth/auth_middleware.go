@@ -2,51 +2,225 @@ package auth
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"strings"
 
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
 	"github.com/rs/zerolog/log"
 )
 
 type AuthMiddleware struct {
-	JwtService JwtService
+	JwtService             JwtService
+	TokenRevocationService TokenRevocationService
+	ApiKeyService          ApiKeyService
 }
 
-func NewAuthMiddleware(jwtService JwtService) AuthMiddleware {
+func NewAuthMiddleware(jwtService JwtService, tokenRevocationService TokenRevocationService, apiKeyService ApiKeyService) AuthMiddleware {
 	return AuthMiddleware{
-		JwtService: jwtService,
+		JwtService:             jwtService,
+		TokenRevocationService: tokenRevocationService,
+		ApiKeyService:          apiKeyService,
 	}
 }
 
+type userIdContextKey int
+
+// UserIdContextKey holds the authenticated user's id, or an empty string for tokens issued before
+// user ids were subjects, which must be resolved by UsernameContextKey instead.
+const UserIdContextKey userIdContextKey = 0
+
 type usernameContextKey int
 
 const UsernameContextKey usernameContextKey = 0
 
+type tokenContextKey int
+
+const TokenContextKey tokenContextKey = 0
+
+type claimsContextKey int
+
+const ClaimsContextKey claimsContextKey = 0
+
+type apiKeyContextKey int
+
+// ApiKeyContextKey holds the *ApiKey of requests authenticated with an API key, which have no
+// claims.
+const ApiKeyContextKey apiKeyContextKey = 0
+
+type rolesContextKey int
+
+// RolesContextKey holds the roles of the authenticated user, which API keys don't carry.
+const RolesContextKey rolesContextKey = 0
+
+type scopesContextKey int
+
+// ScopesContextKey holds the scopes of the presented token or API key.
+const ScopesContextKey scopesContextKey = 0
+
+type readOnlyContextKey int
+
+const readOnlyKey readOnlyContextKey = 0
+
+// Authenticate rejects requests without a valid, unrevoked token or an unexpired API key. Tokens
+// and API keys need the read scope for safe methods, and the write scope for the others.
 func (h AuthMiddleware) Authenticate(next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		const bearerScheme string = "Bearer "
+		token, ok := getAuthorizationToken(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
 
-		auth := r.Header.Get("Authorization")
-		if len(auth) == 0 {
-			auth = r.Header.Get("authorization")
+		var rWithUser *http.Request
+		var statusCode int
+		if IsApiKey(token) {
+			rWithUser, statusCode = h.withApiKey(r, token)
+		} else {
+			rWithUser, statusCode = h.withClaims(r, token)
+		}
+		if statusCode == http.StatusForbidden {
+			forbidden(w, fmt.Sprintf("Requires the scopes %s", requiredScope(r)))
+			return
+		}
+		if statusCode != http.StatusOK {
+			http.Error(w, http.StatusText(statusCode), statusCode)
+			return
 		}
 
-		if !strings.HasPrefix(auth, bearerScheme) {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		next.ServeHTTP(w, rWithUser)
+	})
+}
+
+// AuthenticateSession is Authenticate for routes managing the user's credentials and sessions,
+// which API keys can't be used for, so that a leaked key can't take the account over.
+func (h AuthMiddleware) AuthenticateSession(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := getAuthorizationToken(r)
+		if ok && IsApiKey(token) {
+			forbidden(w, "API keys can't be used for this route")
 			return
 		}
 
-		token := auth[len(bearerScheme):]
-		claims, err := h.JwtService.GetClaims(token)
-		if err != nil {
-			log.Error().Err(err).Msg("Error getting JWT Token claims")
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		h.Authenticate(next).ServeHTTP(w, r)
+	})
+}
+
+// OptionalAuthenticate lets anonymous requests through without the context values set by
+// Authenticate. Requests presenting an invalid or revoked token are still rejected.
+func (h AuthMiddleware) OptionalAuthenticate(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.Header.Get("Authorization")) == 0 {
+			next.ServeHTTP(w, r)
 			return
 		}
 
-		username := claims.Subject
-		ctxWithUsername := context.WithValue(r.Context(), UsernameContextKey, username)
-		rWithUsername := r.WithContext(ctxWithUsername)
-		next.ServeHTTP(w, rWithUsername)
+		h.Authenticate(next).ServeHTTP(w, r)
 	})
 }
+
+// getAuthorizationToken accepts both the RealWorld "Token" scheme and the standard "Bearer"
+// scheme, case-insensitively.
+func getAuthorizationToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(r.Header.Get("Authorization")), " ")
+	if !ok {
+		return "", false
+	}
+
+	if !strings.EqualFold(scheme, "Bearer") && !strings.EqualFold(scheme, "Token") {
+		return "", false
+	}
+
+	return strings.TrimSpace(token), true
+}
+
+func (h AuthMiddleware) withClaims(r *http.Request, token string) (*http.Request, int) {
+	claims, err := h.JwtService.GetClaims(token)
+	if err != nil {
+		log.Error().Err(err).Msg("Error getting JWT Token claims")
+		return nil, http.StatusUnauthorized
+	}
+
+	isRevoked, err := h.TokenRevocationService.IsRevoked(r.Context(), claims)
+	if err != nil {
+		log.Error().Err(err).Msg("Error checking whether the JWT Token is revoked")
+		return nil, http.StatusInternalServerError
+	}
+	if isRevoked {
+		log.Error().Msgf("JWT Token %s has been revoked", claims.Id)
+		return nil, http.StatusUnauthorized
+	}
+
+	if scope := requiredScope(r); len(scope) > 0 && !contains(claims.GetScopes(), scope) {
+		log.Error().Msgf("JWT Token %s has no %s scope", claims.Id, scope)
+		return nil, http.StatusForbidden
+	}
+
+	userId := claims.Subject
+	username := claims.Username
+	if claims.IsLegacySubject() {
+		userId = ""
+		username = claims.Subject
+	}
+
+	ctxWithUserId := context.WithValue(r.Context(), UserIdContextKey, userId)
+	ctxWithUsername := context.WithValue(ctxWithUserId, UsernameContextKey, username)
+	ctxWithToken := context.WithValue(ctxWithUsername, TokenContextKey, token)
+	ctxWithClaims := context.WithValue(ctxWithToken, ClaimsContextKey, claims)
+	ctxWithRoles := context.WithValue(ctxWithClaims, RolesContextKey, claims.GetRoles())
+	ctxWithScopes := context.WithValue(ctxWithRoles, ScopesContextKey, claims.GetScopes())
+	return r.WithContext(ctxWithScopes), http.StatusOK
+}
+
+// withApiKey sets the same context values as withClaims but the claims, with an empty username as
+// keys only know their user's id, and no roles, so that keys can't be used for privileged routes.
+func (h AuthMiddleware) withApiKey(r *http.Request, key string) (*http.Request, int) {
+	apiKey, err := h.ApiKeyService.Authenticate(r.Context(), key)
+	if err != nil {
+		log.Error().Err(err).Msg("Error authenticating API key")
+		if _, ok := err.(*custom_errors.UnauthenticatedError); ok {
+			return nil, http.StatusUnauthorized
+		}
+		return nil, http.StatusInternalServerError
+	}
+
+	if scope := requiredScope(r); len(scope) > 0 && !apiKey.HasScope(scope) {
+		log.Error().Msgf("API key %s of User %s has no %s scope", apiKey.Id, apiKey.UserId, scope)
+		return nil, http.StatusForbidden
+	}
+
+	ctxWithUserId := context.WithValue(r.Context(), UserIdContextKey, apiKey.UserId)
+	ctxWithUsername := context.WithValue(ctxWithUserId, UsernameContextKey, "")
+	ctxWithToken := context.WithValue(ctxWithUsername, TokenContextKey, key)
+	ctxWithApiKey := context.WithValue(ctxWithToken, ApiKeyContextKey, apiKey)
+	ctxWithRoles := context.WithValue(ctxWithApiKey, RolesContextKey, []string{})
+	ctxWithScopes := context.WithValue(ctxWithRoles, ScopesContextKey, apiKey.Scopes)
+	return r.WithContext(ctxWithScopes), http.StatusOK
+}
+
+// ReadOnly marks the requests of routes which only release what the scopes of the token allow
+// whatever their method, such as the userinfo endpoint, so that tokens without the read and write
+// scopes are let through. It must run before Authenticate.
+func ReadOnly(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), readOnlyKey, true)))
+	})
+}
+
+// requiredScope returns the scope the token or API key must have for the request, which is empty
+// for ReadOnly routes.
+func requiredScope(r *http.Request) string {
+	if readOnly, _ := r.Context().Value(readOnlyKey).(bool); readOnly {
+		return ""
+	}
+
+	if isSafeMethod(r.Method) {
+		return ReadScope
+	}
+
+	return WriteScope
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestAuthMiddleware(jwtService JwtService) AuthMiddleware {
+	return NewAuthMiddleware(jwtService, NewTokenRevocationService(NewInMemoryTokenRevocationRepository(), 10), NewApiKeyService(NewInMemoryApiKeyRepository()))
+}
+
+func serveTestRequest(handler http.HandlerFunc, method string, token string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, "/user", nil)
+	if len(token) > 0 {
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+	recorder := httptest.NewRecorder()
+
+	handler(recorder, request)
+
+	return recorder
+}
+
+func noContent(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestGivenTokenWithoutReadScopeWhenAuthenticateSafeRequestShouldReturnForbidden(t *testing.T) {
+	dir := t.TempDir()
+	writeTestKey(t, dir, "key", time.Now())
+	jwtService := newTestJwtService(t, DirKeyRingLoader(dir, "", 0))
+
+	token, err := jwtService.GenerateScopedToken("id", "username", []string{"openid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authMiddleware := newTestAuthMiddleware(jwtService)
+
+	recorder := serveTestRequest(authMiddleware.Authenticate(noContent), http.MethodGet, *token)
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("got %d, want %d", recorder.Code, http.StatusForbidden)
+	}
+
+	recorder = serveTestRequest(ReadOnly(authMiddleware.Authenticate(noContent)), http.MethodPost, *token)
+	if recorder.Code != http.StatusNoContent {
+		t.Fatalf("got %d, want %d", recorder.Code, http.StatusNoContent)
+	}
+}
+
+func TestGivenTokenWithReadScopeWhenAuthenticateShouldOnlyLetSafeRequestsThrough(t *testing.T) {
+	dir := t.TempDir()
+	writeTestKey(t, dir, "key", time.Now())
+	jwtService := newTestJwtService(t, DirKeyRingLoader(dir, "", 0))
+
+	token, err := jwtService.GenerateScopedToken("id", "username", []string{ReadScope})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authMiddleware := newTestAuthMiddleware(jwtService)
+
+	recorder := serveTestRequest(authMiddleware.Authenticate(noContent), http.MethodGet, *token)
+	if recorder.Code != http.StatusNoContent {
+		t.Fatalf("got %d, want %d", recorder.Code, http.StatusNoContent)
+	}
+
+	recorder = serveTestRequest(authMiddleware.Authenticate(noContent), http.MethodPut, *token)
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("got %d, want %d", recorder.Code, http.StatusForbidden)
+	}
+}
+
+func TestGivenRequestIsNotAuthenticatedWhenRequireRoleShouldReturnUnauthorizedErrorEnvelope(t *testing.T) {
+	handler := RequireRole(RoleAdmin)(http.HandlerFunc(noContent)).ServeHTTP
+
+	recorder := serveTestRequest(handler, http.MethodGet, "")
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", recorder.Code, http.StatusUnauthorized)
+	}
+
+	var responseBody struct {
+		Errors struct {
+			Body []string `json:"body"`
+		} `json:"errors"`
+	}
+
+	err := json.NewDecoder(recorder.Body).Decode(&responseBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(responseBody.Errors.Body) != 1 {
+		t.Fatalf("got %v, want one error", responseBody.Errors.Body)
+	}
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type clientIpContextKey int
+
+const ClientIpContextKey clientIpContextKey = 0
+
+// ClientIpResolver resolves the IP address requests were sent from. Requests from
+// TrustedProxies are attributed to the rightmost address of their X-Forwarded-For header that
+// isn't a trusted proxy, as the addresses to its left may be forged by the client.
+type ClientIpResolver struct {
+	TrustedProxies []*net.IPNet
+}
+
+func NewClientIpResolver(trustedProxies []*net.IPNet) ClientIpResolver {
+	return ClientIpResolver{
+		TrustedProxies: trustedProxies,
+	}
+}
+
+// ParseTrustedProxies parses a comma separated list of IP addresses and CIDR ranges.
+func ParseTrustedProxies(trustedProxies string) ([]*net.IPNet, error) {
+	ipNets := []*net.IPNet{}
+
+	for _, trustedProxy := range strings.Split(trustedProxies, ",") {
+		trustedProxy = strings.TrimSpace(trustedProxy)
+		if len(trustedProxy) == 0 {
+			continue
+		}
+
+		if !strings.Contains(trustedProxy, "/") {
+			ip := net.ParseIP(trustedProxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %s", trustedProxy)
+			}
+
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			trustedProxy = fmt.Sprintf("%s/%d", trustedProxy, bits)
+		}
+
+		_, ipNet, err := net.ParseCIDR(trustedProxy)
+		if err != nil {
+			return nil, err
+		}
+
+		ipNets = append(ipNets, ipNet)
+	}
+
+	return ipNets, nil
+}
+
+// Middleware sets ClientIpContextKey, which ClientIp reads.
+func (c ClientIpResolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), ClientIpContextKey, c.resolve(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (c ClientIpResolver) resolve(r *http.Request) string {
+	clientIp := remoteIp(r)
+
+	if !c.isTrustedProxy(clientIp) {
+		return clientIp
+	}
+
+	forwardedFor := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwardedFor) - 1; i >= 0; i-- {
+		forwardedIp := strings.TrimSpace(forwardedFor[i])
+		if net.ParseIP(forwardedIp) == nil {
+			break
+		}
+
+		clientIp = forwardedIp
+		if !c.isTrustedProxy(clientIp) {
+			break
+		}
+	}
+
+	return clientIp
+}
+
+func (c ClientIpResolver) isTrustedProxy(ip string) bool {
+	parsedIp := net.ParseIP(ip)
+	if parsedIp == nil {
+		return false
+	}
+
+	for _, trustedProxy := range c.TrustedProxies {
+		if trustedProxy.Contains(parsedIp) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ClientIp returns the IP address set by ClientIpResolver's Middleware or, when it's not in use,
+// the address of the peer.
+func ClientIp(r *http.Request) string {
+	if clientIp, ok := r.Context().Value(ClientIpContextKey).(string); ok {
+		return clientIp
+	}
+
+	return remoteIp(r)
+}
+
+func remoteIp(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
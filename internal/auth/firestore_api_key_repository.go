@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type FirestoreApiKeyRepository struct {
+	Firestore *firestore.Client
+}
+
+func NewFirestoreApiKeyRepository(firestore *firestore.Client) *FirestoreApiKeyRepository {
+	return &FirestoreApiKeyRepository{
+		Firestore: firestore,
+	}
+}
+
+const apiKeysCollectionName = "api_keys"
+
+type apiKeyDocData struct {
+	KeyHash   string     `firestore:"key_hash"`
+	UserId    string     `firestore:"user_id"`
+	Name      string     `firestore:"name"`
+	Scopes    []string   `firestore:"scopes"`
+	CreatedAt time.Time  `firestore:"created_at"`
+	ExpiresAt *time.Time `firestore:"expires_at"`
+}
+
+func (r *FirestoreApiKeyRepository) Create(ctx context.Context, apiKey ApiKey) error {
+	apiKeyData := apiKeyDocData{
+		KeyHash:   apiKey.KeyHash,
+		UserId:    apiKey.UserId,
+		Name:      apiKey.Name,
+		Scopes:    apiKey.Scopes,
+		CreatedAt: apiKey.CreatedAt,
+		ExpiresAt: apiKey.ExpiresAt,
+	}
+
+	_, err := r.Firestore.Collection(apiKeysCollectionName).Doc(apiKey.Id).Create(ctx, apiKeyData)
+	if err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			return &custom_errors.AlreadyExistsError{Message: "API key already exists"}
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *FirestoreApiKeyRepository) GetById(ctx context.Context, id string) (*ApiKey, error) {
+	apiKeyDocSnapshot, err := r.Firestore.Collection(apiKeysCollectionName).Doc(id).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, &custom_errors.NotFoundError{Message: "API key not found"}
+		}
+		return nil, err
+	}
+
+	return apiKeyFromDocSnapshot(apiKeyDocSnapshot)
+}
+
+// ListByUserId sorts the keys itself, as ordering the query would need a composite index.
+func (r *FirestoreApiKeyRepository) ListByUserId(ctx context.Context, userId string) ([]ApiKey, error) {
+	apiKeyDocSnapshots, err := r.Firestore.Collection(apiKeysCollectionName).Where("user_id", "==", userId).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys := []ApiKey{}
+	for _, apiKeyDocSnapshot := range apiKeyDocSnapshots {
+		apiKey, err := apiKeyFromDocSnapshot(apiKeyDocSnapshot)
+		if err != nil {
+			return nil, err
+		}
+		apiKeys = append(apiKeys, *apiKey)
+	}
+
+	sort.Slice(apiKeys, func(i, j int) bool {
+		return apiKeys[i].CreatedAt.Before(apiKeys[j].CreatedAt)
+	})
+
+	return apiKeys, nil
+}
+
+func (r *FirestoreApiKeyRepository) Delete(ctx context.Context, userId string, id string) error {
+	apiKeyDocRef := r.Firestore.Collection(apiKeysCollectionName).Doc(id)
+
+	err := r.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		apiKeyDocSnapshot, err := tx.Get(apiKeyDocRef)
+		if err != nil {
+			return err
+		}
+
+		apiKeyData := apiKeyDocData{}
+		err = apiKeyDocSnapshot.DataTo(&apiKeyData)
+		if err != nil {
+			return err
+		}
+
+		if apiKeyData.UserId != userId {
+			return &custom_errors.NotFoundError{Message: "API key not found"}
+		}
+
+		return tx.Delete(apiKeyDocRef)
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return &custom_errors.NotFoundError{Message: "API key not found"}
+		}
+		return err
+	}
+
+	return nil
+}
+
+func apiKeyFromDocSnapshot(apiKeyDocSnapshot *firestore.DocumentSnapshot) (*ApiKey, error) {
+	apiKeyData := apiKeyDocData{}
+	err := apiKeyDocSnapshot.DataTo(&apiKeyData)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey := NewApiKey(apiKeyDocSnapshot.Ref.ID, apiKeyData.KeyHash, apiKeyData.UserId, apiKeyData.Name, apiKeyData.Scopes, apiKeyData.CreatedAt, apiKeyData.ExpiresAt)
+
+	return &apiKey, nil
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type FirestoreLoginAttemptsRepository struct {
+	Firestore *firestore.Client
+}
+
+func NewFirestoreLoginAttemptsRepository(firestore *firestore.Client) *FirestoreLoginAttemptsRepository {
+	return &FirestoreLoginAttemptsRepository{
+		Firestore: firestore,
+	}
+}
+
+const loginAttemptsCollectionName = "login_attempts"
+
+type loginAttemptsDocData struct {
+	Failures      int       `firestore:"failures"`
+	LastFailureAt time.Time `firestore:"last_failure_at"`
+	ExpiresAt     time.Time `firestore:"expires_at"`
+}
+
+func (r *FirestoreLoginAttemptsRepository) Update(ctx context.Context, key string, update func(loginAttempts *LoginAttempts) *LoginAttempts) error {
+	loginAttemptsDocRef := r.Firestore.Collection(loginAttemptsCollectionName).Doc(key)
+
+	return r.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		var currentLoginAttempts *LoginAttempts
+
+		loginAttemptsDocSnapshot, err := tx.Get(loginAttemptsDocRef)
+		if err != nil {
+			if status.Code(err) != codes.NotFound {
+				return err
+			}
+		} else {
+			loginAttemptsData := loginAttemptsDocData{}
+			err = loginAttemptsDocSnapshot.DataTo(&loginAttemptsData)
+			if err != nil {
+				return err
+			}
+
+			loginAttempts := NewLoginAttempts(key, loginAttemptsData.Failures, loginAttemptsData.LastFailureAt, loginAttemptsData.ExpiresAt)
+			currentLoginAttempts = &loginAttempts
+		}
+
+		updatedLoginAttempts := update(currentLoginAttempts)
+		if updatedLoginAttempts == nil {
+			if currentLoginAttempts == nil {
+				return nil
+			}
+			return tx.Delete(loginAttemptsDocRef)
+		}
+
+		return tx.Set(loginAttemptsDocRef, loginAttemptsDocData{
+			Failures:      updatedLoginAttempts.Failures,
+			LastFailureAt: updatedLoginAttempts.LastFailureAt,
+			ExpiresAt:     updatedLoginAttempts.ExpiresAt,
+		})
+	})
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type FirestoreRefreshTokenRepository struct {
+	Firestore *firestore.Client
+}
+
+func NewFirestoreRefreshTokenRepository(firestore *firestore.Client) *FirestoreRefreshTokenRepository {
+	return &FirestoreRefreshTokenRepository{
+		Firestore: firestore,
+	}
+}
+
+const refreshTokensCollectionName = "refresh_tokens"
+
+// firestoreMaxBatchWrites is the most writes a Firestore batch can commit.
+const firestoreMaxBatchWrites = 500
+
+type refreshTokenDocData struct {
+	FamilyId  string     `firestore:"family_id"`
+	UserId    string     `firestore:"user_id"`
+	CreatedAt time.Time  `firestore:"created_at"`
+	ExpiresAt time.Time  `firestore:"expires_at"`
+	UsedAt    *time.Time `firestore:"used_at"`
+	RevokedAt *time.Time `firestore:"revoked_at"`
+}
+
+func (r *FirestoreRefreshTokenRepository) Create(ctx context.Context, refreshToken RefreshToken) error {
+	refreshTokenData := refreshTokenDocData{
+		FamilyId:  refreshToken.FamilyId,
+		UserId:    refreshToken.UserId,
+		CreatedAt: refreshToken.CreatedAt,
+		ExpiresAt: refreshToken.ExpiresAt,
+		UsedAt:    refreshToken.UsedAt,
+		RevokedAt: refreshToken.RevokedAt,
+	}
+
+	_, err := r.Firestore.Collection(refreshTokensCollectionName).Doc(refreshToken.TokenHash).Create(ctx, refreshTokenData)
+	if err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			return &custom_errors.AlreadyExistsError{Message: "Refresh token already exists"}
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *FirestoreRefreshTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	refreshTokenDocSnapshot, err := r.Firestore.Collection(refreshTokensCollectionName).Doc(tokenHash).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, &custom_errors.NotFoundError{Message: "Refresh token not found"}
+		}
+		return nil, err
+	}
+
+	return refreshTokenFromDocSnapshot(refreshTokenDocSnapshot)
+}
+
+func (r *FirestoreRefreshTokenRepository) MarkUsed(ctx context.Context, tokenHash string, usedAt time.Time) (bool, error) {
+	refreshTokenDocRef := r.Firestore.Collection(refreshTokensCollectionName).Doc(tokenHash)
+
+	marked := false
+	err := r.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		marked = false
+
+		refreshTokenDocSnapshot, err := tx.Get(refreshTokenDocRef)
+		if err != nil {
+			return err
+		}
+
+		refreshTokenData := refreshTokenDocData{}
+		err = refreshTokenDocSnapshot.DataTo(&refreshTokenData)
+		if err != nil {
+			return err
+		}
+
+		if refreshTokenData.UsedAt != nil {
+			return nil
+		}
+
+		marked = true
+		return tx.Update(refreshTokenDocRef, []firestore.Update{{Path: "used_at", Value: usedAt}})
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return false, &custom_errors.NotFoundError{Message: "Refresh token not found"}
+		}
+		return false, err
+	}
+
+	return marked, nil
+}
+
+func (r *FirestoreRefreshTokenRepository) RevokeFamily(ctx context.Context, familyId string, revokedAt time.Time) error {
+	return r.revokeWhere(ctx, "family_id", familyId, revokedAt)
+}
+
+func (r *FirestoreRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userId string, revokedAt time.Time) error {
+	return r.revokeWhere(ctx, "user_id", userId, revokedAt)
+}
+
+// revokeWhere revokes the unrevoked tokens matching the field, and deletes the expired ones as
+// they can't be refreshed anymore, rather than keep matching them. Writes are committed in
+// batches of at most firestoreMaxBatchWrites, as rotated tokens pile up until they expire.
+func (r *FirestoreRefreshTokenRepository) revokeWhere(ctx context.Context, field string, value string, revokedAt time.Time) error {
+	query := r.Firestore.Collection(refreshTokensCollectionName).Where(field, "==", value).Where("revoked_at", "==", nil)
+	refreshTokenDocSnapshots, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return err
+	}
+
+	batch := r.Firestore.Batch()
+	writes := 0
+
+	for _, refreshTokenDocSnapshot := range refreshTokenDocSnapshots {
+		refreshTokenData := refreshTokenDocData{}
+		err = refreshTokenDocSnapshot.DataTo(&refreshTokenData)
+		if err != nil {
+			return err
+		}
+
+		if revokedAt.Before(refreshTokenData.ExpiresAt) {
+			batch.Update(refreshTokenDocSnapshot.Ref, []firestore.Update{{Path: "revoked_at", Value: revokedAt}})
+		} else {
+			batch.Delete(refreshTokenDocSnapshot.Ref)
+		}
+		writes++
+
+		if writes == firestoreMaxBatchWrites {
+			_, err = batch.Commit(ctx)
+			if err != nil {
+				return err
+			}
+
+			batch = r.Firestore.Batch()
+			writes = 0
+		}
+	}
+
+	if writes == 0 {
+		return nil
+	}
+
+	_, err = batch.Commit(ctx)
+	return err
+}
+
+func refreshTokenFromDocSnapshot(refreshTokenDocSnapshot *firestore.DocumentSnapshot) (*RefreshToken, error) {
+	refreshTokenData := refreshTokenDocData{}
+	err := refreshTokenDocSnapshot.DataTo(&refreshTokenData)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken := NewRefreshToken(refreshTokenDocSnapshot.Ref.ID, refreshTokenData.FamilyId, refreshTokenData.UserId, refreshTokenData.CreatedAt, refreshTokenData.ExpiresAt, refreshTokenData.UsedAt, refreshTokenData.RevokedAt)
+
+	return &refreshToken, nil
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type FirestoreTokenRevocationRepository struct {
+	Firestore *firestore.Client
+}
+
+func NewFirestoreTokenRevocationRepository(firestore *firestore.Client) *FirestoreTokenRevocationRepository {
+	return &FirestoreTokenRevocationRepository{
+		Firestore: firestore,
+	}
+}
+
+const (
+	revokedTokensCollectionName      = "revoked_tokens"
+	subjectRevocationsCollectionName = "subject_revocations"
+)
+
+type revokedTokenDocData struct {
+	ExpiresAt time.Time `firestore:"expires_at"`
+}
+
+type subjectRevocationDocData struct {
+	Subject       string    `firestore:"subject"`
+	RevokedBefore time.Time `firestore:"revoked_before"`
+}
+
+func (r *FirestoreTokenRevocationRepository) RevokeToken(ctx context.Context, tokenId string, expiresAt time.Time) error {
+	_, err := r.Firestore.Collection(revokedTokensCollectionName).Doc(tokenId).Set(ctx, revokedTokenDocData{ExpiresAt: expiresAt})
+	return err
+}
+
+func (r *FirestoreTokenRevocationRepository) IsTokenRevoked(ctx context.Context, tokenId string) (bool, error) {
+	_, err := r.Firestore.Collection(revokedTokensCollectionName).Doc(tokenId).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *FirestoreTokenRevocationRepository) RevokeSubject(ctx context.Context, subject string, revokedBefore time.Time) error {
+	subjectRevocationDocRef := r.subjectRevocationDocRef(subject)
+
+	return r.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		subjectRevocationDocSnapshot, err := tx.Get(subjectRevocationDocRef)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+
+		if subjectRevocationDocSnapshot.Exists() {
+			subjectRevocationData := subjectRevocationDocData{}
+			err = subjectRevocationDocSnapshot.DataTo(&subjectRevocationData)
+			if err != nil {
+				return err
+			}
+
+			if subjectRevocationData.RevokedBefore.After(revokedBefore) {
+				return nil
+			}
+		}
+
+		return tx.Set(subjectRevocationDocRef, subjectRevocationDocData{Subject: subject, RevokedBefore: revokedBefore})
+	})
+}
+
+func (r *FirestoreTokenRevocationRepository) GetSubjectRevokedBefore(ctx context.Context, subject string) (*time.Time, error) {
+	subjectRevocationDocSnapshot, err := r.subjectRevocationDocRef(subject).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	subjectRevocationData := subjectRevocationDocData{}
+	err = subjectRevocationDocSnapshot.DataTo(&subjectRevocationData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &subjectRevocationData.RevokedBefore, nil
+}
+
+// subjectRevocationDocRef hashes the subject, as it may contain characters that aren't allowed in
+// document ids.
+func (r *FirestoreTokenRevocationRepository) subjectRevocationDocRef(subject string) *firestore.DocumentRef {
+	hash := sha256.Sum256([]byte(subject))
+	return r.Firestore.Collection(subjectRevocationsCollectionName).Doc(hex.EncodeToString(hash[:]))
+}
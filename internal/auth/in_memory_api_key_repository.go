@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+)
+
+type InMemoryApiKeyRepository struct {
+	mu      sync.Mutex
+	apiKeys map[string]ApiKey
+}
+
+func NewInMemoryApiKeyRepository() *InMemoryApiKeyRepository {
+	return &InMemoryApiKeyRepository{
+		apiKeys: map[string]ApiKey{},
+	}
+}
+
+func (r *InMemoryApiKeyRepository) Create(ctx context.Context, apiKey ApiKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.apiKeys[apiKey.Id]; ok {
+		return &custom_errors.AlreadyExistsError{Message: "API key already exists"}
+	}
+
+	r.apiKeys[apiKey.Id] = copyApiKey(apiKey)
+
+	return nil
+}
+
+func (r *InMemoryApiKeyRepository) GetById(ctx context.Context, id string) (*ApiKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	apiKey, ok := r.apiKeys[id]
+	if !ok {
+		return nil, &custom_errors.NotFoundError{Message: "API key not found"}
+	}
+
+	apiKey = copyApiKey(apiKey)
+	return &apiKey, nil
+}
+
+func (r *InMemoryApiKeyRepository) ListByUserId(ctx context.Context, userId string) ([]ApiKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	apiKeys := []ApiKey{}
+	for _, apiKey := range r.apiKeys {
+		if apiKey.UserId == userId {
+			apiKeys = append(apiKeys, copyApiKey(apiKey))
+		}
+	}
+
+	sort.Slice(apiKeys, func(i, j int) bool {
+		return apiKeys[i].CreatedAt.Before(apiKeys[j].CreatedAt)
+	})
+
+	return apiKeys, nil
+}
+
+func (r *InMemoryApiKeyRepository) Delete(ctx context.Context, userId string, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	apiKey, ok := r.apiKeys[id]
+	if !ok || apiKey.UserId != userId {
+		return &custom_errors.NotFoundError{Message: "API key not found"}
+	}
+
+	delete(r.apiKeys, id)
+
+	return nil
+}
+
+func copyApiKey(apiKey ApiKey) ApiKey {
+	apiKey.Scopes = append([]string{}, apiKey.Scopes...)
+	return apiKey
+}
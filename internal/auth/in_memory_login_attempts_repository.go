@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// inMemoryLoginAttemptsSweepInterval is the number of updates between sweeps of the expired
+// attempts, which keeps keys that are never seen again from piling up.
+const inMemoryLoginAttemptsSweepInterval = 1024
+
+type InMemoryLoginAttemptsRepository struct {
+	mu            sync.Mutex
+	loginAttempts map[string]LoginAttempts
+	updates       int
+}
+
+func NewInMemoryLoginAttemptsRepository() *InMemoryLoginAttemptsRepository {
+	return &InMemoryLoginAttemptsRepository{
+		loginAttempts: map[string]LoginAttempts{},
+	}
+}
+
+func (r *InMemoryLoginAttemptsRepository) Update(ctx context.Context, key string, update func(loginAttempts *LoginAttempts) *LoginAttempts) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.updates++
+	if r.updates%inMemoryLoginAttemptsSweepInterval == 0 {
+		r.sweep(time.Now())
+	}
+
+	var currentLoginAttempts *LoginAttempts
+	if loginAttempts, ok := r.loginAttempts[key]; ok {
+		currentLoginAttempts = &loginAttempts
+	}
+
+	updatedLoginAttempts := update(currentLoginAttempts)
+	if updatedLoginAttempts == nil {
+		delete(r.loginAttempts, key)
+	} else {
+		r.loginAttempts[key] = *updatedLoginAttempts
+	}
+
+	return nil
+}
+
+func (r *InMemoryLoginAttemptsRepository) sweep(now time.Time) {
+	for key, loginAttempts := range r.loginAttempts {
+		if !now.Before(loginAttempts.ExpiresAt) {
+			delete(r.loginAttempts, key)
+		}
+	}
+}
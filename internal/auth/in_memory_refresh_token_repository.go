@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+)
+
+type InMemoryRefreshTokenRepository struct {
+	mu            sync.Mutex
+	refreshTokens map[string]RefreshToken
+}
+
+func NewInMemoryRefreshTokenRepository() *InMemoryRefreshTokenRepository {
+	return &InMemoryRefreshTokenRepository{
+		refreshTokens: map[string]RefreshToken{},
+	}
+}
+
+func (r *InMemoryRefreshTokenRepository) Create(ctx context.Context, refreshToken RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.refreshTokens[refreshToken.TokenHash]; ok {
+		return &custom_errors.AlreadyExistsError{Message: "Refresh token already exists"}
+	}
+
+	r.refreshTokens[refreshToken.TokenHash] = refreshToken
+
+	return nil
+}
+
+func (r *InMemoryRefreshTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	refreshToken, ok := r.refreshTokens[tokenHash]
+	if !ok {
+		return nil, &custom_errors.NotFoundError{Message: "Refresh token not found"}
+	}
+
+	return &refreshToken, nil
+}
+
+func (r *InMemoryRefreshTokenRepository) MarkUsed(ctx context.Context, tokenHash string, usedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	refreshToken, ok := r.refreshTokens[tokenHash]
+	if !ok {
+		return false, &custom_errors.NotFoundError{Message: "Refresh token not found"}
+	}
+
+	if refreshToken.UsedAt != nil {
+		return false, nil
+	}
+
+	refreshToken.UsedAt = &usedAt
+	r.refreshTokens[tokenHash] = refreshToken
+
+	return true, nil
+}
+
+func (r *InMemoryRefreshTokenRepository) RevokeFamily(ctx context.Context, familyId string, revokedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for tokenHash, refreshToken := range r.refreshTokens {
+		if refreshToken.FamilyId == familyId && refreshToken.RevokedAt == nil {
+			refreshToken.RevokedAt = &revokedAt
+			r.refreshTokens[tokenHash] = refreshToken
+		}
+	}
+
+	return nil
+}
+
+func (r *InMemoryRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userId string, revokedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for tokenHash, refreshToken := range r.refreshTokens {
+		if refreshToken.UserId == userId && refreshToken.RevokedAt == nil {
+			refreshToken.RevokedAt = &revokedAt
+			r.refreshTokens[tokenHash] = refreshToken
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type InMemoryTokenRevocationRepository struct {
+	mu                    sync.Mutex
+	revokedTokens         map[string]time.Time
+	subjectsRevokedBefore map[string]time.Time
+}
+
+func NewInMemoryTokenRevocationRepository() *InMemoryTokenRevocationRepository {
+	return &InMemoryTokenRevocationRepository{
+		revokedTokens:         map[string]time.Time{},
+		subjectsRevokedBefore: map[string]time.Time{},
+	}
+}
+
+func (r *InMemoryTokenRevocationRepository) RevokeToken(ctx context.Context, tokenId string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for revokedTokenId, revokedTokenExpiresAt := range r.revokedTokens {
+		if !now.Before(revokedTokenExpiresAt) {
+			delete(r.revokedTokens, revokedTokenId)
+		}
+	}
+
+	r.revokedTokens[tokenId] = expiresAt
+
+	return nil
+}
+
+func (r *InMemoryTokenRevocationRepository) IsTokenRevoked(ctx context.Context, tokenId string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.revokedTokens[tokenId]
+
+	return ok, nil
+}
+
+func (r *InMemoryTokenRevocationRepository) RevokeSubject(ctx context.Context, subject string, revokedBefore time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if previousRevokedBefore, ok := r.subjectsRevokedBefore[subject]; ok && previousRevokedBefore.After(revokedBefore) {
+		return nil
+	}
+
+	r.subjectsRevokedBefore[subject] = revokedBefore
+
+	return nil
+}
+
+func (r *InMemoryTokenRevocationRepository) GetSubjectRevokedBefore(ctx context.Context, subject string) (*time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	revokedBefore, ok := r.subjectsRevokedBefore[subject]
+	if !ok {
+		return nil, nil
+	}
+
+	return &revokedBefore, nil
+}
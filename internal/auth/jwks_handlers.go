@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+type JwksHandlers struct {
+	JwtService JwtService
+}
+
+func NewJwksHandlers(jwtService JwtService) JwksHandlers {
+	return JwksHandlers{
+		JwtService: jwtService,
+	}
+}
+
+func (h *JwksHandlers) GetJwks(w http.ResponseWriter, r *http.Request) {
+	response, err := json.Marshal(h.JwtService.GetJwks())
+	if err != nil {
+		log.Error().Err(err).Msg("Error marshalling JWKS")
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.Header().Set("cache-control", "public, max-age=300")
+	w.Write(response)
+}
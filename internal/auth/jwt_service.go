@@ -1,33 +1,133 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 )
 
+// Claims identifies the user by its immutable id in the subject, and carries the username and
+// roles the user had when the token was issued, with the space separated scopes the roles grant.
+// Tokens issued to other apps carry no roles, and only the scopes granted to the app. Tokens
+// issued before user ids were subjects carry no username claim, and their subject is the username.
+// AuthTime and Nonce are claims of OpenID Connect ID tokens, which access tokens never carry.
+type Claims struct {
+	jwt.StandardClaims
+	Username string   `json:"username,omitempty"`
+	Roles    []string `json:"roles,omitempty"`
+	Scope    string   `json:"scope,omitempty"`
+	AuthTime int64    `json:"auth_time,omitempty"`
+	Nonce    string   `json:"nonce,omitempty"`
+}
+
+// IsIdToken tells whether the claims are the ones of an ID token, which is signed with the same key
+// as access tokens, but only proves the user's identity to the client it was issued to.
+func (c *Claims) IsIdToken() bool {
+	return len(c.Audience) > 0 || c.AuthTime != 0 || len(c.Nonce) > 0
+}
+
+func (c *Claims) IsLegacySubject() bool {
+	return len(c.Username) == 0
+}
+
+// GetRoles returns the roles of the token, which are the user role for tokens issued before roles.
+func (c *Claims) GetRoles() []string {
+	if len(c.Roles) == 0 {
+		return []string{RoleUser}
+	}
+	return c.Roles
+}
+
+func (c *Claims) GetScopes() []string {
+	if len(c.Scope) == 0 {
+		return ScopesOfRoles(c.GetRoles())
+	}
+	return strings.Fields(c.Scope)
+}
+
 type JwtService struct {
-	SecretKey       string
+	KeyRing         *KeyRing
 	SecondsToExpire int
 }
 
-func NewJwtService(secretKey string, secondsToExpire int) JwtService {
+func NewJwtService(keyRing *KeyRing, secondsToExpire int) JwtService {
 	return JwtService{
-		SecretKey:       secretKey,
+		KeyRing:         keyRing,
 		SecondsToExpire: secondsToExpire,
 	}
 }
 
-func (s *JwtService) GenerateToken(username string) (*string, error) {
+func (s *JwtService) GenerateToken(userId string, username string, roles []string) (*string, error) {
+	return s.generateToken(userId, username, roles, ScopesOfRoles(roles))
+}
+
+// GenerateScopedToken generates a token with only the scopes, and none of the user's roles, for
+// apps the user signed in to.
+func (s *JwtService) GenerateScopedToken(userId string, username string, scopes []string) (*string, error) {
+	return s.generateToken(userId, username, nil, scopes)
+}
+
+func (s *JwtService) generateToken(userId string, username string, roles []string, scopes []string) (*string, error) {
 	now := time.Now()
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{
-		Subject:   username,
-		IssuedAt:  now.Unix(),
-		ExpiresAt: now.Add(time.Second * time.Duration(s.SecondsToExpire)).Unix(),
+	tokenId, err := newTokenId()
+	if err != nil {
+		return nil, err
+	}
+
+	signingKey := s.KeyRing.SigningKey()
+
+	token := jwt.NewWithClaims(signingKey.SigningMethod, Claims{
+		StandardClaims: jwt.StandardClaims{
+			Id:        *tokenId,
+			Subject:   userId,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(time.Second * time.Duration(s.SecondsToExpire)).Unix(),
+		},
+		Username: username,
+		Roles:    roles,
+		Scope:    strings.Join(scopes, " "),
 	})
 
-	tokenString, err := token.SignedString([]byte(s.SecretKey))
+	if len(signingKey.Id) > 0 {
+		token.Header["kid"] = signingKey.Id
+	}
+
+	tokenString, err := token.SignedString(signingKey.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tokenString, nil
+}
+
+// SignIdToken signs OpenID Connect ID tokens with the signing key when relying parties can verify
+// them with the JWKS. HMAC signing keys can't be shared, so ID tokens are then signed with HS256
+// and the client's secret instead, as OpenID Connect specifies, which public clients don't have.
+func (s *JwtService) SignIdToken(claims jwt.Claims, clientSecret string) (*string, error) {
+	signingKey := s.KeyRing.SigningKey()
+
+	if !signingKey.IsPublishable() {
+		if len(clientSecret) == 0 {
+			return nil, fmt.Errorf("ID tokens of public clients can't be signed with an HMAC signing key")
+		}
+
+		tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(clientSecret))
+		if err != nil {
+			return nil, err
+		}
+
+		return &tokenString, nil
+	}
+
+	token := jwt.NewWithClaims(signingKey.SigningMethod, claims)
+	token.Header["kid"] = signingKey.Id
+
+	tokenString, err := token.SignedString(signingKey.PrivateKey)
 	if err != nil {
 		return nil, err
 	}
@@ -35,14 +135,94 @@ func (s *JwtService) GenerateToken(username string) (*string, error) {
 	return &tokenString, nil
 }
 
-func (s *JwtService) GetClaims(tokenString string) (*jwt.StandardClaims, error) {
-	parsedToken, err := jwt.ParseWithClaims(tokenString, &jwt.StandardClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(s.SecretKey), nil
+// IdTokenSigningAlg is the algorithm SignIdToken signs with.
+func (s *JwtService) IdTokenSigningAlg() string {
+	return s.KeyRing.SigningKey().SigningMethod.Alg()
+}
+
+// GetClaims verifies the token with the key named by its kid header or, for tokens without one,
+// with every verification key of the token's algorithm. ID tokens are rejected, as they aren't
+// access tokens.
+func (s *JwtService) GetClaims(tokenString string) (*Claims, error) {
+	var err error = fmt.Errorf("no verification key for token")
+
+	for _, verificationKey := range s.KeyRing.VerificationKeys() {
+		var claims *Claims
+		claims, err = getClaims(tokenString, verificationKey)
+		if err == nil {
+			return claims, nil
+		}
+
+		if _, ok := err.(*errKeyMismatch); !ok {
+			if validationErr, ok := err.(*jwt.ValidationError); !ok || validationErr.Errors&jwt.ValidationErrorSignatureInvalid == 0 {
+				return nil, err
+			}
+		}
+	}
+
+	return nil, err
+}
+
+func (s *JwtService) GetJwks() Jwks {
+	jwks := Jwks{
+		Keys: []Jwk{},
+	}
+
+	for _, verificationKey := range s.KeyRing.VerificationKeys() {
+		if verificationKey.IsPublishable() {
+			jwks.Keys = append(jwks.Keys, verificationKey.Jwk())
+		}
+	}
+
+	return jwks
+}
+
+type errKeyMismatch struct {
+	message string
+}
+
+func (e *errKeyMismatch) Error() string {
+	return e.message
+}
+
+func getClaims(tokenString string, verificationKey SigningKey) (*Claims, error) {
+	parsedToken, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		if token.Method.Alg() != verificationKey.SigningMethod.Alg() {
+			return nil, &errKeyMismatch{message: fmt.Sprintf("unexpected signing method %s", token.Method.Alg())}
+		}
+
+		if kid, ok := token.Header["kid"].(string); ok && kid != verificationKey.Id {
+			return nil, &errKeyMismatch{message: fmt.Sprintf("unknown key id %s", kid)}
+		}
+
+		return verificationKey.PublicKey, nil
 	})
+	if err != nil {
+		if validationErr, ok := err.(*jwt.ValidationError); ok {
+			if keyMismatchErr, ok := validationErr.Inner.(*errKeyMismatch); ok {
+				return nil, keyMismatchErr
+			}
+		}
+		return nil, err
+	}
 
-	if claims, ok := parsedToken.Claims.(*jwt.StandardClaims); ok && parsedToken.Valid {
+	if claims, ok := parsedToken.Claims.(*Claims); ok && parsedToken.Valid {
+		if claims.IsIdToken() {
+			return nil, fmt.Errorf("ID tokens can't be used as access tokens")
+		}
 		return claims, nil
 	} else {
+		return nil, fmt.Errorf("invalid token")
+	}
+}
+
+func newTokenId() (*string, error) {
+	tokenIdBytes := make([]byte, 16)
+	_, err := rand.Read(tokenIdBytes)
+	if err != nil {
 		return nil, err
 	}
+
+	tokenId := base64.RawURLEncoding.EncodeToString(tokenIdBytes)
+	return &tokenId, nil
 }
@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func TestGivenIdTokenWhenGetClaimsShouldReturnError(t *testing.T) {
+	dir := t.TempDir()
+	writeTestKey(t, dir, "key", time.Now())
+	jwtService := newTestJwtService(t, DirKeyRingLoader(dir, "", 0))
+
+	now := time.Now()
+
+	// The audience is a string, which access token claims can hold, rather than an array.
+	idToken, err := jwtService.SignIdToken(jwt.MapClaims{
+		"sub":       "id",
+		"aud":       "client",
+		"iat":       now.Unix(),
+		"exp":       now.Add(time.Hour).Unix(),
+		"auth_time": now.Unix(),
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = jwtService.GetClaims(*idToken)
+	if err == nil {
+		t.Fatal("err must not be nil")
+	}
+}
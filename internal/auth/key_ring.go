@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// KeyRingLoader returns the key that signs new tokens and the keys that are still accepted when
+// verifying tokens. The signing key doesn't need to be part of the verification keys.
+type KeyRingLoader func() (*SigningKey, []SigningKey, error)
+
+// KeyRing holds one signing key and any number of verification keys, so that signing keys can
+// be rotated without invalidating the tokens signed by the previous ones.
+type KeyRing struct {
+	mu               sync.RWMutex
+	load             KeyRingLoader
+	signingKey       SigningKey
+	verificationKeys []SigningKey
+}
+
+func NewKeyRing(load KeyRingLoader) (*KeyRing, error) {
+	keyRing := &KeyRing{
+		load: load,
+	}
+
+	err := keyRing.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return keyRing, nil
+}
+
+// Reload replaces the keys with freshly loaded ones. The current keys are kept if loading fails.
+func (k *KeyRing) Reload() error {
+	signingKey, verificationKeys, err := k.load()
+	if err != nil {
+		return err
+	}
+
+	if !signingKey.CanSign() {
+		return fmt.Errorf("signing key %s has no private key", signingKey.Id)
+	}
+
+	allVerificationKeys := []SigningKey{*signingKey}
+	for _, verificationKey := range verificationKeys {
+		if len(verificationKey.Id) > 0 && verificationKey.Id == signingKey.Id && verificationKey.SigningMethod == signingKey.SigningMethod {
+			continue
+		}
+		allVerificationKeys = append(allVerificationKeys, verificationKey)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.signingKey = *signingKey
+	k.verificationKeys = allVerificationKeys
+
+	log.Info().Msgf("Loaded %d JWT verification keys, signing with %s key '%s'", len(allVerificationKeys), signingKey.SigningMethod.Alg(), signingKey.Id)
+
+	return nil
+}
+
+func (k *KeyRing) SigningKey() SigningKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.signingKey
+}
+
+func (k *KeyRing) VerificationKeys() []SigningKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	verificationKeys := make([]SigningKey, len(k.verificationKeys))
+	copy(verificationKeys, k.verificationKeys)
+
+	return verificationKeys
+}
+
+func StaticKeyRingLoader(signingKey SigningKey, verificationKeys []SigningKey) KeyRingLoader {
+	return func() (*SigningKey, []SigningKey, error) {
+		return &signingKey, verificationKeys, nil
+	}
+}
+
+// DirKeyRingLoader loads every <kid>.pem file of dir. The signing key is the one with signingKeyId
+// or, if empty, the most recently modified private key, so that keys are rotated by adding a new
+// one and reloading. Keys older than the signing key stop being accepted once the signing key is
+// older than retirement, which should be longer than the tokens' lifetime. Removing a key's file
+// retires it on the next reload.
+func DirKeyRingLoader(dir string, signingKeyId string, retirement time.Duration) KeyRingLoader {
+	return func() (*SigningKey, []SigningKey, error) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		type keyFile struct {
+			key     SigningKey
+			modTime time.Time
+		}
+
+		keyFiles := []keyFile{}
+		for _, entry := range entries {
+			if entry.IsDir() || filepath.Ext(entry.Name()) != ".pem" {
+				continue
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			key, err := LoadKey(filepath.Join(dir, entry.Name()), strings.TrimSuffix(entry.Name(), ".pem"))
+			if err != nil {
+				return nil, nil, err
+			}
+
+			keyFiles = append(keyFiles, keyFile{key: *key, modTime: info.ModTime()})
+		}
+
+		sort.Slice(keyFiles, func(i, j int) bool {
+			if keyFiles[i].modTime.Equal(keyFiles[j].modTime) {
+				return keyFiles[i].key.Id < keyFiles[j].key.Id
+			}
+			return keyFiles[i].modTime.Before(keyFiles[j].modTime)
+		})
+
+		var signingKeyFile *keyFile
+		for i := range keyFiles {
+			if !keyFiles[i].key.CanSign() {
+				continue
+			}
+
+			if len(signingKeyId) == 0 || keyFiles[i].key.Id == signingKeyId {
+				signingKeyFile = &keyFiles[i]
+			}
+		}
+
+		if signingKeyFile == nil {
+			if len(signingKeyId) > 0 {
+				return nil, nil, fmt.Errorf("no private key with id %s in %s", signingKeyId, dir)
+			}
+			return nil, nil, fmt.Errorf("no private key in %s", dir)
+		}
+
+		isRetirementDue := retirement > 0 && time.Since(signingKeyFile.modTime) > retirement
+
+		verificationKeys := []SigningKey{}
+		for _, keyFile := range keyFiles {
+			if isRetirementDue && keyFile.modTime.Before(signingKeyFile.modTime) {
+				continue
+			}
+			verificationKeys = append(verificationKeys, keyFile.key)
+		}
+
+		return &signingKeyFile.key, verificationKeys, nil
+	}
+}
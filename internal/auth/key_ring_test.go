@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestKey writes a new Ed25519 private key to <dir>/<id>.pem, last modified at modTime.
+func writeTestKey(t *testing.T, dir string, id string, modTime time.Time) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%s.pem", id))
+
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Chtimes(path, modTime, modTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestJwtService(t *testing.T, load KeyRingLoader) JwtService {
+	keyRing, err := NewKeyRing(load)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewJwtService(keyRing, 900)
+}
+
+func generateTestToken(t *testing.T, jwtService JwtService) string {
+	token, err := jwtService.GenerateToken("id", "username", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return *token
+}
+
+func TestGivenSigningKeyIdWhenLoadKeysShouldSignWithThatKey(t *testing.T) {
+	dir := t.TempDir()
+	writeTestKey(t, dir, "old", time.Now().Add(-time.Hour))
+	writeTestKey(t, dir, "new", time.Now())
+
+	jwtService := newTestJwtService(t, DirKeyRingLoader(dir, "old", 0))
+
+	if jwtService.KeyRing.SigningKey().Id != "old" {
+		t.Fatalf("got %s, want %s", jwtService.KeyRing.SigningKey().Id, "old")
+	}
+
+	claims, err := jwtService.GetClaims(generateTestToken(t, jwtService))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claims.Subject != "id" {
+		t.Fatalf("got %s, want %s", claims.Subject, "id")
+	}
+}
+
+func TestGivenNoSigningKeyIdWhenLoadKeysShouldSignWithMostRecentKey(t *testing.T) {
+	dir := t.TempDir()
+	writeTestKey(t, dir, "old", time.Now().Add(-time.Hour))
+	writeTestKey(t, dir, "new", time.Now())
+
+	jwtService := newTestJwtService(t, DirKeyRingLoader(dir, "", 0))
+
+	if jwtService.KeyRing.SigningKey().Id != "new" {
+		t.Fatalf("got %s, want %s", jwtService.KeyRing.SigningKey().Id, "new")
+	}
+}
+
+func TestGivenUnknownSigningKeyIdWhenLoadKeysShouldReturnError(t *testing.T) {
+	dir := t.TempDir()
+	writeTestKey(t, dir, "key", time.Now())
+
+	_, err := NewKeyRing(DirKeyRingLoader(dir, "unknown", 0))
+	if err == nil {
+		t.Fatal("err must not be nil")
+	}
+}
+
+func TestWhenReloadShouldSignWithNewKeyAndAcceptPreviousKeyTokens(t *testing.T) {
+	dir := t.TempDir()
+	writeTestKey(t, dir, "old", time.Now().Add(-time.Minute))
+
+	jwtService := newTestJwtService(t, DirKeyRingLoader(dir, "", time.Hour))
+	oldToken := generateTestToken(t, jwtService)
+
+	writeTestKey(t, dir, "new", time.Now())
+
+	err := jwtService.KeyRing.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if jwtService.KeyRing.SigningKey().Id != "new" {
+		t.Fatalf("got %s, want %s", jwtService.KeyRing.SigningKey().Id, "new")
+	}
+
+	_, err = jwtService.GetClaims(oldToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = jwtService.GetClaims(generateTestToken(t, jwtService))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGivenRetirementHasPassedWhenReloadShouldRejectPreviousKeyTokens(t *testing.T) {
+	dir := t.TempDir()
+	writeTestKey(t, dir, "old", time.Now().Add(-3*time.Hour))
+
+	jwtService := newTestJwtService(t, DirKeyRingLoader(dir, "", time.Hour))
+	oldToken := generateTestToken(t, jwtService)
+
+	writeTestKey(t, dir, "new", time.Now().Add(-2*time.Hour))
+
+	err := jwtService.KeyRing.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = jwtService.GetClaims(oldToken)
+	if err == nil {
+		t.Fatal("err must not be nil")
+	}
+
+	for _, verificationKey := range jwtService.KeyRing.VerificationKeys() {
+		if verificationKey.Id == "old" {
+			t.Fatal("Retired key must not be a verification key")
+		}
+	}
+}
+
+func TestGivenLoadFailsWhenReloadShouldKeepCurrentKeys(t *testing.T) {
+	dir := t.TempDir()
+	writeTestKey(t, dir, "key", time.Now())
+
+	jwtService := newTestJwtService(t, DirKeyRingLoader(dir, "", 0))
+	token := generateTestToken(t, jwtService)
+
+	err := os.Remove(filepath.Join(dir, "key.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = jwtService.KeyRing.Reload()
+	if err == nil {
+		t.Fatal("err must not be nil")
+	}
+
+	_, err = jwtService.GetClaims(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGivenTokenWithUnknownKidWhenAuthenticateShouldReturnUnauthorized(t *testing.T) {
+	otherDir := t.TempDir()
+	writeTestKey(t, otherDir, "unknown", time.Now())
+	token := generateTestToken(t, newTestJwtService(t, DirKeyRingLoader(otherDir, "", 0)))
+
+	dir := t.TempDir()
+	writeTestKey(t, dir, "key", time.Now())
+	jwtService := newTestJwtService(t, DirKeyRingLoader(dir, "", 0))
+
+	authMiddleware := NewAuthMiddleware(jwtService, NewTokenRevocationService(NewInMemoryTokenRevocationRepository(), 10), NewApiKeyService(NewInMemoryApiKeyRepository()))
+	handler := authMiddleware.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/user", nil)
+	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	recorder := httptest.NewRecorder()
+
+	handler(recorder, request)
+
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", recorder.Code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,21 @@
+package auth
+
+import "time"
+
+// LoginAttempts counts the failed login attempts for a throttling key, e.g. an account or an IP
+// address. The attempts are forgotten after ExpiresAt.
+type LoginAttempts struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	ExpiresAt     time.Time
+}
+
+func NewLoginAttempts(key string, failures int, lastFailureAt time.Time, expiresAt time.Time) LoginAttempts {
+	return LoginAttempts{
+		Key:           key,
+		Failures:      failures,
+		LastFailureAt: lastFailureAt,
+		ExpiresAt:     expiresAt,
+	}
+}
@@ -0,0 +1,10 @@
+package auth
+
+import "context"
+
+type LoginAttemptsRepository interface {
+	// Update atomically replaces the attempts of the key with the result of update, which is
+	// given nil when there are none. Returning nil deletes them. update may be called more than
+	// once.
+	Update(ctx context.Context, key string, update func(loginAttempts *LoginAttempts) *LoginAttempts) error
+}
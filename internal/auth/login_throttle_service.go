@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// LoginThrottlePolicy makes every failed login attempt wait twice as long as the previous one
+// before the next, starting at BackoffBase and up to BackoffMax, and locks the key out for
+// Lockout once MaxFailures is reached. Failures are forgotten after Lockout without any.
+type LoginThrottlePolicy struct {
+	MaxFailures int
+	Lockout     time.Duration
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+}
+
+func NewLoginThrottlePolicy(maxFailures int, lockout time.Duration, backoffBase time.Duration, backoffMax time.Duration) LoginThrottlePolicy {
+	return LoginThrottlePolicy{
+		MaxFailures: maxFailures,
+		Lockout:     lockout,
+		BackoffBase: backoffBase,
+		BackoffMax:  backoffMax,
+	}
+}
+
+// retryAfter returns how long the next attempt must wait, or 0 if it's allowed now.
+func (p *LoginThrottlePolicy) retryAfter(loginAttempts *LoginAttempts, now time.Time) time.Duration {
+	if loginAttempts == nil || loginAttempts.Failures == 0 {
+		return 0
+	}
+
+	var delay time.Duration
+	if loginAttempts.Failures >= p.MaxFailures {
+		delay = p.Lockout
+	} else if p.BackoffBase > 0 {
+		delay = p.BackoffBase
+		for i := 1; i < loginAttempts.Failures && delay < p.BackoffMax; i++ {
+			delay *= 2
+		}
+		if delay > p.BackoffMax {
+			delay = p.BackoffMax
+		}
+	}
+
+	retryAfter := loginAttempts.LastFailureAt.Add(delay).Sub(now)
+	if retryAfter < 0 {
+		return 0
+	}
+
+	return retryAfter
+}
+
+type LoginThrottleService struct {
+	LoginAttemptsRepository LoginAttemptsRepository
+	AccountPolicy           LoginThrottlePolicy
+	IpPolicy                LoginThrottlePolicy
+}
+
+func NewLoginThrottleService(loginAttemptsRepository LoginAttemptsRepository, accountPolicy LoginThrottlePolicy, ipPolicy LoginThrottlePolicy) LoginThrottleService {
+	return LoginThrottleService{
+		LoginAttemptsRepository: loginAttemptsRepository,
+		AccountPolicy:           accountPolicy,
+		IpPolicy:                ipPolicy,
+	}
+}
+
+// Attempt returns how long a login attempt for the account from the ip must wait, or 0 if it's
+// allowed. Allowed attempts are counted as failed until Succeeded is called, so that concurrent
+// attempts can't get around the limits.
+func (s *LoginThrottleService) Attempt(ctx context.Context, account string, ip string) (time.Duration, error) {
+	if len(ip) > 0 {
+		retryAfter, err := s.attempt(ctx, ipThrottleKey(ip), s.IpPolicy)
+		if err != nil || retryAfter > 0 {
+			return retryAfter, err
+		}
+	}
+
+	retryAfter, err := s.attempt(ctx, accountThrottleKey(account), s.AccountPolicy)
+	if err != nil || retryAfter > 0 {
+		if len(ip) > 0 {
+			uncountErr := s.uncount(ctx, ipThrottleKey(ip))
+			if uncountErr != nil && err == nil {
+				err = uncountErr
+			}
+		}
+		return retryAfter, err
+	}
+
+	return 0, nil
+}
+
+// Succeeded forgets the failures of the account, and uncounts the attempt from the ip. The other
+// failures from the ip are kept, as any user could otherwise reset them with their own account.
+func (s *LoginThrottleService) Succeeded(ctx context.Context, account string, ip string) error {
+	err := s.LoginAttemptsRepository.Update(ctx, accountThrottleKey(account), func(loginAttempts *LoginAttempts) *LoginAttempts {
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(ip) == 0 {
+		return nil
+	}
+
+	return s.uncount(ctx, ipThrottleKey(ip))
+}
+
+func (s *LoginThrottleService) attempt(ctx context.Context, key string, policy LoginThrottlePolicy) (time.Duration, error) {
+	var retryAfter time.Duration
+
+	err := s.LoginAttemptsRepository.Update(ctx, key, func(loginAttempts *LoginAttempts) *LoginAttempts {
+		now := time.Now()
+
+		if loginAttempts != nil && !now.Before(loginAttempts.ExpiresAt) {
+			loginAttempts = nil
+		}
+
+		retryAfter = policy.retryAfter(loginAttempts, now)
+		if retryAfter > 0 {
+			return loginAttempts
+		}
+
+		failures := 1
+		if loginAttempts != nil {
+			failures = loginAttempts.Failures + 1
+		}
+
+		updatedLoginAttempts := NewLoginAttempts(key, failures, now, now.Add(policy.Lockout))
+		return &updatedLoginAttempts
+	})
+
+	return retryAfter, err
+}
+
+func (s *LoginThrottleService) uncount(ctx context.Context, key string) error {
+	return s.LoginAttemptsRepository.Update(ctx, key, func(loginAttempts *LoginAttempts) *LoginAttempts {
+		if loginAttempts == nil || loginAttempts.Failures <= 1 {
+			return nil
+		}
+
+		updatedLoginAttempts := *loginAttempts
+		updatedLoginAttempts.Failures--
+		return &updatedLoginAttempts
+	})
+}
+
+// The keys are hashed so that they are valid document ids, and don't store emails and IP
+// addresses in the clear.
+func accountThrottleKey(account string) string {
+	return HashOpaqueToken("account:" + account)
+}
+
+func ipThrottleKey(ip string) string {
+	return HashOpaqueToken("ip:" + ip)
+}
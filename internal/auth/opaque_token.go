@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// NewOpaqueToken returns a random token for clients to present back as is. Only its
+// HashOpaqueToken should be stored.
+func NewOpaqueToken() (*string, error) {
+	tokenBytes := make([]byte, 32)
+	_, err := rand.Read(tokenBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+	return &token, nil
+}
+
+func HashOpaqueToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
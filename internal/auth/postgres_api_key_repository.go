@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+)
+
+type PostgresApiKeyRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewPostgresApiKeyRepository(pool *pgxpool.Pool) *PostgresApiKeyRepository {
+	return &PostgresApiKeyRepository{
+		Pool: pool,
+	}
+}
+
+const apiKeyColumns = "id, key_hash, user_id, name, scopes, created_at, expires_at"
+
+func (r *PostgresApiKeyRepository) Create(ctx context.Context, apiKey ApiKey) error {
+	_, err := r.Pool.Exec(ctx, "INSERT INTO api_keys ("+apiKeyColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		apiKey.Id, apiKey.KeyHash, apiKey.UserId, apiKey.Name, apiKey.Scopes, apiKey.CreatedAt, apiKey.ExpiresAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return &custom_errors.AlreadyExistsError{Message: "API key already exists"}
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *PostgresApiKeyRepository) GetById(ctx context.Context, id string) (*ApiKey, error) {
+	apiKey, err := scanApiKey(r.Pool.QueryRow(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE id = $1", id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &custom_errors.NotFoundError{Message: "API key not found"}
+		}
+		return nil, err
+	}
+
+	return apiKey, nil
+}
+
+func (r *PostgresApiKeyRepository) ListByUserId(ctx context.Context, userId string) ([]ApiKey, error) {
+	rows, err := r.Pool.Query(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE user_id = $1 ORDER BY created_at", userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	apiKeys := []ApiKey{}
+	for rows.Next() {
+		apiKey, err := scanApiKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		apiKeys = append(apiKeys, *apiKey)
+	}
+
+	return apiKeys, rows.Err()
+}
+
+func (r *PostgresApiKeyRepository) Delete(ctx context.Context, userId string, id string) error {
+	commandTag, err := r.Pool.Exec(ctx, "DELETE FROM api_keys WHERE user_id = $1 AND id = $2", userId, id)
+	if err != nil {
+		return err
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return &custom_errors.NotFoundError{Message: "API key not found"}
+	}
+
+	return nil
+}
+
+func scanApiKey(row pgx.Row) (*ApiKey, error) {
+	apiKey := ApiKey{}
+	err := row.Scan(&apiKey.Id, &apiKey.KeyHash, &apiKey.UserId, &apiKey.Name, &apiKey.Scopes, &apiKey.CreatedAt, &apiKey.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiKey, nil
+}
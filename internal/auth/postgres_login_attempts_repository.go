@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type PostgresLoginAttemptsRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewPostgresLoginAttemptsRepository(pool *pgxpool.Pool) *PostgresLoginAttemptsRepository {
+	return &PostgresLoginAttemptsRepository{
+		Pool: pool,
+	}
+}
+
+// Update locks the key's row for the duration of the transaction. As there is no row to lock for
+// keys without attempts, the first ones are inserted with ON CONFLICT, and update is called again
+// if another transaction inserted the row first.
+func (r *PostgresLoginAttemptsRepository) Update(ctx context.Context, key string, update func(loginAttempts *LoginAttempts) *LoginAttempts) error {
+	for {
+		retry := false
+
+		err := r.Pool.BeginFunc(ctx, func(tx pgx.Tx) error {
+			loginAttempts := LoginAttempts{Key: key}
+			err := tx.QueryRow(ctx, `SELECT failures, last_failure_at, expires_at
+				FROM login_attempts
+				WHERE key = $1
+				FOR UPDATE`, key).
+				Scan(&loginAttempts.Failures, &loginAttempts.LastFailureAt, &loginAttempts.ExpiresAt)
+
+			var currentLoginAttempts *LoginAttempts
+			if err == nil {
+				currentLoginAttempts = &loginAttempts
+			} else if !errors.Is(err, pgx.ErrNoRows) {
+				return err
+			}
+
+			updatedLoginAttempts := update(currentLoginAttempts)
+
+			if updatedLoginAttempts == nil {
+				if currentLoginAttempts == nil {
+					return nil
+				}
+				_, err = tx.Exec(ctx, "DELETE FROM login_attempts WHERE key = $1", key)
+				return err
+			}
+
+			if currentLoginAttempts != nil {
+				_, err = tx.Exec(ctx, "UPDATE login_attempts SET failures = $2, last_failure_at = $3, expires_at = $4 WHERE key = $1",
+					key, updatedLoginAttempts.Failures, updatedLoginAttempts.LastFailureAt, updatedLoginAttempts.ExpiresAt)
+				return err
+			}
+
+			commandTag, err := tx.Exec(ctx, `INSERT INTO login_attempts (key, failures, last_failure_at, expires_at)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT (key) DO NOTHING`,
+				key, updatedLoginAttempts.Failures, updatedLoginAttempts.LastFailureAt, updatedLoginAttempts.ExpiresAt)
+			if err != nil {
+				return err
+			}
+
+			retry = commandTag.RowsAffected() == 0
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if !retry {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+)
+
+type PostgresRefreshTokenRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewPostgresRefreshTokenRepository(pool *pgxpool.Pool) *PostgresRefreshTokenRepository {
+	return &PostgresRefreshTokenRepository{
+		Pool: pool,
+	}
+}
+
+func (r *PostgresRefreshTokenRepository) Create(ctx context.Context, refreshToken RefreshToken) error {
+	_, err := r.Pool.Exec(ctx, `INSERT INTO refresh_tokens (token_hash, family_id, user_id, created_at, expires_at, used_at, revoked_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		refreshToken.TokenHash, refreshToken.FamilyId, refreshToken.UserId, refreshToken.CreatedAt, refreshToken.ExpiresAt, refreshToken.UsedAt, refreshToken.RevokedAt)
+	return err
+}
+
+func (r *PostgresRefreshTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	refreshToken := RefreshToken{}
+	err := r.Pool.QueryRow(ctx, `SELECT token_hash, family_id, user_id, created_at, expires_at, used_at, revoked_at
+		FROM refresh_tokens
+		WHERE token_hash = $1`, tokenHash).
+		Scan(&refreshToken.TokenHash, &refreshToken.FamilyId, &refreshToken.UserId, &refreshToken.CreatedAt, &refreshToken.ExpiresAt, &refreshToken.UsedAt, &refreshToken.RevokedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &custom_errors.NotFoundError{Message: "Refresh token not found"}
+		}
+		return nil, err
+	}
+
+	return &refreshToken, nil
+}
+
+func (r *PostgresRefreshTokenRepository) MarkUsed(ctx context.Context, tokenHash string, usedAt time.Time) (bool, error) {
+	commandTag, err := r.Pool.Exec(ctx, "UPDATE refresh_tokens SET used_at = $2 WHERE token_hash = $1 AND used_at IS NULL", tokenHash, usedAt)
+	if err != nil {
+		return false, err
+	}
+
+	return commandTag.RowsAffected() == 1, nil
+}
+
+func (r *PostgresRefreshTokenRepository) RevokeFamily(ctx context.Context, familyId string, revokedAt time.Time) error {
+	_, err := r.Pool.Exec(ctx, "UPDATE refresh_tokens SET revoked_at = $2 WHERE family_id = $1 AND revoked_at IS NULL", familyId, revokedAt)
+	return err
+}
+
+func (r *PostgresRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userId string, revokedAt time.Time) error {
+	_, err := r.Pool.Exec(ctx, "UPDATE refresh_tokens SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL", userId, revokedAt)
+	return err
+}
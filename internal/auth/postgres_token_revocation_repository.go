@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type PostgresTokenRevocationRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewPostgresTokenRevocationRepository(pool *pgxpool.Pool) *PostgresTokenRevocationRepository {
+	return &PostgresTokenRevocationRepository{
+		Pool: pool,
+	}
+}
+
+func (r *PostgresTokenRevocationRepository) RevokeToken(ctx context.Context, tokenId string, expiresAt time.Time) error {
+	_, err := r.Pool.Exec(ctx, `INSERT INTO revoked_tokens (token_id, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (token_id) DO NOTHING`, tokenId, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Pool.Exec(ctx, "DELETE FROM revoked_tokens WHERE expires_at < now()")
+	return err
+}
+
+func (r *PostgresTokenRevocationRepository) IsTokenRevoked(ctx context.Context, tokenId string) (bool, error) {
+	var isTokenRevoked bool
+	err := r.Pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE token_id = $1)", tokenId).Scan(&isTokenRevoked)
+	if err != nil {
+		return false, err
+	}
+
+	return isTokenRevoked, nil
+}
+
+func (r *PostgresTokenRevocationRepository) RevokeSubject(ctx context.Context, subject string, revokedBefore time.Time) error {
+	_, err := r.Pool.Exec(ctx, `INSERT INTO subject_revocations (subject, revoked_before)
+		VALUES ($1, $2)
+		ON CONFLICT (subject) DO UPDATE SET revoked_before = GREATEST(subject_revocations.revoked_before, EXCLUDED.revoked_before)`, subject, revokedBefore)
+	return err
+}
+
+func (r *PostgresTokenRevocationRepository) GetSubjectRevokedBefore(ctx context.Context, subject string) (*time.Time, error) {
+	var revokedBefore time.Time
+	err := r.Pool.QueryRow(ctx, "SELECT revoked_before FROM subject_revocations WHERE subject = $1", subject).Scan(&revokedBefore)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &revokedBefore, nil
+}
@@ -0,0 +1,28 @@
+package auth
+
+import "time"
+
+// RefreshToken is the server-side record of an opaque refresh token. Only the hash of the token
+// is stored. Every rotation issues a new token in the same family, so reusing a rotated token
+// identifies the whole chain as compromised.
+type RefreshToken struct {
+	TokenHash string
+	FamilyId  string
+	UserId    string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+func NewRefreshToken(tokenHash string, familyId string, userId string, createdAt time.Time, expiresAt time.Time, usedAt *time.Time, revokedAt *time.Time) RefreshToken {
+	return RefreshToken{
+		TokenHash: tokenHash,
+		FamilyId:  familyId,
+		UserId:    userId,
+		CreatedAt: createdAt,
+		ExpiresAt: expiresAt,
+		UsedAt:    usedAt,
+		RevokedAt: revokedAt,
+	}
+}
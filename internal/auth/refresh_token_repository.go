@@ -0,0 +1,15 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, refreshToken RefreshToken) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	// MarkUsed atomically flags the token as used, returning false if it had already been used.
+	MarkUsed(ctx context.Context, tokenHash string, usedAt time.Time) (bool, error)
+	RevokeFamily(ctx context.Context, familyId string, revokedAt time.Time) error
+	RevokeAllForUser(ctx context.Context, userId string, revokedAt time.Time) error
+}
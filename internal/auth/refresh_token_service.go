@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"github.com/rs/zerolog/log"
+)
+
+type RefreshTokenService struct {
+	RefreshTokenRepository RefreshTokenRepository
+	SecondsToExpire        int
+}
+
+func NewRefreshTokenService(refreshTokenRepository RefreshTokenRepository, secondsToExpire int) RefreshTokenService {
+	return RefreshTokenService{
+		RefreshTokenRepository: refreshTokenRepository,
+		SecondsToExpire:        secondsToExpire,
+	}
+}
+
+func (s *RefreshTokenService) IssueToken(ctx context.Context, userId string) (*string, error) {
+	familyId, err := NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueToken(ctx, userId, *familyId)
+}
+
+// RotateToken exchanges a refresh token for a new one in the same family. Presenting a token
+// that was already rotated revokes the whole family.
+func (s *RefreshTokenService) RotateToken(ctx context.Context, token string) (*RefreshToken, *string, error) {
+	refreshToken, err := s.RefreshTokenRepository.GetByTokenHash(ctx, HashOpaqueToken(token))
+	if err != nil {
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			return nil, nil, &custom_errors.UnauthenticatedError{Message: "Invalid refresh token"}
+		}
+		return nil, nil, err
+	}
+
+	if refreshToken.RevokedAt != nil {
+		return nil, nil, &custom_errors.UnauthenticatedError{Message: "Refresh token has been revoked"}
+	}
+
+	now := time.Now()
+
+	if refreshToken.UsedAt != nil {
+		return nil, nil, s.revokeReusedFamily(ctx, *refreshToken, now)
+	}
+
+	if !now.Before(refreshToken.ExpiresAt) {
+		return nil, nil, &custom_errors.UnauthenticatedError{Message: "Refresh token has expired"}
+	}
+
+	marked, err := s.RefreshTokenRepository.MarkUsed(ctx, refreshToken.TokenHash, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !marked {
+		return nil, nil, s.revokeReusedFamily(ctx, *refreshToken, now)
+	}
+
+	newToken, err := s.issueToken(ctx, refreshToken.UserId, refreshToken.FamilyId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return refreshToken, newToken, nil
+}
+
+// RevokeToken revokes the family of the refresh token. Unknown tokens are ignored.
+func (s *RefreshTokenService) RevokeToken(ctx context.Context, token string) error {
+	refreshToken, err := s.RefreshTokenRepository.GetByTokenHash(ctx, HashOpaqueToken(token))
+	if err != nil {
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			return nil
+		}
+		return err
+	}
+
+	return s.RefreshTokenRepository.RevokeFamily(ctx, refreshToken.FamilyId, time.Now())
+}
+
+func (s *RefreshTokenService) RevokeAllForUser(ctx context.Context, userId string) error {
+	return s.RefreshTokenRepository.RevokeAllForUser(ctx, userId, time.Now())
+}
+
+func (s *RefreshTokenService) issueToken(ctx context.Context, userId string, familyId string) (*string, error) {
+	token, err := NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(time.Second * time.Duration(s.SecondsToExpire))
+
+	err = s.RefreshTokenRepository.Create(ctx, NewRefreshToken(HashOpaqueToken(*token), familyId, userId, now, expiresAt, nil, nil))
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (s *RefreshTokenService) revokeReusedFamily(ctx context.Context, refreshToken RefreshToken, now time.Time) error {
+	log.Warn().Msgf("Refresh token reuse detected for User %s, revoking token family %s", refreshToken.UserId, refreshToken.FamilyId)
+
+	err := s.RefreshTokenRepository.RevokeFamily(ctx, refreshToken.FamilyId, now)
+	if err != nil {
+		return err
+	}
+
+	return &custom_errors.UnauthenticatedError{Message: "Refresh token has already been used"}
+}
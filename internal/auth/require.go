@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RequireRole lets requests through when the authenticated user has any of the roles. It must
+// run after Authenticate.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userRoles, ok := r.Context().Value(RolesContextKey).([]string)
+			if !ok {
+				unauthorized(w)
+				return
+			}
+
+			for _, role := range roles {
+				if contains(userRoles, role) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			forbidden(w, fmt.Sprintf("Requires one of the roles %s", strings.Join(roles, ", ")))
+		})
+	}
+}
+
+// RequireScope lets requests through when the presented token or API key has all the scopes. It
+// must run after Authenticate.
+func RequireScope(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenScopes, ok := r.Context().Value(ScopesContextKey).([]string)
+			if !ok {
+				unauthorized(w)
+				return
+			}
+
+			for _, scope := range scopes {
+				if !contains(tokenScopes, scope) {
+					forbidden(w, fmt.Sprintf("Requires the scopes %s", strings.Join(scopes, ", ")))
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func unauthorized(w http.ResponseWriter) {
+	writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+}
+
+func forbidden(w http.ResponseWriter, message string) {
+	writeErrorResponse(w, http.StatusForbidden, message)
+}
+
+// writeErrorResponse answers with the error envelope of the RealWorld API.
+func writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
+	var responseBody struct {
+		Errors struct {
+			Body []string `json:"body"`
+		} `json:"errors"`
+	}
+	responseBody.Errors.Body = []string{message}
+
+	response, err := json.Marshal(responseBody)
+	if err != nil {
+		log.Error().Err(err).Msg("Error marshalling error response body")
+		http.Error(w, http.StatusText(statusCode), statusCode)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(response)
+}
@@ -0,0 +1,44 @@
+package auth
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Roles are the roles users can be given, from the least to the most privileged.
+var Roles = []string{RoleUser, RoleModerator, RoleAdmin}
+
+const (
+	ReadScope     = "read"
+	WriteScope    = "write"
+	ModerateScope = "moderate"
+	AdminScope    = "admin"
+)
+
+var scopes = []string{ReadScope, WriteScope, ModerateScope, AdminScope}
+
+// roleScopes are the scopes granted to tokens by each role.
+var roleScopes = map[string][]string{
+	RoleUser:      {ReadScope, WriteScope},
+	RoleModerator: {ReadScope, WriteScope, ModerateScope},
+	RoleAdmin:     {ReadScope, WriteScope, ModerateScope, AdminScope},
+}
+
+func IsRole(role string) bool {
+	return contains(Roles, role)
+}
+
+// ScopesOfRoles returns the scopes granted by any of the roles, in a stable order.
+func ScopesOfRoles(roles []string) []string {
+	scopesOfRoles := []string{}
+	for _, scope := range scopes {
+		for _, role := range roles {
+			if contains(roleScopes[role], scope) {
+				scopesOfRoles = append(scopesOfRoles, scope)
+				break
+			}
+		}
+	}
+	return scopesOfRoles
+}
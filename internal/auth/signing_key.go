@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// SigningKey signs and verifies JWTs, or only verifies them when it has no private key. HMAC keys
+// are shared secrets and are never published, while the public half of RSA and Ed25519 keys is
+// served as a JWK so that other services can verify tokens without being able to mint them.
+type SigningKey struct {
+	Id            string
+	SigningMethod jwt.SigningMethod
+	PrivateKey    interface{}
+	PublicKey     interface{}
+}
+
+func NewHmacSigningKey(id string, secretKey string) SigningKey {
+	return SigningKey{
+		Id:            id,
+		SigningMethod: jwt.SigningMethodHS256,
+		PrivateKey:    []byte(secretKey),
+		PublicKey:     []byte(secretKey),
+	}
+}
+
+// LoadKey reads an RSA or Ed25519 key from a PEM file. Keys loaded from a public key can only
+// verify tokens. When id is empty, the key's RFC 7638 thumbprint is used.
+func LoadKey(path string, id string) (*SigningKey, error) {
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	signingKey := SigningKey{}
+
+	if rsaPrivateKey, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes); err == nil {
+		signingKey.SigningMethod = jwt.SigningMethodRS256
+		signingKey.PrivateKey = rsaPrivateKey
+		signingKey.PublicKey = &rsaPrivateKey.PublicKey
+	} else if edPrivateKey, err := jwt.ParseEdPrivateKeyFromPEM(pemBytes); err == nil {
+		signingKey.SigningMethod = jwt.SigningMethodEdDSA
+		signingKey.PrivateKey = edPrivateKey
+		signingKey.PublicKey = edPrivateKey.(ed25519.PrivateKey).Public()
+	} else if rsaPublicKey, err := jwt.ParseRSAPublicKeyFromPEM(pemBytes); err == nil {
+		signingKey.SigningMethod = jwt.SigningMethodRS256
+		signingKey.PublicKey = rsaPublicKey
+	} else if edPublicKey, err := jwt.ParseEdPublicKeyFromPEM(pemBytes); err == nil {
+		signingKey.SigningMethod = jwt.SigningMethodEdDSA
+		signingKey.PublicKey = edPublicKey
+	} else {
+		return nil, fmt.Errorf("%s is not a PEM encoded RSA or Ed25519 key", path)
+	}
+
+	signingKey.Id = id
+	if len(signingKey.Id) == 0 {
+		jwk := signingKey.Jwk()
+		signingKey.Id = jwk.Thumbprint()
+	}
+
+	return &signingKey, nil
+}
+
+func (k SigningKey) CanSign() bool {
+	return k.PrivateKey != nil
+}
+
+func (k SigningKey) IsPublishable() bool {
+	return k.SigningMethod != jwt.SigningMethodHS256
+}
+
+type Jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type Jwks struct {
+	Keys []Jwk `json:"keys"`
+}
+
+// Jwk must only be called on publishable keys.
+func (k SigningKey) Jwk() Jwk {
+	jwk := Jwk{
+		Kid: k.Id,
+		Use: "sig",
+		Alg: k.SigningMethod.Alg(),
+	}
+
+	switch publicKey := k.PublicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+	}
+
+	return jwk
+}
+
+// Thumbprint computes the RFC 7638 thumbprint of the key, which only covers its required members.
+func (j Jwk) Thumbprint() string {
+	var requiredMembers interface{}
+	switch j.Kty {
+	case "RSA":
+		requiredMembers = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{E: j.E, Kty: j.Kty, N: j.N}
+	case "OKP":
+		requiredMembers = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{Crv: j.Crv, Kty: j.Kty, X: j.X}
+	}
+
+	// Marshalling these structs can't fail.
+	requiredMembersJson, _ := json.Marshal(requiredMembers)
+	hash := sha256.Sum256(requiredMembersJson)
+
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
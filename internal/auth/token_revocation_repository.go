@@ -0,0 +1,17 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+type TokenRevocationRepository interface {
+	// RevokeToken revokes a single token by its jti claim. The revocation can be forgotten once
+	// the token has expired.
+	RevokeToken(ctx context.Context, tokenId string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, tokenId string) (bool, error)
+	// RevokeSubject revokes every token of the subject issued before revokedBefore.
+	RevokeSubject(ctx context.Context, subject string, revokedBefore time.Time) error
+	// GetSubjectRevokedBefore returns nil if the subject's tokens were never revoked.
+	GetSubjectRevokedBefore(ctx context.Context, subject string) (*time.Time, error)
+}
@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TokenRevocationService checks tokens against the revocation store, caching the answers for
+// CacheSecondsToExpire so that not every request hits the store. Revocations made by other
+// instances take up to that long to be seen.
+type TokenRevocationService struct {
+	TokenRevocationRepository TokenRevocationRepository
+	CacheSecondsToExpire      int
+	cache                     *tokenRevocationCache
+}
+
+func NewTokenRevocationService(tokenRevocationRepository TokenRevocationRepository, cacheSecondsToExpire int) TokenRevocationService {
+	return TokenRevocationService{
+		TokenRevocationRepository: tokenRevocationRepository,
+		CacheSecondsToExpire:      cacheSecondsToExpire,
+		cache:                     newTokenRevocationCache(),
+	}
+}
+
+func (s *TokenRevocationService) RevokeToken(ctx context.Context, claims *Claims) error {
+	if len(claims.Id) == 0 {
+		return nil
+	}
+
+	expiresAt := time.Unix(claims.ExpiresAt, 0)
+
+	err := s.TokenRevocationRepository.RevokeToken(ctx, claims.Id, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	s.cache.setToken(claims.Id, true, expiresAt)
+
+	return nil
+}
+
+// legacySubjectPrefix keeps the revocations of the usernames that were the subject of tokens
+// issued before user ids were apart from the revocations of user ids, as users can take any
+// username, including another user's id.
+const legacySubjectPrefix = "legacy-username:"
+
+// RevokeLegacySubject revokes every token issued for the username while subjects were usernames.
+func (s *TokenRevocationService) RevokeLegacySubject(ctx context.Context, username string) error {
+	return s.RevokeSubject(ctx, legacySubjectPrefix+username)
+}
+
+func (s *TokenRevocationService) RevokeSubject(ctx context.Context, subject string) error {
+	revokedBefore := time.Now()
+
+	err := s.TokenRevocationRepository.RevokeSubject(ctx, subject, revokedBefore)
+	if err != nil {
+		return err
+	}
+
+	s.cache.setSubject(subject, &revokedBefore, s.cacheExpiresAt())
+
+	return nil
+}
+
+// IsRevoked compares the issued at claim in whole seconds, so tokens issued in the same second as
+// a subject's revocation are still accepted. This keeps the token returned alongside e.g. a
+// password change valid.
+func (s *TokenRevocationService) IsRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	if len(claims.Id) > 0 {
+		isTokenRevoked, err := s.isTokenRevoked(ctx, claims)
+		if err != nil {
+			return false, err
+		}
+		if isTokenRevoked {
+			return true, nil
+		}
+	}
+
+	subject := claims.Subject
+	if claims.IsLegacySubject() {
+		subject = legacySubjectPrefix + subject
+	}
+
+	revokedBefore, err := s.getSubjectRevokedBefore(ctx, subject)
+	if err != nil {
+		return false, err
+	}
+
+	return revokedBefore != nil && claims.IssuedAt < revokedBefore.Unix(), nil
+}
+
+func (s *TokenRevocationService) isTokenRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	if isTokenRevoked, ok := s.cache.getToken(claims.Id); ok {
+		return isTokenRevoked, nil
+	}
+
+	isTokenRevoked, err := s.TokenRevocationRepository.IsTokenRevoked(ctx, claims.Id)
+	if err != nil {
+		return false, err
+	}
+
+	if isTokenRevoked {
+		s.cache.setToken(claims.Id, true, time.Unix(claims.ExpiresAt, 0))
+	} else {
+		s.cache.setToken(claims.Id, false, s.cacheExpiresAt())
+	}
+
+	return isTokenRevoked, nil
+}
+
+func (s *TokenRevocationService) getSubjectRevokedBefore(ctx context.Context, subject string) (*time.Time, error) {
+	if revokedBefore, ok := s.cache.getSubject(subject); ok {
+		return revokedBefore, nil
+	}
+
+	revokedBefore, err := s.TokenRevocationRepository.GetSubjectRevokedBefore(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.setSubject(subject, revokedBefore, s.cacheExpiresAt())
+
+	return revokedBefore, nil
+}
+
+func (s *TokenRevocationService) cacheExpiresAt() time.Time {
+	return time.Now().Add(time.Second * time.Duration(s.CacheSecondsToExpire))
+}
+
+type tokenRevocationCache struct {
+	mu        sync.Mutex
+	tokens    map[string]cachedTokenRevocation
+	subjects  map[string]cachedSubjectRevocation
+	lastSweep time.Time
+}
+
+type cachedTokenRevocation struct {
+	isRevoked bool
+	expiresAt time.Time
+}
+
+type cachedSubjectRevocation struct {
+	revokedBefore *time.Time
+	expiresAt     time.Time
+}
+
+const tokenRevocationCacheSweepInterval = time.Minute
+
+func newTokenRevocationCache() *tokenRevocationCache {
+	return &tokenRevocationCache{
+		tokens:    map[string]cachedTokenRevocation{},
+		subjects:  map[string]cachedSubjectRevocation{},
+		lastSweep: time.Now(),
+	}
+}
+
+func (c *tokenRevocationCache) getToken(tokenId string) (bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.tokens[tokenId]
+	if !ok || !time.Now().Before(cached.expiresAt) {
+		return false, false
+	}
+
+	return cached.isRevoked, true
+}
+
+func (c *tokenRevocationCache) setToken(tokenId string, isRevoked bool, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tokens[tokenId] = cachedTokenRevocation{isRevoked: isRevoked, expiresAt: expiresAt}
+	c.sweep()
+}
+
+func (c *tokenRevocationCache) getSubject(subject string) (*time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.subjects[subject]
+	if !ok || !time.Now().Before(cached.expiresAt) {
+		return nil, false
+	}
+
+	return cached.revokedBefore, true
+}
+
+func (c *tokenRevocationCache) setSubject(subject string, revokedBefore *time.Time, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.subjects[subject] = cachedSubjectRevocation{revokedBefore: revokedBefore, expiresAt: expiresAt}
+	c.sweep()
+}
+
+// sweep must be called with the lock held.
+func (c *tokenRevocationCache) sweep() {
+	now := time.Now()
+	if now.Sub(c.lastSweep) < tokenRevocationCacheSweepInterval {
+		return
+	}
+
+	for tokenId, cached := range c.tokens {
+		if !now.Before(cached.expiresAt) {
+			delete(c.tokens, tokenId)
+		}
+	}
+
+	for subject, cached := range c.subjects {
+		if !now.Before(cached.expiresAt) {
+			delete(c.subjects, subject)
+		}
+	}
+
+	c.lastSweep = now
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func newTestClaims(subject string, username string) *Claims {
+	return &Claims{
+		StandardClaims: jwt.StandardClaims{
+			Subject:   subject,
+			IssuedAt:  time.Now().Add(-time.Minute).Unix(),
+			ExpiresAt: time.Now().Add(time.Hour).Unix(),
+		},
+		Username: username,
+	}
+}
+
+func TestGivenUsernameIsAnotherUsersIdWhenRevokeLegacySubjectShouldNotRevokeThatUsersTokens(t *testing.T) {
+	tokenRevocationService := NewTokenRevocationService(NewInMemoryTokenRevocationRepository(), 10)
+	claims := newTestClaims("victim-id", "victim")
+
+	err := tokenRevocationService.RevokeLegacySubject(context.Background(), "victim-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	isRevoked, err := tokenRevocationService.IsRevoked(context.Background(), claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if isRevoked {
+		t.Fatal("Token must not be revoked")
+	}
+}
+
+func TestWhenRevokeLegacySubjectShouldRevokeTokensWhoseSubjectIsTheUsername(t *testing.T) {
+	tokenRevocationService := NewTokenRevocationService(NewInMemoryTokenRevocationRepository(), 10)
+	claims := newTestClaims("alice", "")
+
+	err := tokenRevocationService.RevokeLegacySubject(context.Background(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	isRevoked, err := tokenRevocationService.IsRevoked(context.Background(), claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !isRevoked {
+		t.Fatal("Token must be revoked")
+	}
+}
+
+func TestWhenRevokeSubjectShouldRevokeTokensIssuedBefore(t *testing.T) {
+	tokenRevocationService := NewTokenRevocationService(NewInMemoryTokenRevocationRepository(), 10)
+	claims := newTestClaims("id", "alice")
+
+	err := tokenRevocationService.RevokeSubject(context.Background(), "id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	isRevoked, err := tokenRevocationService.IsRevoked(context.Background(), claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !isRevoked {
+		t.Fatal("Token must be revoked")
+	}
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// The TOTP parameters of RFC 6238 that authenticator apps support everywhere.
+const (
+	totpDigits = 6
+	totpPeriod = 30 * time.Second
+	// totpSkew is the number of periods before and after the current one whose codes are
+	// accepted, to make up for clock drift and typing time.
+	totpSkew = 1
+)
+
+var totpSecretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewTotpSecret returns a random base32 encoded secret of the 160 bits RFC 4226 recommends.
+func NewTotpSecret() (*string, error) {
+	secretBytes := make([]byte, 20)
+	_, err := rand.Read(secretBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := totpSecretEncoding.EncodeToString(secretBytes)
+	return &secret, nil
+}
+
+// TotpProvisioningUri returns the otpauth URI authenticator apps enroll the secret with, usually
+// from a QR code.
+func TotpProvisioningUri(secret string, issuer string, accountName string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(int(totpPeriod.Seconds())))
+
+	provisioningUri := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + accountName,
+		RawQuery: query.Encode(),
+	}
+
+	return provisioningUri.String()
+}
+
+// ValidateTotpCode returns the time step of the code if it's valid at now and its step is
+// after lastUsedStep, so that codes can't be replayed.
+func ValidateTotpCode(secret string, code string, now time.Time, lastUsedStep int64) (int64, bool) {
+	key, err := totpSecretEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	currentStep := now.Unix() / int64(totpPeriod.Seconds())
+
+	for step := currentStep - totpSkew; step <= currentStep+totpSkew; step++ {
+		if step <= lastUsedStep {
+			continue
+		}
+
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// totpCode is the HOTP value of RFC 4226 for the step.
+func totpCode(key []byte, step int64) string {
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", totpDigits, value%modulo)
+}
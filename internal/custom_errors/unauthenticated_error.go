@@ -0,0 +1,9 @@
+package custom_errors
+
+type UnauthenticatedError struct {
+	Message string
+}
+
+func (e *UnauthenticatedError) Error() string {
+	return e.Message
+}
@@ -0,0 +1,42 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileSender writes every message to its own file of Dir, named after the time it was sent and
+// its recipient, instead of delivering it. It is meant for local development and tests.
+type FileSender struct {
+	Dir  string
+	From string
+}
+
+func NewFileSender(dir string, from string) *FileSender {
+	return &FileSender{
+		Dir:  dir,
+		From: from,
+	}
+}
+
+func (s *FileSender) Send(ctx context.Context, message Message) error {
+	err := os.MkdirAll(s.Dir, 0o755)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	content, err := encodeMessage(s.From, message, now)
+	if err != nil {
+		return err
+	}
+
+	fileName := fmt.Sprintf("%d_%s.eml", now.UnixNano(), url.PathEscape(message.To))
+
+	return os.WriteFile(filepath.Join(s.Dir, fileName), content, 0o644)
+}
@@ -0,0 +1,26 @@
+package mail
+
+import "context"
+
+// Mailer renders messages from Templates and sends them with Sender.
+type Mailer struct {
+	Sender    Sender
+	Templates Templates
+}
+
+func NewMailer(sender Sender, templates Templates) Mailer {
+	return Mailer{
+		Sender:    sender,
+		Templates: templates,
+	}
+}
+
+// Send renders the named template for the first of locales it exists in, and sends it to to.
+func (m *Mailer) Send(ctx context.Context, to string, name string, data interface{}, locales ...string) error {
+	message, err := m.Templates.Render(to, name, data, locales...)
+	if err != nil {
+		return err
+	}
+
+	return m.Sender.Send(ctx, *message)
+}
@@ -0,0 +1,94 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"time"
+)
+
+// Message is a mail with a plain text body and, optionally, an HTML alternative.
+type Message struct {
+	To       string
+	Subject  string
+	TextBody string
+	HtmlBody string
+}
+
+func NewMessage(to string, subject string, textBody string, htmlBody string) Message {
+	return Message{
+		To:       to,
+		Subject:  subject,
+		TextBody: textBody,
+		HtmlBody: htmlBody,
+	}
+}
+
+// encodeMessage returns the message in the Internet Message Format, with quoted-printable bodies.
+func encodeMessage(from string, message Message, date time.Time) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	fmt.Fprintf(&buffer, "From: %s\r\n", from)
+	fmt.Fprintf(&buffer, "To: %s\r\n", message.To)
+	fmt.Fprintf(&buffer, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buffer, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(&buffer, "MIME-Version: 1.0\r\n")
+
+	if len(message.HtmlBody) == 0 {
+		fmt.Fprintf(&buffer, "Content-Type: text/plain; charset=utf-8\r\n")
+		fmt.Fprintf(&buffer, "Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+		err := writeQuotedPrintable(&buffer, message.TextBody)
+		if err != nil {
+			return nil, err
+		}
+
+		return buffer.Bytes(), nil
+	}
+
+	multipartWriter := multipart.NewWriter(&buffer)
+	fmt.Fprintf(&buffer, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", multipartWriter.Boundary())
+
+	for _, part := range []struct {
+		contentType string
+		body        string
+	}{
+		{contentType: "text/plain; charset=utf-8", body: message.TextBody},
+		{contentType: "text/html; charset=utf-8", body: message.HtmlBody},
+	} {
+		partWriter, err := multipartWriter.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		err = writeQuotedPrintable(partWriter, part.body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := multipartWriter.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	quotedPrintableWriter := quotedprintable.NewWriter(w)
+
+	_, err := quotedPrintableWriter.Write([]byte(body))
+	if err != nil {
+		return err
+	}
+
+	return quotedPrintableWriter.Close()
+}
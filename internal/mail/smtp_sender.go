@@ -0,0 +1,106 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SmtpSender delivers messages through an SMTP server, upgrading the connection with STARTTLS
+// when the server supports it. Credentials are only sent over TLS, or to localhost. From may
+// include a display name, e.g. "Conduit <noreply@example.com>".
+type SmtpSender struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func NewSmtpSender(host string, port int, username string, password string, from string) *SmtpSender {
+	return &SmtpSender{
+		Host:     host,
+		Port:     port,
+		Username: username,
+		Password: password,
+		From:     from,
+	}
+}
+
+func (s *SmtpSender) Send(ctx context.Context, message Message) error {
+	content, err := encodeMessage(s.From, message, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.Host, strconv.Itoa(s.Port)))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		err = conn.SetDeadline(deadline)
+		if err != nil {
+			return err
+		}
+	}
+
+	client, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: s.Host})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(s.Username) > 0 {
+		err = client.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host))
+		if err != nil {
+			return err
+		}
+	}
+
+	fromAddress, err := netmail.ParseAddress(s.From)
+	if err != nil {
+		return err
+	}
+
+	err = client.Mail(fromAddress.Address)
+	if err != nil {
+		return err
+	}
+
+	err = client.Rcpt(message.To)
+	if err != nil {
+		return err
+	}
+
+	dataWriter, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	_, err = dataWriter.Write(content)
+	if err != nil {
+		return err
+	}
+
+	err = dataWriter.Close()
+	if err != nil {
+		return fmt.Errorf("error delivering message to %s: %w", message.To, err)
+	}
+
+	return client.Quit()
+}
@@ -0,0 +1,37 @@
+package mail
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// StdoutSender prints messages to the standard output instead of delivering them. As messages
+// may carry secrets such as password reset links, it is only meant for local development.
+type StdoutSender struct {
+	mu     sync.Mutex
+	Writer io.Writer
+	From   string
+}
+
+func NewStdoutSender(from string) *StdoutSender {
+	return &StdoutSender{
+		Writer: os.Stdout,
+		From:   from,
+	}
+}
+
+func (s *StdoutSender) Send(ctx context.Context, message Message) error {
+	content, err := encodeMessage(s.From, message, time.Now())
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.Writer.Write(append(content, "\r\n\r\n"...))
+	return err
+}
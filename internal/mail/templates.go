@@ -0,0 +1,163 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+
+	"golang.org/x/text/language"
+)
+
+//go:embed templates
+var embeddedTemplates embed.FS
+
+// DefaultTemplatesFS returns the templates shipped with the service.
+func DefaultTemplatesFS() fs.FS {
+	templatesFS, err := fs.Sub(embeddedTemplates, "templates")
+	if err != nil {
+		panic(err)
+	}
+
+	return templatesFS
+}
+
+// Templates renders messages from the <locale>/<name>.subject.txt, <locale>/<name>.txt and,
+// optionally, <locale>/<name>.html templates of FS. Locales are looked up by their full tag,
+// then by their base language, then DefaultLocale is used.
+type Templates struct {
+	FS            fs.FS
+	DefaultLocale string
+}
+
+func NewTemplates(templatesFS fs.FS, defaultLocale string) Templates {
+	return Templates{
+		FS:            templatesFS,
+		DefaultLocale: defaultLocale,
+	}
+}
+
+func (t *Templates) Render(to string, name string, data interface{}, locales ...string) (*Message, error) {
+	for _, locale := range t.candidateLocales(locales) {
+		subjectPath := path.Join(locale, name+".subject.txt")
+
+		_, err := fs.Stat(t.FS, subjectPath)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		subject, err := t.renderText(subjectPath, data)
+		if err != nil {
+			return nil, err
+		}
+
+		textBody, err := t.renderText(path.Join(locale, name+".txt"), data)
+		if err != nil {
+			return nil, err
+		}
+
+		var htmlBody string
+		htmlPath := path.Join(locale, name+".html")
+		_, err = fs.Stat(t.FS, htmlPath)
+		if err == nil {
+			htmlBody, err = t.renderHtml(htmlPath, data)
+			if err != nil {
+				return nil, err
+			}
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+
+		message := NewMessage(to, strings.TrimSpace(subject), textBody, htmlBody)
+		return &message, nil
+	}
+
+	return nil, fmt.Errorf("no '%s' mail template for locales %v or default locale %s", name, locales, t.DefaultLocale)
+}
+
+func (t *Templates) candidateLocales(locales []string) []string {
+	candidateLocales := []string{}
+	for _, locale := range locales {
+		candidateLocales = append(candidateLocales, locale)
+		if base, _, ok := strings.Cut(locale, "-"); ok {
+			candidateLocales = append(candidateLocales, base)
+		}
+	}
+
+	return append(candidateLocales, t.DefaultLocale)
+}
+
+func (t *Templates) renderText(templatePath string, data interface{}) (string, error) {
+	parsedTemplate, err := texttemplate.ParseFS(t.FS, templatePath)
+	if err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+	err = parsedTemplate.Execute(&buffer, data)
+	if err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
+
+func (t *Templates) renderHtml(templatePath string, data interface{}) (string, error) {
+	parsedTemplate, err := htmltemplate.ParseFS(t.FS, templatePath)
+	if err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+	err = parsedTemplate.Execute(&buffer, data)
+	if err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
+
+// ParseAcceptLanguage returns the locales of an Accept-Language header, most preferred first.
+func ParseAcceptLanguage(acceptLanguage string) []string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil {
+		return nil
+	}
+
+	locales := []string{}
+	for _, tag := range tags {
+		locales = append(locales, tag.String())
+	}
+
+	return locales
+}
+
+// OverlayFS returns a file system that opens each file from the first of fileSystems that has it,
+// e.g. to override some of the default templates.
+func OverlayFS(fileSystems ...fs.FS) fs.FS {
+	return overlayFS(fileSystems)
+}
+
+type overlayFS []fs.FS
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	for _, fileSystem := range o {
+		file, err := fileSystem.Open(name)
+		if err == nil {
+			return file, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
@@ -0,0 +1,153 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	githubAuthorizationEndpoint = "https://github.com/login/oauth/authorize"
+	githubTokenEndpoint         = "https://github.com/login/oauth/access_token"
+	githubApiUrl                = "https://api.github.com"
+)
+
+// GithubProvider signs users in with GitHub, which is a plain OAuth 2.0 provider: the identity is
+// read from its REST API with the access token. The endpoints can be overridden for GitHub
+// Enterprise.
+type GithubProvider struct {
+	name                  string
+	ClientId              string
+	ClientSecret          string
+	Scopes                []string
+	AuthorizationEndpoint string
+	TokenEndpoint         string
+	ApiUrl                string
+	HttpClient            *http.Client
+}
+
+func NewGithubProvider(name string, clientId string, clientSecret string, scopes []string) *GithubProvider {
+	return &GithubProvider{
+		name:                  name,
+		ClientId:              clientId,
+		ClientSecret:          clientSecret,
+		Scopes:                scopes,
+		AuthorizationEndpoint: githubAuthorizationEndpoint,
+		TokenEndpoint:         githubTokenEndpoint,
+		ApiUrl:                githubApiUrl,
+		HttpClient:            newHttpClient(),
+	}
+}
+
+type githubUser struct {
+	Id    int64  `json:"id"`
+	Login string `json:"login"`
+	Name  string `json:"name"`
+}
+
+type githubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+func (p *GithubProvider) Name() string {
+	return p.name
+}
+
+// AuthorizationUrl ignores the nonce, which only applies to ID tokens.
+func (p *GithubProvider) AuthorizationUrl(ctx context.Context, state string, codeChallenge string, nonce string, redirectUri string) (*string, error) {
+	authorizationUrl, err := url.Parse(p.AuthorizationEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	query := authorizationUrl.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.ClientId)
+	query.Set("redirect_uri", redirectUri)
+	query.Set("scope", strings.Join(p.Scopes, " "))
+	query.Set("state", state)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	query.Set("allow_signup", "false")
+	authorizationUrl.RawQuery = query.Encode()
+
+	authorizationUrlString := authorizationUrl.String()
+	return &authorizationUrlString, nil
+}
+
+func (p *GithubProvider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string, redirectUri string) (*Identity, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectUri)
+	form.Set("client_id", p.ClientId)
+	form.Set("client_secret", p.ClientSecret)
+	form.Set("code_verifier", codeVerifier)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("content-type", "application/x-www-form-urlencoded")
+
+	// GitHub answers errors with a 200.
+	tokens := tokenResponse{}
+	err = getJson(p.HttpClient, request, &tokens)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens.Error) > 0 {
+		return nil, fmt.Errorf("token endpoint error %s: %s", tokens.Error, tokens.ErrorDescription)
+	}
+	if len(tokens.AccessToken) == 0 {
+		return nil, errors.New("token response has no access_token")
+	}
+
+	user := githubUser{}
+	err = p.getApi(ctx, "/user", tokens.AccessToken, &user)
+	if err != nil {
+		return nil, err
+	}
+	if user.Id == 0 {
+		return nil, errors.New("GitHub user has no id")
+	}
+
+	// The email of the profile is the public one, which isn't necessarily verified.
+	emails := []githubEmail{}
+	err = p.getApi(ctx, "/user/emails", tokens.AccessToken, &emails)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := Identity{
+		Subject:           strconv.FormatInt(user.Id, 10),
+		PreferredUsername: user.Login,
+		Name:              user.Name,
+	}
+
+	for _, email := range emails {
+		if email.Primary {
+			identity.Email = email.Email
+			identity.EmailVerified = email.Verified
+			break
+		}
+	}
+
+	return &identity, nil
+}
+
+func (p *GithubProvider) getApi(ctx context.Context, path string, accessToken string, value interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s%s", strings.TrimSuffix(p.ApiUrl, "/"), path), nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("authorization", fmt.Sprintf("Bearer %s", accessToken))
+
+	return getJson(p.HttpClient, request, value)
+}
@@ -0,0 +1,99 @@
+package oauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKeys returns the signature keys of the set by key id, skipping the ones of unsupported
+// types.
+func (s jsonWebKeySet) publicKeys() map[string]crypto.PublicKey {
+	publicKeys := map[string]crypto.PublicKey{}
+
+	for _, key := range s.Keys {
+		if len(key.Use) > 0 && key.Use != "sig" {
+			continue
+		}
+
+		publicKey := key.publicKey()
+		if publicKey != nil {
+			publicKeys[key.Kid] = publicKey
+		}
+	}
+
+	return publicKeys
+}
+
+func (k jsonWebKey) publicKey() crypto.PublicKey {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil
+		}
+
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil
+		}
+
+		publicKey := &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil
+		}
+
+		return publicKey
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,299 @@
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// minJwksRefreshInterval keeps ID tokens with unknown key ids from making us fetch the keys of
+// the issuer over and over.
+const minJwksRefreshInterval = 30 * time.Second
+
+var idTokenSigningMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}
+
+// OidcProvider signs users in with an OpenID Connect issuer, such as Google. Its endpoints and
+// keys are discovered from the issuer on first use.
+type OidcProvider struct {
+	name         string
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	Scopes       []string
+	HttpClient   *http.Client
+
+	mu            sync.Mutex
+	discovery     *discoveryDocument
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+func NewOidcProvider(name string, issuer string, clientId string, clientSecret string, scopes []string) *OidcProvider {
+	return &OidcProvider{
+		name:         name,
+		Issuer:       strings.TrimSuffix(issuer, "/"),
+		ClientId:     clientId,
+		ClientSecret: clientSecret,
+		Scopes:       scopes,
+		HttpClient:   newHttpClient(),
+	}
+}
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IdToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string      `json:"nonce"`
+	Email             string      `json:"email"`
+	EmailVerified     interface{} `json:"email_verified"`
+	PreferredUsername string      `json:"preferred_username"`
+	Name              string      `json:"name"`
+}
+
+type userinfoResponse struct {
+	Subject       string      `json:"sub"`
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"`
+}
+
+func (p *OidcProvider) Name() string {
+	return p.name
+}
+
+func (p *OidcProvider) AuthorizationUrl(ctx context.Context, state string, codeChallenge string, nonce string, redirectUri string) (*string, error) {
+	discovery, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	authorizationUrl, err := url.Parse(discovery.AuthorizationEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	query := authorizationUrl.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.ClientId)
+	query.Set("redirect_uri", redirectUri)
+	query.Set("scope", strings.Join(p.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	authorizationUrl.RawQuery = query.Encode()
+
+	authorizationUrlString := authorizationUrl.String()
+	return &authorizationUrlString, nil
+}
+
+func (p *OidcProvider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string, redirectUri string) (*Identity, error) {
+	discovery, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectUri)
+	form.Set("client_id", p.ClientId)
+	form.Set("client_secret", p.ClientSecret)
+	form.Set("code_verifier", codeVerifier)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("content-type", "application/x-www-form-urlencoded")
+
+	tokens := tokenResponse{}
+	err = getJson(p.HttpClient, request, &tokens)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens.Error) > 0 {
+		return nil, fmt.Errorf("token endpoint error %s: %s", tokens.Error, tokens.ErrorDescription)
+	}
+	if len(tokens.IdToken) == 0 {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	claims, err := p.verifyIdToken(ctx, tokens.IdToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := Identity{
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		EmailVerified:     isTrue(claims.EmailVerified),
+		PreferredUsername: claims.PreferredUsername,
+		Name:              claims.Name,
+	}
+
+	// Some issuers only release the email from the userinfo endpoint.
+	if len(identity.Email) == 0 && len(discovery.UserinfoEndpoint) > 0 && len(tokens.AccessToken) > 0 {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, discovery.UserinfoEndpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Set("authorization", fmt.Sprintf("Bearer %s", tokens.AccessToken))
+
+		userinfo := userinfoResponse{}
+		err = getJson(p.HttpClient, request, &userinfo)
+		if err != nil {
+			return nil, err
+		}
+
+		if userinfo.Subject == identity.Subject {
+			identity.Email = userinfo.Email
+			identity.EmailVerified = isTrue(userinfo.EmailVerified)
+		}
+	}
+
+	return &identity, nil
+}
+
+func (p *OidcProvider) verifyIdToken(ctx context.Context, idToken string, nonce string) (*idTokenClaims, error) {
+	claims := idTokenClaims{}
+
+	parser := jwt.NewParser(jwt.WithValidMethods(idTokenSigningMethods))
+
+	_, err := parser.ParseWithClaims(idToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.getKey(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid id_token: %w", err)
+	}
+
+	if !claims.VerifyIssuer(p.Issuer, true) {
+		return nil, fmt.Errorf("invalid id_token: unexpected issuer %s", claims.Issuer)
+	}
+
+	if !claims.VerifyAudience(p.ClientId, true) {
+		return nil, errors.New("invalid id_token: unexpected audience")
+	}
+
+	if claims.ExpiresAt == nil {
+		return nil, errors.New("invalid id_token: missing exp")
+	}
+
+	if len(claims.Subject) == 0 {
+		return nil, errors.New("invalid id_token: missing sub")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, errors.New("invalid id_token: unexpected nonce")
+	}
+
+	return &claims, nil
+}
+
+func (p *OidcProvider) getDiscovery(ctx context.Context) (*discoveryDocument, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/.well-known/openid-configuration", p.Issuer), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	discovery := discoveryDocument{}
+	err = getJson(p.HttpClient, request, &discovery)
+	if err != nil {
+		return nil, err
+	}
+
+	if discovery.Issuer != p.Issuer {
+		return nil, fmt.Errorf("discovery document of %s is for issuer %s", p.Issuer, discovery.Issuer)
+	}
+
+	if len(discovery.AuthorizationEndpoint) == 0 || len(discovery.TokenEndpoint) == 0 || len(discovery.JwksUri) == 0 {
+		return nil, fmt.Errorf("discovery document of %s is incomplete", p.Issuer)
+	}
+
+	p.discovery = &discovery
+
+	return p.discovery, nil
+}
+
+// getKey returns the issuer's key with the id, fetching the keys again if it isn't known yet, as
+// issuers rotate their keys.
+func (p *OidcProvider) getKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	discovery, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	if p.keys != nil && time.Since(p.keysFetchedAt) < minJwksRefreshInterval {
+		return nil, fmt.Errorf("unknown key id %s", kid)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, discovery.JwksUri, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	jwks := jsonWebKeySet{}
+	err = getJson(p.HttpClient, request, &jwks)
+	if err != nil {
+		return nil, err
+	}
+
+	p.keys = jwks.publicKeys()
+	p.keysFetchedAt = time.Now()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unknown key id %s", kid)
+}
+
+// isTrue reads boolean claims, which some issuers send as strings.
+func isTrue(claim interface{}) bool {
+	switch value := claim.(type) {
+	case bool:
+		return value
+	case string:
+		isTrue, _ := strconv.ParseBool(value)
+		return isTrue
+	default:
+		return false
+	}
+}
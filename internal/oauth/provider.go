@@ -0,0 +1,73 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Identity is a user as asserted by a provider. Subject is the provider's stable id for the user,
+// and EmailVerified tells whether the provider vouches for the user owning Email.
+type Identity struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+	Name              string
+}
+
+// Provider signs users in with the OAuth 2.0 authorization code flow with PKCE.
+type Provider interface {
+	Name() string
+	// AuthorizationUrl returns the URL to send the user to, which redirects back to redirectUri
+	// with the state and a code.
+	AuthorizationUrl(ctx context.Context, state string, codeChallenge string, nonce string, redirectUri string) (*string, error)
+	// Exchange redeems the code for the identity of the user.
+	Exchange(ctx context.Context, code string, codeVerifier string, nonce string, redirectUri string) (*Identity, error)
+}
+
+// CodeChallenge returns the S256 PKCE challenge of the verifier.
+func CodeChallenge(codeVerifier string) string {
+	hash := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+const maxResponseBodySize = 1 << 20
+
+func newHttpClient() *http.Client {
+	return &http.Client{
+		Timeout: 10 * time.Second,
+	}
+}
+
+// getJson decodes the JSON response body of the request into value, failing on non 2xx statuses.
+func getJson(httpClient *http.Client, request *http.Request, value interface{}) error {
+	request.Header.Set("accept", "application/json")
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxResponseBodySize))
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("%s %s: unexpected status %d: %s", request.Method, request.URL.Redacted(), response.StatusCode, body)
+	}
+
+	err = json.Unmarshal(body, value)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", request.Method, request.URL.Redacted(), err)
+	}
+
+	return nil
+}
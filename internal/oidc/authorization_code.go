@@ -0,0 +1,37 @@
+package oidc
+
+import "time"
+
+// AuthorizationCode is the server-side state of an authorization granted to a client, keyed by the
+// hash of the code. RedirectUriProvided tells whether the client sent the redirect URI, rather
+// than relying on its only registered one. CodeChallenge is the S256 PKCE challenge, if the client
+// sent one.
+type AuthorizationCode struct {
+	CodeHash            string
+	ClientId            string
+	UserId              string
+	RedirectUri         string
+	RedirectUriProvided bool
+	Scopes              []string
+	Nonce               string
+	CodeChallenge       string
+	AuthTime            time.Time
+	CreatedAt           time.Time
+	ExpiresAt           time.Time
+}
+
+func NewAuthorizationCode(codeHash string, clientId string, userId string, redirectUri string, redirectUriProvided bool, scopes []string, nonce string, codeChallenge string, authTime time.Time, createdAt time.Time, expiresAt time.Time) AuthorizationCode {
+	return AuthorizationCode{
+		CodeHash:            codeHash,
+		ClientId:            clientId,
+		UserId:              userId,
+		RedirectUri:         redirectUri,
+		RedirectUriProvided: redirectUriProvided,
+		Scopes:              scopes,
+		Nonce:               nonce,
+		CodeChallenge:       codeChallenge,
+		AuthTime:            authTime,
+		CreatedAt:           createdAt,
+		ExpiresAt:           expiresAt,
+	}
+}
@@ -0,0 +1,10 @@
+package oidc
+
+import "context"
+
+type AuthorizationCodeRepository interface {
+	Create(ctx context.Context, authorizationCode AuthorizationCode) error
+	// Consume atomically deletes the code and returns it, so that every code is redeemed at most
+	// once.
+	Consume(ctx context.Context, codeHash string) (*AuthorizationCode, error)
+}
@@ -0,0 +1,36 @@
+package oidc
+
+import "crypto/subtle"
+
+// Client is an application allowed to sign users in with the service. Clients without a secret
+// are public clients, such as single-page apps, which must use PKCE.
+type Client struct {
+	Id           string
+	Secret       string
+	RedirectUris []string
+}
+
+func NewClient(id string, secret string, redirectUris []string) Client {
+	return Client{
+		Id:           id,
+		Secret:       secret,
+		RedirectUris: redirectUris,
+	}
+}
+
+func (c Client) IsPublic() bool {
+	return len(c.Secret) == 0
+}
+
+func (c Client) HasRedirectUri(redirectUri string) bool {
+	for _, clientRedirectUri := range c.RedirectUris {
+		if clientRedirectUri == redirectUri {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Client) IsCorrectSecret(secret string) bool {
+	return !c.IsPublic() && subtle.ConstantTimeCompare([]byte(c.Secret), []byte(secret)) == 1
+}
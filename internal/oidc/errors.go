@@ -0,0 +1,22 @@
+package oidc
+
+// Error is an OAuth 2.0 error, whose code is one of the ones of RFC 6749, answered to clients as
+// is.
+type Error struct {
+	Code        string
+	Description string
+}
+
+func (e *Error) Error() string {
+	return e.Description
+}
+
+const (
+	invalidRequest          = "invalid_request"
+	invalidClient           = "invalid_client"
+	invalidGrant            = "invalid_grant"
+	invalidScope            = "invalid_scope"
+	unsupportedGrantType    = "unsupported_grant_type"
+	unsupportedResponseType = "unsupported_response_type"
+	loginRequired           = "login_required"
+)
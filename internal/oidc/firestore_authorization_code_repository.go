@@ -0,0 +1,91 @@
+package oidc
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type FirestoreAuthorizationCodeRepository struct {
+	Firestore *firestore.Client
+}
+
+func NewFirestoreAuthorizationCodeRepository(firestore *firestore.Client) *FirestoreAuthorizationCodeRepository {
+	return &FirestoreAuthorizationCodeRepository{
+		Firestore: firestore,
+	}
+}
+
+const authorizationCodesCollectionName = "oidc_authorization_codes"
+
+type authorizationCodeDocData struct {
+	ClientId            string    `firestore:"client_id"`
+	UserId              string    `firestore:"user_id"`
+	RedirectUri         string    `firestore:"redirect_uri"`
+	RedirectUriProvided bool      `firestore:"redirect_uri_provided"`
+	Scopes              []string  `firestore:"scopes"`
+	Nonce               string    `firestore:"nonce"`
+	CodeChallenge       string    `firestore:"code_challenge"`
+	AuthTime            time.Time `firestore:"auth_time"`
+	CreatedAt           time.Time `firestore:"created_at"`
+	ExpiresAt           time.Time `firestore:"expires_at"`
+}
+
+func (r *FirestoreAuthorizationCodeRepository) Create(ctx context.Context, authorizationCode AuthorizationCode) error {
+	authorizationCodeData := authorizationCodeDocData{
+		ClientId:            authorizationCode.ClientId,
+		UserId:              authorizationCode.UserId,
+		RedirectUri:         authorizationCode.RedirectUri,
+		RedirectUriProvided: authorizationCode.RedirectUriProvided,
+		Scopes:              authorizationCode.Scopes,
+		Nonce:               authorizationCode.Nonce,
+		CodeChallenge:       authorizationCode.CodeChallenge,
+		AuthTime:            authorizationCode.AuthTime,
+		CreatedAt:           authorizationCode.CreatedAt,
+		ExpiresAt:           authorizationCode.ExpiresAt,
+	}
+
+	_, err := r.Firestore.Collection(authorizationCodesCollectionName).Doc(authorizationCode.CodeHash).Create(ctx, authorizationCodeData)
+	if err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			return &custom_errors.AlreadyExistsError{Message: "Authorization code already exists"}
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *FirestoreAuthorizationCodeRepository) Consume(ctx context.Context, codeHash string) (*AuthorizationCode, error) {
+	authorizationCodeDocRef := r.Firestore.Collection(authorizationCodesCollectionName).Doc(codeHash)
+
+	var authorizationCode AuthorizationCode
+	err := r.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		authorizationCodeDocSnapshot, err := tx.Get(authorizationCodeDocRef)
+		if err != nil {
+			return err
+		}
+
+		authorizationCodeData := authorizationCodeDocData{}
+		err = authorizationCodeDocSnapshot.DataTo(&authorizationCodeData)
+		if err != nil {
+			return err
+		}
+
+		authorizationCode = NewAuthorizationCode(codeHash, authorizationCodeData.ClientId, authorizationCodeData.UserId, authorizationCodeData.RedirectUri, authorizationCodeData.RedirectUriProvided, authorizationCodeData.Scopes, authorizationCodeData.Nonce, authorizationCodeData.CodeChallenge, authorizationCodeData.AuthTime, authorizationCodeData.CreatedAt, authorizationCodeData.ExpiresAt)
+
+		return tx.Delete(authorizationCodeDocRef)
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, &custom_errors.NotFoundError{Message: "Authorization code not found"}
+		}
+		return nil, err
+	}
+
+	return &authorizationCode, nil
+}
@@ -0,0 +1,65 @@
+package oidc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+)
+
+// inMemoryAuthorizationCodesSweepInterval is the number of codes created between sweeps of the
+// expired ones, which keeps codes clients never redeemed from piling up.
+const inMemoryAuthorizationCodesSweepInterval = 1024
+
+type InMemoryAuthorizationCodeRepository struct {
+	mu                 sync.Mutex
+	authorizationCodes map[string]AuthorizationCode
+	creates            int
+}
+
+func NewInMemoryAuthorizationCodeRepository() *InMemoryAuthorizationCodeRepository {
+	return &InMemoryAuthorizationCodeRepository{
+		authorizationCodes: map[string]AuthorizationCode{},
+	}
+}
+
+func (r *InMemoryAuthorizationCodeRepository) Create(ctx context.Context, authorizationCode AuthorizationCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.creates++
+	if r.creates%inMemoryAuthorizationCodesSweepInterval == 0 {
+		r.sweep(time.Now())
+	}
+
+	if _, ok := r.authorizationCodes[authorizationCode.CodeHash]; ok {
+		return &custom_errors.AlreadyExistsError{Message: "Authorization code already exists"}
+	}
+
+	r.authorizationCodes[authorizationCode.CodeHash] = authorizationCode
+
+	return nil
+}
+
+func (r *InMemoryAuthorizationCodeRepository) Consume(ctx context.Context, codeHash string) (*AuthorizationCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	authorizationCode, ok := r.authorizationCodes[codeHash]
+	if !ok {
+		return nil, &custom_errors.NotFoundError{Message: "Authorization code not found"}
+	}
+
+	delete(r.authorizationCodes, codeHash)
+
+	return &authorizationCode, nil
+}
+
+func (r *InMemoryAuthorizationCodeRepository) sweep(now time.Time) {
+	for codeHash, authorizationCode := range r.authorizationCodes {
+		if !now.Before(authorizationCode.ExpiresAt) {
+			delete(r.authorizationCodes, codeHash)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package oidc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/auth"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/users"
+	"github.com/rs/zerolog/log"
+)
+
+type OidcHandlers struct {
+	OidcService  OidcService
+	UsersService users.UsersService
+	// LoginUrl is the page unauthenticated users are sent to, with the authorization URL to come
+	// back to once signed in as its return_to query parameter.
+	LoginUrl string
+}
+
+func NewOidcHandlers(oidcService OidcService, usersService users.UsersService, loginUrl string) OidcHandlers {
+	return OidcHandlers{
+		OidcService:  oidcService,
+		UsersService: usersService,
+		LoginUrl:     loginUrl,
+	}
+}
+
+type errorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+type redirectResponse struct {
+	RedirectUri string `json:"redirectUri"`
+}
+
+func (h *OidcHandlers) GetDiscovery(w http.ResponseWriter, r *http.Request) {
+	response, err := json.Marshal(h.OidcService.Discovery())
+	if err != nil {
+		log.Error().Err(err).Msg("Error marshalling OpenID Connect discovery document")
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.Header().Set("cache-control", "public, max-age=300")
+	w.Write(response)
+}
+
+// Authorize authenticates users with the token of the Authorization header, which browsers can't
+// send on navigations, so frontends complete authorizations with a request accepting
+// application/json, which gets the URI to redirect to in the body rather than a redirect.
+func (h *OidcHandlers) Authorize(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, &Error{Code: invalidRequest, Description: err.Error()})
+		return
+	}
+
+	request := AuthorizationRequest{
+		ResponseType:        r.Form.Get("response_type"),
+		ClientId:            r.Form.Get("client_id"),
+		RedirectUri:         r.Form.Get("redirect_uri"),
+		Scope:               r.Form.Get("scope"),
+		State:               r.Form.Get("state"),
+		Nonce:               r.Form.Get("nonce"),
+		CodeChallenge:       r.Form.Get("code_challenge"),
+		CodeChallengeMethod: r.Form.Get("code_challenge_method"),
+		Prompt:              r.Form.Get("prompt"),
+	}
+
+	redirectUri, err := h.OidcService.GetRedirectUri(request)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error authorizing client %s", request.ClientId)
+		if oidcErr, ok := err.(*Error); ok {
+			writeError(w, http.StatusBadRequest, oidcErr)
+			return
+		}
+
+		writeError(w, http.StatusInternalServerError, &Error{Code: "server_error"})
+		return
+	}
+
+	// API keys can't sign users in to other apps, as they'd then get tokens beyond the keys' scopes.
+	claims, ok := r.Context().Value(auth.ClaimsContextKey).(*auth.Claims)
+	if !ok {
+		if request.Prompt != "none" && len(h.LoginUrl) > 0 && r.Method == http.MethodGet {
+			loginUrl, err := url.Parse(h.LoginUrl)
+			if err != nil {
+				log.Error().Err(err).Msgf("Error parsing login URL %s", h.LoginUrl)
+				writeError(w, http.StatusInternalServerError, &Error{Code: "server_error"})
+				return
+			}
+
+			query := loginUrl.Query()
+			query.Set("return_to", h.OidcService.Issuer+r.URL.RequestURI())
+			loginUrl.RawQuery = query.Encode()
+
+			http.Redirect(w, r, loginUrl.String(), http.StatusFound)
+			return
+		}
+
+		h.redirectWithError(w, r, *redirectUri, request.State, &Error{Code: loginRequired, Description: "The user must sign in"})
+		return
+	}
+
+	user, err := users.GetAuthenticatedUser(r, &h.UsersService)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting the user authorizing client %s", request.ClientId)
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			h.redirectWithError(w, r, *redirectUri, request.State, &Error{Code: loginRequired, Description: "The user must sign in"})
+			return
+		}
+
+		writeError(w, http.StatusInternalServerError, &Error{Code: "server_error"})
+		return
+	}
+
+	authTime := time.Now()
+	if claims.IssuedAt > 0 {
+		authTime = time.Unix(claims.IssuedAt, 0)
+	}
+
+	code, err := h.OidcService.Authorize(r.Context(), request, *redirectUri, user.Id, authTime)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error authorizing client %s for User %s", request.ClientId, user.Username)
+		if oidcErr, ok := err.(*Error); ok {
+			h.redirectWithError(w, r, *redirectUri, request.State, oidcErr)
+			return
+		}
+
+		writeError(w, http.StatusInternalServerError, &Error{Code: "server_error"})
+		return
+	}
+
+	h.redirect(w, r, *redirectUri, map[string]string{"code": *code, "state": request.State})
+}
+
+func (h *OidcHandlers) Token(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, &Error{Code: invalidRequest, Description: err.Error()})
+		return
+	}
+
+	request := TokenRequest{
+		GrantType:    r.PostForm.Get("grant_type"),
+		ClientId:     r.PostForm.Get("client_id"),
+		ClientSecret: r.PostForm.Get("client_secret"),
+		Code:         r.PostForm.Get("code"),
+		RedirectUri:  r.PostForm.Get("redirect_uri"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+	}
+
+	// Credentials of client_secret_basic are form encoded before being base64 encoded.
+	basicClientId, basicClientSecret, hasBasicAuth := r.BasicAuth()
+	if hasBasicAuth {
+		request.ClientId, err = url.QueryUnescape(basicClientId)
+		if err == nil {
+			request.ClientSecret, err = url.QueryUnescape(basicClientSecret)
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, &Error{Code: invalidRequest, Description: "Malformed client credentials"})
+			return
+		}
+	}
+
+	tokenResponse, err := h.OidcService.ExchangeCode(r.Context(), request)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error exchanging code of client %s", request.ClientId)
+		if oidcErr, ok := err.(*Error); ok {
+			if oidcErr.Code == invalidClient {
+				if hasBasicAuth {
+					w.Header().Set("www-authenticate", `Basic realm="oauth"`)
+				}
+				writeError(w, http.StatusUnauthorized, oidcErr)
+				return
+			}
+
+			writeError(w, http.StatusBadRequest, oidcErr)
+			return
+		}
+
+		writeError(w, http.StatusInternalServerError, &Error{Code: "server_error"})
+		return
+	}
+
+	response, err := json.Marshal(tokenResponse)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error marshalling token response of client %s", request.ClientId)
+		writeError(w, http.StatusInternalServerError, &Error{Code: "server_error"})
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.Header().Set("cache-control", "no-store")
+	w.Write(response)
+}
+
+func (h *OidcHandlers) Userinfo(w http.ResponseWriter, r *http.Request) {
+	user, err := users.GetAuthenticatedUser(r, &h.UsersService)
+	if err != nil {
+		log.Error().Err(err).Msg("Error getting the authenticated User")
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			w.Header().Set("www-authenticate", `Bearer error="invalid_token"`)
+			writeError(w, http.StatusUnauthorized, &Error{Code: "invalid_token", Description: "User not found"})
+			return
+		}
+
+		writeError(w, http.StatusInternalServerError, &Error{Code: "server_error"})
+		return
+	}
+
+	tokenScopes, _ := r.Context().Value(auth.ScopesContextKey).([]string)
+
+	response, err := json.Marshal(h.OidcService.Userinfo(*user, tokenScopes))
+	if err != nil {
+		log.Error().Err(err).Msgf("Error marshalling userinfo of User %s", user.Username)
+		writeError(w, http.StatusInternalServerError, &Error{Code: "server_error"})
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.Header().Set("cache-control", "no-store")
+	w.Write(response)
+}
+
+func (h *OidcHandlers) redirectWithError(w http.ResponseWriter, r *http.Request, redirectUri string, state string, err *Error) {
+	h.redirect(w, r, redirectUri, map[string]string{"error": err.Code, "error_description": err.Description, "state": state})
+}
+
+// redirect sends the user back to the client with the parameters added to the redirect URI's
+// query, or answers the URI to requests accepting JSON.
+func (h *OidcHandlers) redirect(w http.ResponseWriter, r *http.Request, redirectUri string, params map[string]string) {
+	parsedRedirectUri, err := url.Parse(redirectUri)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error parsing redirect URI %s", redirectUri)
+		writeError(w, http.StatusInternalServerError, &Error{Code: "server_error"})
+		return
+	}
+
+	query := parsedRedirectUri.Query()
+	for key, value := range params {
+		if len(value) > 0 {
+			query.Set(key, value)
+		}
+	}
+	parsedRedirectUri.RawQuery = query.Encode()
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		response, err := json.Marshal(redirectResponse{RedirectUri: parsedRedirectUri.String()})
+		if err != nil {
+			log.Error().Err(err).Msg("Error marshalling redirect response")
+			writeError(w, http.StatusInternalServerError, &Error{Code: "server_error"})
+			return
+		}
+
+		w.Header().Set("content-type", "application/json")
+		w.Header().Set("cache-control", "no-store")
+		w.Write(response)
+		return
+	}
+
+	http.Redirect(w, r, parsedRedirectUri.String(), http.StatusFound)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, err *Error) {
+	response, marshalErr := json.Marshal(errorResponse{Error: err.Code, ErrorDescription: err.Description})
+	if marshalErr != nil {
+		http.Error(w, http.StatusText(statusCode), statusCode)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.Header().Set("cache-control", "no-store")
+	w.WriteHeader(statusCode)
+	w.Write(response)
+}
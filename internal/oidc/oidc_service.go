@@ -0,0 +1,331 @@
+package oidc
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/auth"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/oauth"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/users"
+)
+
+const (
+	openidScope  = "openid"
+	profileScope = "profile"
+	emailScope   = "email"
+)
+
+var supportedScopes = []string{openidScope, profileScope, emailScope}
+
+// OidcService makes the service an OpenID Connect provider for the registered clients, with the
+// authorization code flow. Access tokens are signed like the tokens the service issues on login,
+// but only carry the granted scopes, so clients can read the userinfo but can't act as the user.
+type OidcService struct {
+	Issuer                      string
+	Clients                     map[string]Client
+	AuthorizationCodeRepository AuthorizationCodeRepository
+	UsersService                users.UsersService
+	JwtService                  auth.JwtService
+	CodeSecondsToExpire         int
+}
+
+func NewOidcService(issuer string, clients map[string]Client, authorizationCodeRepository AuthorizationCodeRepository, usersService users.UsersService, jwtService auth.JwtService, codeSecondsToExpire int) OidcService {
+	return OidcService{
+		Issuer:                      strings.TrimSuffix(issuer, "/"),
+		Clients:                     clients,
+		AuthorizationCodeRepository: authorizationCodeRepository,
+		UsersService:                usersService,
+		JwtService:                  jwtService,
+		CodeSecondsToExpire:         codeSecondsToExpire,
+	}
+}
+
+type AuthorizationRequest struct {
+	ResponseType        string
+	ClientId            string
+	RedirectUri         string
+	Scope               string
+	State               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Prompt              string
+}
+
+type TokenRequest struct {
+	GrantType    string
+	ClientId     string
+	ClientSecret string
+	Code         string
+	RedirectUri  string
+	CodeVerifier string
+}
+
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	IdToken     string `json:"id_token"`
+	Scope       string `json:"scope"`
+}
+
+// scopedClaims are the claims about the user released by the profile and email scopes.
+type scopedClaims struct {
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Picture           string `json:"picture,omitempty"`
+	Email             string `json:"email,omitempty"`
+	EmailVerified     *bool  `json:"email_verified,omitempty"`
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	AuthTime int64  `json:"auth_time"`
+	Nonce    string `json:"nonce,omitempty"`
+	scopedClaims
+}
+
+type Userinfo struct {
+	Subject string `json:"sub"`
+	scopedClaims
+}
+
+type DiscoveryDocument struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JwksUri                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IdTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+func (s *OidcService) Discovery() DiscoveryDocument {
+	return DiscoveryDocument{
+		Issuer:                            s.Issuer,
+		AuthorizationEndpoint:             fmt.Sprintf("%s/oauth/authorize", s.Issuer),
+		TokenEndpoint:                     fmt.Sprintf("%s/oauth/token", s.Issuer),
+		UserinfoEndpoint:                  fmt.Sprintf("%s/oauth/userinfo", s.Issuer),
+		JwksUri:                           fmt.Sprintf("%s/.well-known/jwks.json", s.Issuer),
+		ScopesSupported:                   supportedScopes,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code"},
+		SubjectTypesSupported:             []string{"public"},
+		IdTokenSigningAlgValuesSupported:  []string{s.JwtService.IdTokenSigningAlg()},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "preferred_username", "picture", "email", "email_verified"},
+	}
+}
+
+// GetRedirectUri returns the redirect URI of the request, which must be one of the client's.
+// Errors must be shown to the user rather than redirected to a URI which can't be trusted.
+func (s *OidcService) GetRedirectUri(request AuthorizationRequest) (*string, error) {
+	client, ok := s.Clients[request.ClientId]
+	if !ok {
+		return nil, &Error{Code: invalidRequest, Description: "Unknown client_id"}
+	}
+
+	redirectUri := request.RedirectUri
+	if len(redirectUri) == 0 && len(client.RedirectUris) == 1 {
+		redirectUri = client.RedirectUris[0]
+	}
+
+	if !client.HasRedirectUri(redirectUri) {
+		return nil, &Error{Code: invalidRequest, Description: "Unregistered redirect_uri"}
+	}
+
+	return &redirectUri, nil
+}
+
+// Authorize grants the request of the client for the user, who authenticated at authTime, and
+// returns the code the client redeems at the token endpoint. Clients are first-party apps, so
+// users aren't asked for their consent.
+func (s *OidcService) Authorize(ctx context.Context, request AuthorizationRequest, redirectUri string, userId string, authTime time.Time) (*string, error) {
+	client := s.Clients[request.ClientId]
+
+	if request.ResponseType != "code" {
+		return nil, &Error{Code: unsupportedResponseType, Description: "Only the code response_type is supported"}
+	}
+
+	scopes, err := parseScope(request.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(request.CodeChallenge) > 0 && request.CodeChallengeMethod != "S256" {
+		return nil, &Error{Code: invalidRequest, Description: "Only the S256 code_challenge_method is supported"}
+	}
+
+	if client.IsPublic() && len(request.CodeChallenge) == 0 {
+		return nil, &Error{Code: invalidRequest, Description: "Public clients must send a code_challenge"}
+	}
+
+	code, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	err = s.AuthorizationCodeRepository.Create(ctx, NewAuthorizationCode(auth.HashOpaqueToken(*code), client.Id, userId, redirectUri, len(request.RedirectUri) > 0, scopes, request.Nonce, request.CodeChallenge, authTime, now, now.Add(time.Second*time.Duration(s.CodeSecondsToExpire))))
+	if err != nil {
+		return nil, err
+	}
+
+	return code, nil
+}
+
+// ExchangeCode redeems the code for an access token with the granted scopes and an ID token. The
+// redirect_uri must be the one of the authorization request, if it was sent there or here.
+func (s *OidcService) ExchangeCode(ctx context.Context, request TokenRequest) (*TokenResponse, error) {
+	client, ok := s.Clients[request.ClientId]
+	if !ok || (!client.IsPublic() && !client.IsCorrectSecret(request.ClientSecret)) {
+		return nil, &Error{Code: invalidClient, Description: "Invalid client credentials"}
+	}
+
+	if request.GrantType != "authorization_code" {
+		return nil, &Error{Code: unsupportedGrantType, Description: "Only the authorization_code grant_type is supported"}
+	}
+
+	invalidCodeErr := &Error{Code: invalidGrant, Description: "Invalid or expired code"}
+
+	authorizationCode, err := s.AuthorizationCodeRepository.Consume(ctx, auth.HashOpaqueToken(request.Code))
+	if err != nil {
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			return nil, invalidCodeErr
+		}
+		return nil, err
+	}
+
+	if authorizationCode.ClientId != client.Id || !time.Now().Before(authorizationCode.ExpiresAt) {
+		return nil, invalidCodeErr
+	}
+
+	if (authorizationCode.RedirectUriProvided || len(request.RedirectUri) > 0) && authorizationCode.RedirectUri != request.RedirectUri {
+		return nil, invalidCodeErr
+	}
+
+	if len(authorizationCode.CodeChallenge) > 0 || len(request.CodeVerifier) > 0 {
+		if subtle.ConstantTimeCompare([]byte(oauth.CodeChallenge(request.CodeVerifier)), []byte(authorizationCode.CodeChallenge)) != 1 {
+			return nil, &Error{Code: invalidGrant, Description: "Invalid code_verifier"}
+		}
+	}
+
+	user, err := s.UsersService.GetUserById(ctx, authorizationCode.UserId)
+	if err != nil {
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			return nil, invalidCodeErr
+		}
+		return nil, err
+	}
+
+	accessToken, err := s.JwtService.GenerateScopedToken(user.Id, user.Username, authorizationCode.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	idToken, err := s.JwtService.SignIdToken(idTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.Issuer,
+			Subject:   user.Id,
+			Audience:  jwt.ClaimStrings{client.Id},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Second * time.Duration(s.JwtService.SecondsToExpire))),
+		},
+		AuthTime:     authorizationCode.AuthTime.Unix(),
+		Nonce:        authorizationCode.Nonce,
+		scopedClaims: newScopedClaims(*user, authorizationCode.Scopes),
+	}, client.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenResponse{
+		AccessToken: *accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   s.JwtService.SecondsToExpire,
+		IdToken:     *idToken,
+		Scope:       strings.Join(authorizationCode.Scopes, " "),
+	}, nil
+}
+
+// Userinfo returns the claims about the user released by the scopes of the access token. Tokens
+// the service issued on login aren't OpenID Connect ones, and release every claim.
+func (s *OidcService) Userinfo(user users.User, tokenScopes []string) Userinfo {
+	scopes := supportedScopes
+	if contains(tokenScopes, openidScope) {
+		scopes = tokenScopes
+	}
+
+	return Userinfo{
+		Subject:      user.Id,
+		scopedClaims: newScopedClaims(user, scopes),
+	}
+}
+
+func newScopedClaims(user users.User, scopes []string) scopedClaims {
+	claims := scopedClaims{}
+
+	for _, scope := range scopes {
+		switch scope {
+		case profileScope:
+			claims.PreferredUsername = user.Username
+			if user.Image != nil {
+				claims.Picture = *user.Image
+			}
+		case emailScope:
+			emailVerified := user.EmailVerified
+			claims.Email = user.Email
+			claims.EmailVerified = &emailVerified
+		}
+	}
+
+	return claims
+}
+
+// parseScope returns the supported scopes of the space separated scope, which must include
+// openid. Other scopes are ignored, as OpenID Connect allows.
+func parseScope(scope string) ([]string, error) {
+	scopes := []string{}
+	hasOpenidScope := false
+
+	for _, requestedScope := range strings.Fields(scope) {
+		for _, supportedScope := range supportedScopes {
+			if requestedScope == supportedScope && !contains(scopes, requestedScope) {
+				scopes = append(scopes, requestedScope)
+			}
+		}
+
+		if requestedScope == openidScope {
+			hasOpenidScope = true
+		}
+	}
+
+	if !hasOpenidScope {
+		return nil, &Error{Code: invalidScope, Description: "The openid scope is required"}
+	}
+
+	return scopes, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+)
+
+type PostgresAuthorizationCodeRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewPostgresAuthorizationCodeRepository(pool *pgxpool.Pool) *PostgresAuthorizationCodeRepository {
+	return &PostgresAuthorizationCodeRepository{
+		Pool: pool,
+	}
+}
+
+func (r *PostgresAuthorizationCodeRepository) Create(ctx context.Context, authorizationCode AuthorizationCode) error {
+	_, err := r.Pool.Exec(ctx, `INSERT INTO oidc_authorization_codes (code_hash, client_id, user_id, redirect_uri, redirect_uri_provided, scopes, nonce, code_challenge, auth_time, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		authorizationCode.CodeHash, authorizationCode.ClientId, authorizationCode.UserId, authorizationCode.RedirectUri, authorizationCode.RedirectUriProvided, authorizationCode.Scopes, authorizationCode.Nonce, authorizationCode.CodeChallenge, authorizationCode.AuthTime, authorizationCode.CreatedAt, authorizationCode.ExpiresAt)
+	return err
+}
+
+func (r *PostgresAuthorizationCodeRepository) Consume(ctx context.Context, codeHash string) (*AuthorizationCode, error) {
+	authorizationCode := AuthorizationCode{}
+	err := r.Pool.QueryRow(ctx, `DELETE FROM oidc_authorization_codes
+		WHERE code_hash = $1
+		RETURNING code_hash, client_id, user_id, redirect_uri, redirect_uri_provided, scopes, nonce, code_challenge, auth_time, created_at, expires_at`, codeHash).
+		Scan(&authorizationCode.CodeHash, &authorizationCode.ClientId, &authorizationCode.UserId, &authorizationCode.RedirectUri, &authorizationCode.RedirectUriProvided, &authorizationCode.Scopes, &authorizationCode.Nonce, &authorizationCode.CodeChallenge, &authorizationCode.AuthTime, &authorizationCode.CreatedAt, &authorizationCode.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &custom_errors.NotFoundError{Message: "Authorization code not found"}
+		}
+		return nil, err
+	}
+
+	return &authorizationCode, nil
+}
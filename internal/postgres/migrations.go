@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// Arbitrary key for pg_advisory_lock so that only one instance migrates at a time.
+const migrationsLockKey = 7_264_813_390
+
+func Migrate(ctx context.Context, pool *pgxpool.Pool) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationsLockKey)
+	if err != nil {
+		return err
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationsLockKey)
+
+	_, err = conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version TEXT PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return err
+	}
+
+	fileNames, err := fs.Glob(migrationsFS, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(fileNames)
+
+	for _, fileName := range fileNames {
+		version := strings.TrimSuffix(strings.TrimPrefix(fileName, "migrations/"), ".sql")
+
+		var applied bool
+		err = conn.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)", version).Scan(&applied)
+		if err != nil {
+			return err
+		}
+		if applied {
+			continue
+		}
+
+		migration, err := migrationsFS.ReadFile(fileName)
+		if err != nil {
+			return err
+		}
+
+		log.Info().Msgf("Applying migration %s...", version)
+
+		err = conn.BeginFunc(ctx, func(tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, string(migration))
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec(ctx, "INSERT INTO schema_migrations (version) VALUES ($1)", version)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,16 @@
+-- Canonical keys must match users.canonicalKey: trimmed, NFKC-normalized and lowercased.
+ALTER TABLE users
+    ADD COLUMN username_key TEXT,
+    ADD COLUMN email_key TEXT;
+
+UPDATE users
+SET username_key = lower(normalize(btrim(username), NFKC)),
+    email_key = lower(normalize(btrim(email), NFKC));
+
+ALTER TABLE users
+    ALTER COLUMN username_key SET NOT NULL,
+    ALTER COLUMN email_key SET NOT NULL,
+    DROP CONSTRAINT users_username_key,
+    DROP CONSTRAINT users_email_key,
+    ADD CONSTRAINT users_username_key_unique UNIQUE (username_key),
+    ADD CONSTRAINT users_email_key_unique UNIQUE (email_key);
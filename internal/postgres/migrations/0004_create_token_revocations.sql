@@ -0,0 +1,11 @@
+CREATE TABLE revoked_tokens (
+    token_id TEXT PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX revoked_tokens_expires_at_idx ON revoked_tokens (expires_at);
+
+CREATE TABLE subject_revocations (
+    subject TEXT PRIMARY KEY,
+    revoked_before TIMESTAMPTZ NOT NULL
+);
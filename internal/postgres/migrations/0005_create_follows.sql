@@ -0,0 +1,8 @@
+CREATE TABLE follows (
+    follower_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    followee_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (follower_id, followee_id)
+);
+
+CREATE INDEX follows_followee_id_idx ON follows (followee_id);
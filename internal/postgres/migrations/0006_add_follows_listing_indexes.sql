@@ -0,0 +1,5 @@
+DROP INDEX follows_followee_id_idx;
+
+CREATE INDEX follows_followee_id_created_at_idx ON follows (followee_id, created_at DESC, follower_id DESC);
+
+CREATE INDEX follows_follower_id_created_at_idx ON follows (follower_id, created_at DESC, followee_id DESC);
@@ -0,0 +1,8 @@
+CREATE TABLE totp_enrollments (
+    user_id TEXT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    recovery_code_hashes TEXT[] NOT NULL,
+    last_used_step BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    confirmed_at TIMESTAMPTZ
+);
@@ -0,0 +1,20 @@
+CREATE TABLE webauthn_credentials (
+    id TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    public_key BYTEA NOT NULL,
+    sign_count BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    PRIMARY KEY (user_id, id)
+);
+
+CREATE TABLE webauthn_challenges (
+    challenge_hash TEXT PRIMARY KEY,
+    ceremony TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX webauthn_challenges_expires_at_idx ON webauthn_challenges (expires_at);
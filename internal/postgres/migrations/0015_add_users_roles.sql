@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN roles TEXT[] NOT NULL DEFAULT '{user}';
@@ -0,0 +1 @@
+ALTER TABLE oidc_authorization_codes ADD COLUMN redirect_uri_provided BOOLEAN NOT NULL DEFAULT TRUE;
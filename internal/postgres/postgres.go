@@ -0,0 +1,15 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+func InitPostgres(ctx context.Context, url string) (*pgxpool.Pool, error) {
+	pool, err := pgxpool.Connect(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
@@ -0,0 +1,25 @@
+package ratelimit
+
+import (
+	"net/http"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/auth"
+)
+
+// ByIp keys requests by client IP, as resolved by auth.ClientIp.
+func ByIp(r *http.Request) string {
+	return "ip:" + auth.ClientIp(r)
+}
+
+// ByUser keys authenticated requests by user, and anonymous ones by client IP.
+func ByUser(r *http.Request) string {
+	if userId, ok := r.Context().Value(auth.UserIdContextKey).(string); ok && len(userId) > 0 {
+		return "user:" + userId
+	}
+
+	if username, ok := r.Context().Value(auth.UsernameContextKey).(string); ok && len(username) > 0 {
+		return "username:" + username
+	}
+
+	return ByIp(r)
+}
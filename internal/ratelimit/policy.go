@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy allows Limit requests per Window, in bursts of up to Limit requests.
+type Policy struct {
+	Limit  int
+	Window time.Duration
+}
+
+func NewPolicy(limit int, window time.Duration) Policy {
+	return Policy{
+		Limit:  limit,
+		Window: window,
+	}
+}
+
+// ParsePolicy parses policies written as <limit>/<window>, e.g. 10/1m, where window is a
+// time.Duration.
+func ParsePolicy(policy string) (*Policy, error) {
+	limitString, windowString, ok := strings.Cut(policy, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid rate limit policy %s, must be <limit>/<window>", policy)
+	}
+
+	limit, err := strconv.Atoi(limitString)
+	if err != nil || limit <= 0 {
+		return nil, fmt.Errorf("invalid rate limit policy %s, limit must be a positive integer", policy)
+	}
+
+	window, err := time.ParseDuration(windowString)
+	if err != nil || window <= 0 {
+		return nil, fmt.Errorf("invalid rate limit policy %s, window must be a positive duration", policy)
+	}
+
+	parsedPolicy := NewPolicy(limit, window)
+	return &parsedPolicy, nil
+}
+
+func (p *Policy) String() string {
+	return fmt.Sprintf("%d;w=%d", p.Limit, int(p.Window.Seconds()))
+}
+
+// refillRate is the number of tokens per second added back to a bucket.
+func (p *Policy) refillRate() float64 {
+	return float64(p.Limit) / p.Window.Seconds()
+}
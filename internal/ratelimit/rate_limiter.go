@@ -0,0 +1,152 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// KeyFunc returns the key requests are counted by.
+type KeyFunc func(r *http.Request) string
+
+// inMemorySweepInterval is the number of requests between sweeps of the full buckets, which
+// keeps keys that are never seen again from piling up.
+const inMemorySweepInterval = 1024
+
+// RateLimiter limits requests with a token bucket per key. Buckets are kept in memory, so every
+// instance of the service enforces its own limits.
+type RateLimiter struct {
+	Name    string
+	Policy  Policy
+	KeyFunc KeyFunc
+
+	mu       sync.Mutex
+	buckets  map[string]*bucket
+	requests int
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+func NewRateLimiter(name string, policy Policy, keyFunc KeyFunc) *RateLimiter {
+	return &RateLimiter{
+		Name:    name,
+		Policy:  policy,
+		KeyFunc: keyFunc,
+		buckets: map[string]*bucket{},
+	}
+}
+
+type result struct {
+	allowed    bool
+	remaining  int
+	reset      time.Duration
+	retryAfter time.Duration
+}
+
+func (l *RateLimiter) take(key string, now time.Time) result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.requests++
+	if l.requests%inMemorySweepInterval == 0 {
+		l.sweep(now)
+	}
+
+	keyBucket, ok := l.buckets[key]
+	if !ok {
+		keyBucket = &bucket{tokens: float64(l.Policy.Limit), updatedAt: now}
+		l.buckets[key] = keyBucket
+	}
+
+	refillRate := l.Policy.refillRate()
+
+	keyBucket.tokens = math.Min(float64(l.Policy.Limit), keyBucket.tokens+now.Sub(keyBucket.updatedAt).Seconds()*refillRate)
+	keyBucket.updatedAt = now
+
+	allowed := keyBucket.tokens >= 1
+	if allowed {
+		keyBucket.tokens--
+	}
+
+	return result{
+		allowed:    allowed,
+		remaining:  int(keyBucket.tokens),
+		reset:      secondsDuration((float64(l.Policy.Limit) - keyBucket.tokens) / refillRate),
+		retryAfter: secondsDuration((1 - keyBucket.tokens) / refillRate),
+	}
+}
+
+func (l *RateLimiter) sweep(now time.Time) {
+	refillRate := l.Policy.refillRate()
+
+	for key, keyBucket := range l.buckets {
+		if keyBucket.tokens+now.Sub(keyBucket.updatedAt).Seconds()*refillRate >= float64(l.Policy.Limit) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Middleware limits the requests of the route group it's used on, setting the RateLimit-Limit,
+// RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers. Limited requests get a 429
+// response with a Retry-After header.
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := l.take(l.KeyFunc(r), time.Now())
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(l.Policy.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.reset)))
+		w.Header().Set("RateLimit-Policy", l.Policy.String())
+
+		if !result.allowed {
+			log.Warn().Msgf("Rate limiting %s %s for %s", r.Method, r.URL.Path, l.Name)
+			tooManyRequests(w, result.retryAfter)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// LimitFunc limits the requests to a single handler. It's meant to be used inside
+// authentication middleware, so that KeyFunc can key requests by user.
+func (l *RateLimiter) LimitFunc(next http.HandlerFunc) http.HandlerFunc {
+	return l.Middleware(next).ServeHTTP
+}
+
+func tooManyRequests(w http.ResponseWriter, retryAfter time.Duration) {
+	response, err := json.Marshal(map[string]interface{}{
+		"errors": map[string]interface{}{
+			"body": []string{"Too many requests"},
+		},
+	})
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(retryAfter)))
+	w.WriteHeader(http.StatusTooManyRequests)
+	w.Write(response)
+}
+
+func secondsDuration(seconds float64) time.Duration {
+	if seconds < 0 {
+		return 0
+	}
+
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func ceilSeconds(duration time.Duration) int {
+	return int(math.Ceil(duration.Seconds()))
+}
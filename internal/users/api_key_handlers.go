@@ -0,0 +1,177 @@
+package users
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/auth"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"github.com/rs/zerolog/log"
+)
+
+type ApiKeyHandlers struct {
+	ApiKeyService auth.ApiKeyService
+	UsersService  UsersService
+}
+
+func NewApiKeyHandlers(apiKeyService auth.ApiKeyService, usersService UsersService) ApiKeyHandlers {
+	return ApiKeyHandlers{
+		ApiKeyService: apiKeyService,
+		UsersService:  usersService,
+	}
+}
+
+type apiKeyResponse struct {
+	ApiKey apiKeyResponseApiKey `json:"apiKey"`
+}
+
+type apiKeysResponse struct {
+	ApiKeys []apiKeyResponseApiKey `json:"apiKeys"`
+}
+
+// apiKeyResponseApiKey only has the Key right after its creation.
+type apiKeyResponseApiKey struct {
+	Id        string     `json:"id"`
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+	Key       *string    `json:"key,omitempty"`
+}
+
+func newApiKeyResponseApiKey(apiKey auth.ApiKey, key *string) apiKeyResponseApiKey {
+	return apiKeyResponseApiKey{
+		Id:        apiKey.Id,
+		Name:      apiKey.Name,
+		Scopes:    apiKey.Scopes,
+		CreatedAt: apiKey.CreatedAt,
+		ExpiresAt: apiKey.ExpiresAt,
+		Key:       key,
+	}
+}
+
+func (h *ApiKeyHandlers) CreateApiKey(w http.ResponseWriter, r *http.Request) {
+	username := r.Context().Value(auth.UsernameContextKey).(string)
+
+	var request struct {
+		ApiKey struct {
+			Name      string     `json:"name"`
+			Scopes    []string   `json:"scopes"`
+			ExpiresAt *time.Time `json:"expiresAt"`
+		} `json:"apiKey"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error decoding request")
+		unprocessableEntity(w, r, []error{err})
+		return
+	}
+
+	user, err := GetAuthenticatedUser(r, &h.UsersService)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting User %s", username)
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			notFound(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	apiKey, key, err := h.ApiKeyService.CreateKey(r.Context(), user.Id, request.ApiKey.Name, request.ApiKey.Scopes, request.ApiKey.ExpiresAt)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error creating API key for User %s", username)
+		if _, ok := err.(*custom_errors.InvalidArgumentError); ok {
+			unprocessableEntity(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	response, err := json.Marshal(apiKeyResponse{ApiKey: newApiKeyResponseApiKey(*apiKey, key)})
+	if err != nil {
+		log.Error().Err(err).Msgf("Error marshalling response body for User %s", username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.Header().Set("cache-control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(response)
+}
+
+func (h *ApiKeyHandlers) ListApiKeys(w http.ResponseWriter, r *http.Request) {
+	username := r.Context().Value(auth.UsernameContextKey).(string)
+
+	user, err := GetAuthenticatedUser(r, &h.UsersService)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting User %s", username)
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			notFound(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	apiKeys, err := h.ApiKeyService.ListKeys(r.Context(), user.Id)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error listing the API keys of User %s", username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	responseBody := apiKeysResponse{ApiKeys: []apiKeyResponseApiKey{}}
+	for _, apiKey := range apiKeys {
+		responseBody.ApiKeys = append(responseBody.ApiKeys, newApiKeyResponseApiKey(apiKey, nil))
+	}
+
+	response, err := json.Marshal(responseBody)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error marshalling response body for User %s", username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.Write(response)
+}
+
+func (h *ApiKeyHandlers) DeleteApiKey(w http.ResponseWriter, r *http.Request) {
+	username := r.Context().Value(auth.UsernameContextKey).(string)
+	id := chi.URLParam(r, "id")
+
+	user, err := GetAuthenticatedUser(r, &h.UsersService)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting User %s", username)
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			notFound(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	err = h.ApiKeyService.DeleteKey(r.Context(), user.Id, id)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error deleting API key %s of User %s", id, username)
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			notFound(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
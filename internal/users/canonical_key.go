@@ -0,0 +1,13 @@
+package users
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// canonicalKey is the form of a username or email used for lookups and uniqueness checks, so that
+// e.g. "Alice" and "alice" refer to the same user. The value as typed is kept for display.
+func canonicalKey(value string) string {
+	return strings.ToLower(norm.NFKC.String(strings.TrimSpace(value)))
+}
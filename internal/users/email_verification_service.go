@@ -0,0 +1,64 @@
+package users
+
+import (
+	"context"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/mail"
+)
+
+type EmailVerificationService struct {
+	UsersService        UsersService
+	OneTimeTokenService OneTimeTokenService
+	Mailer              mail.Mailer
+	SecondsToExpire     int
+	// VerifyUrl is the page users are sent to with the token in its token query parameter. When
+	// empty, the token itself is mailed.
+	VerifyUrl string
+}
+
+func NewEmailVerificationService(usersService UsersService, oneTimeTokenService OneTimeTokenService, mailer mail.Mailer, secondsToExpire int, verifyUrl string) EmailVerificationService {
+	return EmailVerificationService{
+		UsersService:        usersService,
+		OneTimeTokenService: oneTimeTokenService,
+		Mailer:              mailer,
+		SecondsToExpire:     secondsToExpire,
+		VerifyUrl:           verifyUrl,
+	}
+}
+
+// SendVerificationEmail mails a verification token to the user's current email, in the first of
+// locales there's a template for.
+func (s *EmailVerificationService) SendVerificationEmail(ctx context.Context, user User, locales ...string) error {
+	token, expiresAt, err := s.OneTimeTokenService.IssueToken(ctx, EmailVerificationPurpose, user, s.SecondsToExpire)
+	if err != nil {
+		return err
+	}
+
+	mailData, err := newTokenMailData(s.VerifyUrl, *token, *expiresAt)
+	if err != nil {
+		return err
+	}
+
+	return s.Mailer.Send(ctx, user.Email, "email_verification", mailData, locales...)
+}
+
+// VerifyEmail marks the email the token was sent to as verified, and invalidates every other
+// verification token of the user.
+func (s *EmailVerificationService) VerifyEmail(ctx context.Context, token string) (*User, error) {
+	user, err := s.OneTimeTokenService.ConsumeToken(ctx, EmailVerificationPurpose, token)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err = s.UsersService.MarkEmailVerified(ctx, user.Id, user.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.OneTimeTokenService.RevokeAllForUser(ctx, EmailVerificationPurpose, user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
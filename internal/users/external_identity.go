@@ -0,0 +1,23 @@
+package users
+
+import "time"
+
+// ExternalIdentity links the account of a user at an OAuth provider, identified by the provider's
+// subject, to the user. Email is the one the provider asserted when the identity was linked.
+type ExternalIdentity struct {
+	Provider  string
+	Subject   string
+	UserId    string
+	Email     string
+	CreatedAt time.Time
+}
+
+func NewExternalIdentity(provider string, subject string, userId string, email string, createdAt time.Time) ExternalIdentity {
+	return ExternalIdentity{
+		Provider:  provider,
+		Subject:   subject,
+		UserId:    userId,
+		Email:     email,
+		CreatedAt: createdAt,
+	}
+}
@@ -0,0 +1,8 @@
+package users
+
+import "context"
+
+type ExternalIdentityRepository interface {
+	Create(ctx context.Context, externalIdentity ExternalIdentity) error
+	GetByProviderSubject(ctx context.Context, provider string, subject string) (*ExternalIdentity, error)
+}
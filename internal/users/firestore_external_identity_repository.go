@@ -0,0 +1,79 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type FirestoreExternalIdentityRepository struct {
+	Firestore *firestore.Client
+}
+
+func NewFirestoreExternalIdentityRepository(firestore *firestore.Client) *FirestoreExternalIdentityRepository {
+	return &FirestoreExternalIdentityRepository{
+		Firestore: firestore,
+	}
+}
+
+const externalIdentitiesCollectionName = "external_identities"
+
+type externalIdentityDocData struct {
+	Provider  string    `firestore:"provider"`
+	Subject   string    `firestore:"subject"`
+	UserId    string    `firestore:"user_id"`
+	Email     string    `firestore:"email"`
+	CreatedAt time.Time `firestore:"created_at"`
+}
+
+func (r *FirestoreExternalIdentityRepository) Create(ctx context.Context, externalIdentity ExternalIdentity) error {
+	externalIdentityData := externalIdentityDocData{
+		Provider:  externalIdentity.Provider,
+		Subject:   externalIdentity.Subject,
+		UserId:    externalIdentity.UserId,
+		Email:     externalIdentity.Email,
+		CreatedAt: externalIdentity.CreatedAt,
+	}
+
+	_, err := r.externalIdentityDoc(externalIdentity.Provider, externalIdentity.Subject).Create(ctx, externalIdentityData)
+	if err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			return &custom_errors.AlreadyExistsError{Message: "External identity already linked"}
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *FirestoreExternalIdentityRepository) GetByProviderSubject(ctx context.Context, provider string, subject string) (*ExternalIdentity, error) {
+	externalIdentityDocSnapshot, err := r.externalIdentityDoc(provider, subject).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, &custom_errors.NotFoundError{Message: "External identity not found"}
+		}
+		return nil, err
+	}
+
+	externalIdentityData := externalIdentityDocData{}
+	err = externalIdentityDocSnapshot.DataTo(&externalIdentityData)
+	if err != nil {
+		return nil, err
+	}
+
+	externalIdentity := NewExternalIdentity(externalIdentityData.Provider, externalIdentityData.Subject, externalIdentityData.UserId, externalIdentityData.Email, externalIdentityData.CreatedAt)
+
+	return &externalIdentity, nil
+}
+
+// externalIdentityDoc keys identities by provider and subject, escaped as subjects are opaque
+// strings which may contain slashes.
+func (r *FirestoreExternalIdentityRepository) externalIdentityDoc(provider string, subject string) *firestore.DocumentRef {
+	return r.Firestore.Collection(externalIdentitiesCollectionName).Doc(fmt.Sprintf("%s:%s", provider, url.PathEscape(subject)))
+}
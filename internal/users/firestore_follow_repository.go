@@ -0,0 +1,181 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type FirestoreFollowRepository struct {
+	Firestore *firestore.Client
+}
+
+func NewFirestoreFollowRepository(firestore *firestore.Client) *FirestoreFollowRepository {
+	return &FirestoreFollowRepository{
+		Firestore: firestore,
+	}
+}
+
+const followsCollectionName = "follows"
+
+const followCountsCollectionName = "follow_counts"
+
+type followDocData struct {
+	FollowerId string    `firestore:"follower_id"`
+	FolloweeId string    `firestore:"followee_id"`
+	CreatedAt  time.Time `firestore:"created_at"`
+}
+
+type followCountsDocData struct {
+	FollowersCount int `firestore:"followers_count"`
+	FollowingCount int `firestore:"following_count"`
+}
+
+// Create and Delete keep the follow_counts documents in sync within the same transaction, as
+// Firestore can't count the documents matched by a query.
+func (r *FirestoreFollowRepository) Create(ctx context.Context, follow Follow) error {
+	followData := followDocData{
+		FollowerId: follow.FollowerId,
+		FolloweeId: follow.FolloweeId,
+		CreatedAt:  follow.CreatedAt,
+	}
+
+	followDocRef := r.followDocRef(follow.FollowerId, follow.FolloweeId)
+
+	return r.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		_, err := tx.Get(followDocRef)
+		if err == nil {
+			return nil
+		}
+		if status.Code(err) != codes.NotFound {
+			return err
+		}
+
+		err = tx.Create(followDocRef, followData)
+		if err != nil {
+			return err
+		}
+
+		return r.incrementCounts(tx, follow.FollowerId, follow.FolloweeId, 1)
+	})
+}
+
+func (r *FirestoreFollowRepository) Delete(ctx context.Context, followerId string, followeeId string) error {
+	followDocRef := r.followDocRef(followerId, followeeId)
+
+	return r.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		_, err := tx.Get(followDocRef)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return nil
+			}
+			return err
+		}
+
+		err = tx.Delete(followDocRef)
+		if err != nil {
+			return err
+		}
+
+		return r.incrementCounts(tx, followerId, followeeId, -1)
+	})
+}
+
+func (r *FirestoreFollowRepository) Exists(ctx context.Context, followerId string, followeeId string) (bool, error) {
+	_, err := r.followDocRef(followerId, followeeId).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *FirestoreFollowRepository) ListFollowers(ctx context.Context, followeeId string, limit int, after *Follow) ([]Follow, error) {
+	return r.list(ctx, "followee_id", followeeId, limit, after)
+}
+
+func (r *FirestoreFollowRepository) ListFollowing(ctx context.Context, followerId string, limit int, after *Follow) ([]Follow, error) {
+	return r.list(ctx, "follower_id", followerId, limit, after)
+}
+
+func (r *FirestoreFollowRepository) GetCounts(ctx context.Context, userId string) (*FollowCounts, error) {
+	followCountsDocSnapshot, err := r.Firestore.Collection(followCountsCollectionName).Doc(userId).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return &FollowCounts{}, nil
+		}
+		return nil, err
+	}
+
+	followCountsData := followCountsDocData{}
+	err = followCountsDocSnapshot.DataTo(&followCountsData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FollowCounts{
+		Followers: followCountsData.FollowersCount,
+		Following: followCountsData.FollowingCount,
+	}, nil
+}
+
+// list orders by creation time and then by document id, both descending. Within one user's
+// follows, the document id orders like the id of the listed user. The query needs a composite
+// index on field, created_at and __name__.
+func (r *FirestoreFollowRepository) list(ctx context.Context, field string, userId string, limit int, after *Follow) ([]Follow, error) {
+	query := r.Firestore.Collection(followsCollectionName).
+		Where(field, "==", userId).
+		OrderBy("created_at", firestore.Desc).
+		OrderBy(firestore.DocumentID, firestore.Desc)
+
+	if after != nil {
+		query = query.StartAfter(after.CreatedAt, followDocId(after.FollowerId, after.FolloweeId))
+	}
+
+	followDocSnapshots, err := query.Limit(limit).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	follows := []Follow{}
+	for _, followDocSnapshot := range followDocSnapshots {
+		followData := followDocData{}
+		err := followDocSnapshot.DataTo(&followData)
+		if err != nil {
+			return nil, err
+		}
+
+		follows = append(follows, NewFollow(followData.FollowerId, followData.FolloweeId, followData.CreatedAt))
+	}
+
+	return follows, nil
+}
+
+func (r *FirestoreFollowRepository) incrementCounts(tx *firestore.Transaction, followerId string, followeeId string, n int) error {
+	err := tx.Set(r.Firestore.Collection(followCountsCollectionName).Doc(followeeId), map[string]interface{}{
+		"followers_count": firestore.Increment(n),
+	}, firestore.MergeAll)
+	if err != nil {
+		return err
+	}
+
+	return tx.Set(r.Firestore.Collection(followCountsCollectionName).Doc(followerId), map[string]interface{}{
+		"following_count": firestore.Increment(n),
+	}, firestore.MergeAll)
+}
+
+func (r *FirestoreFollowRepository) followDocRef(followerId string, followeeId string) *firestore.DocumentRef {
+	return r.Firestore.Collection(followsCollectionName).Doc(followDocId(followerId, followeeId))
+}
+
+// User ids are Firestore's auto generated ids, which never contain '_'.
+func followDocId(followerId string, followeeId string) string {
+	return fmt.Sprintf("%s_%s", followerId, followeeId)
+}
@@ -0,0 +1,81 @@
+package users
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type FirestoreOauthStateRepository struct {
+	Firestore *firestore.Client
+}
+
+func NewFirestoreOauthStateRepository(firestore *firestore.Client) *FirestoreOauthStateRepository {
+	return &FirestoreOauthStateRepository{
+		Firestore: firestore,
+	}
+}
+
+const oauthStatesCollectionName = "oauth_states"
+
+type oauthStateDocData struct {
+	Provider     string    `firestore:"provider"`
+	CodeVerifier string    `firestore:"code_verifier"`
+	Nonce        string    `firestore:"nonce"`
+	CreatedAt    time.Time `firestore:"created_at"`
+	ExpiresAt    time.Time `firestore:"expires_at"`
+}
+
+func (r *FirestoreOauthStateRepository) Create(ctx context.Context, oauthState OauthState) error {
+	oauthStateData := oauthStateDocData{
+		Provider:     oauthState.Provider,
+		CodeVerifier: oauthState.CodeVerifier,
+		Nonce:        oauthState.Nonce,
+		CreatedAt:    oauthState.CreatedAt,
+		ExpiresAt:    oauthState.ExpiresAt,
+	}
+
+	_, err := r.Firestore.Collection(oauthStatesCollectionName).Doc(oauthState.StateHash).Create(ctx, oauthStateData)
+	if err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			return &custom_errors.AlreadyExistsError{Message: "State already exists"}
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *FirestoreOauthStateRepository) Consume(ctx context.Context, stateHash string) (*OauthState, error) {
+	oauthStateDocRef := r.Firestore.Collection(oauthStatesCollectionName).Doc(stateHash)
+
+	var oauthState OauthState
+	err := r.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		oauthStateDocSnapshot, err := tx.Get(oauthStateDocRef)
+		if err != nil {
+			return err
+		}
+
+		oauthStateData := oauthStateDocData{}
+		err = oauthStateDocSnapshot.DataTo(&oauthStateData)
+		if err != nil {
+			return err
+		}
+
+		oauthState = NewOauthState(stateHash, oauthStateData.Provider, oauthStateData.CodeVerifier, oauthStateData.Nonce, oauthStateData.CreatedAt, oauthStateData.ExpiresAt)
+
+		return tx.Delete(oauthStateDocRef)
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, &custom_errors.NotFoundError{Message: "State not found"}
+		}
+		return nil, err
+	}
+
+	return &oauthState, nil
+}
@@ -0,0 +1,132 @@
+package users
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type FirestoreOneTimeTokenRepository struct {
+	Firestore *firestore.Client
+}
+
+func NewFirestoreOneTimeTokenRepository(firestore *firestore.Client) *FirestoreOneTimeTokenRepository {
+	return &FirestoreOneTimeTokenRepository{
+		Firestore: firestore,
+	}
+}
+
+const oneTimeTokensCollectionName = "one_time_tokens"
+
+type oneTimeTokenDocData struct {
+	Purpose   string     `firestore:"purpose"`
+	UserId    string     `firestore:"user_id"`
+	Email     string     `firestore:"email"`
+	CreatedAt time.Time  `firestore:"created_at"`
+	ExpiresAt time.Time  `firestore:"expires_at"`
+	UsedAt    *time.Time `firestore:"used_at"`
+}
+
+func (r *FirestoreOneTimeTokenRepository) Create(ctx context.Context, oneTimeToken OneTimeToken) error {
+	oneTimeTokenData := oneTimeTokenDocData{
+		Purpose:   string(oneTimeToken.Purpose),
+		UserId:    oneTimeToken.UserId,
+		Email:     oneTimeToken.Email,
+		CreatedAt: oneTimeToken.CreatedAt,
+		ExpiresAt: oneTimeToken.ExpiresAt,
+		UsedAt:    oneTimeToken.UsedAt,
+	}
+
+	_, err := r.Firestore.Collection(oneTimeTokensCollectionName).Doc(oneTimeToken.TokenHash).Create(ctx, oneTimeTokenData)
+	if err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			return &custom_errors.AlreadyExistsError{Message: "Token already exists"}
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *FirestoreOneTimeTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*OneTimeToken, error) {
+	oneTimeTokenDocSnapshot, err := r.Firestore.Collection(oneTimeTokensCollectionName).Doc(tokenHash).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, &custom_errors.NotFoundError{Message: "Token not found"}
+		}
+		return nil, err
+	}
+
+	return oneTimeTokenFromDocSnapshot(oneTimeTokenDocSnapshot)
+}
+
+func (r *FirestoreOneTimeTokenRepository) MarkUsed(ctx context.Context, tokenHash string, usedAt time.Time) (bool, error) {
+	oneTimeTokenDocRef := r.Firestore.Collection(oneTimeTokensCollectionName).Doc(tokenHash)
+
+	marked := false
+	err := r.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		marked = false
+
+		oneTimeTokenDocSnapshot, err := tx.Get(oneTimeTokenDocRef)
+		if err != nil {
+			return err
+		}
+
+		oneTimeTokenData := oneTimeTokenDocData{}
+		err = oneTimeTokenDocSnapshot.DataTo(&oneTimeTokenData)
+		if err != nil {
+			return err
+		}
+
+		if oneTimeTokenData.UsedAt != nil {
+			return nil
+		}
+
+		marked = true
+		return tx.Update(oneTimeTokenDocRef, []firestore.Update{{Path: "used_at", Value: usedAt}})
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return false, &custom_errors.NotFoundError{Message: "Token not found"}
+		}
+		return false, err
+	}
+
+	return marked, nil
+}
+
+func (r *FirestoreOneTimeTokenRepository) MarkAllUsedForUser(ctx context.Context, userId string, purpose OneTimeTokenPurpose, usedAt time.Time) error {
+	query := r.Firestore.Collection(oneTimeTokensCollectionName).Where("user_id", "==", userId).Where("purpose", "==", string(purpose)).Where("used_at", "==", nil)
+	oneTimeTokenDocSnapshots, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return err
+	}
+
+	if len(oneTimeTokenDocSnapshots) == 0 {
+		return nil
+	}
+
+	batch := r.Firestore.Batch()
+	for _, oneTimeTokenDocSnapshot := range oneTimeTokenDocSnapshots {
+		batch.Update(oneTimeTokenDocSnapshot.Ref, []firestore.Update{{Path: "used_at", Value: usedAt}})
+	}
+
+	_, err = batch.Commit(ctx)
+	return err
+}
+
+func oneTimeTokenFromDocSnapshot(oneTimeTokenDocSnapshot *firestore.DocumentSnapshot) (*OneTimeToken, error) {
+	oneTimeTokenData := oneTimeTokenDocData{}
+	err := oneTimeTokenDocSnapshot.DataTo(&oneTimeTokenData)
+	if err != nil {
+		return nil, err
+	}
+
+	oneTimeToken := NewOneTimeToken(oneTimeTokenDocSnapshot.Ref.ID, OneTimeTokenPurpose(oneTimeTokenData.Purpose), oneTimeTokenData.UserId, oneTimeTokenData.Email, oneTimeTokenData.CreatedAt, oneTimeTokenData.ExpiresAt, oneTimeTokenData.UsedAt)
+
+	return &oneTimeToken, nil
+}
@@ -0,0 +1,95 @@
+package users
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type FirestoreTotpEnrollmentRepository struct {
+	Firestore *firestore.Client
+}
+
+func NewFirestoreTotpEnrollmentRepository(firestore *firestore.Client) *FirestoreTotpEnrollmentRepository {
+	return &FirestoreTotpEnrollmentRepository{
+		Firestore: firestore,
+	}
+}
+
+const totpEnrollmentsCollectionName = "totp_enrollments"
+
+type totpEnrollmentDocData struct {
+	Secret             string     `firestore:"secret"`
+	RecoveryCodeHashes []string   `firestore:"recovery_code_hashes"`
+	LastUsedStep       int64      `firestore:"last_used_step"`
+	CreatedAt          time.Time  `firestore:"created_at"`
+	ConfirmedAt        *time.Time `firestore:"confirmed_at"`
+}
+
+func (r *FirestoreTotpEnrollmentRepository) GetByUserId(ctx context.Context, userId string) (*TotpEnrollment, error) {
+	totpEnrollmentDocSnapshot, err := r.Firestore.Collection(totpEnrollmentsCollectionName).Doc(userId).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, &custom_errors.NotFoundError{Message: "TOTP enrollment not found"}
+		}
+		return nil, err
+	}
+
+	return totpEnrollmentFromDocSnapshot(totpEnrollmentDocSnapshot)
+}
+
+func (r *FirestoreTotpEnrollmentRepository) Update(ctx context.Context, userId string, update func(totpEnrollment *TotpEnrollment) (*TotpEnrollment, error)) error {
+	totpEnrollmentDocRef := r.Firestore.Collection(totpEnrollmentsCollectionName).Doc(userId)
+
+	return r.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		var currentTotpEnrollment *TotpEnrollment
+
+		totpEnrollmentDocSnapshot, err := tx.Get(totpEnrollmentDocRef)
+		if err != nil {
+			if status.Code(err) != codes.NotFound {
+				return err
+			}
+		} else {
+			currentTotpEnrollment, err = totpEnrollmentFromDocSnapshot(totpEnrollmentDocSnapshot)
+			if err != nil {
+				return err
+			}
+		}
+
+		updatedTotpEnrollment, err := update(currentTotpEnrollment)
+		if err != nil {
+			return err
+		}
+
+		if updatedTotpEnrollment == nil {
+			if currentTotpEnrollment == nil {
+				return nil
+			}
+			return tx.Delete(totpEnrollmentDocRef)
+		}
+
+		return tx.Set(totpEnrollmentDocRef, totpEnrollmentDocData{
+			Secret:             updatedTotpEnrollment.Secret,
+			RecoveryCodeHashes: updatedTotpEnrollment.RecoveryCodeHashes,
+			LastUsedStep:       updatedTotpEnrollment.LastUsedStep,
+			CreatedAt:          updatedTotpEnrollment.CreatedAt,
+			ConfirmedAt:        updatedTotpEnrollment.ConfirmedAt,
+		})
+	})
+}
+
+func totpEnrollmentFromDocSnapshot(totpEnrollmentDocSnapshot *firestore.DocumentSnapshot) (*TotpEnrollment, error) {
+	totpEnrollmentData := totpEnrollmentDocData{}
+	err := totpEnrollmentDocSnapshot.DataTo(&totpEnrollmentData)
+	if err != nil {
+		return nil, err
+	}
+
+	totpEnrollment := NewTotpEnrollment(totpEnrollmentDocSnapshot.Ref.ID, totpEnrollmentData.Secret, totpEnrollmentData.RecoveryCodeHashes, totpEnrollmentData.LastUsedStep, totpEnrollmentData.CreatedAt, totpEnrollmentData.ConfirmedAt)
+
+	return &totpEnrollment, nil
+}
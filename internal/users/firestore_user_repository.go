@@ -0,0 +1,503 @@
+package users
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"cloud.google.com/go/firestore"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type FirestoreUserRepository struct {
+	Firestore *firestore.Client
+}
+
+func NewFirestoreUserRepository(firestore *firestore.Client) *FirestoreUserRepository {
+	return &FirestoreUserRepository{
+		Firestore: firestore,
+	}
+}
+
+const (
+	usersCollectionName     = "users"
+	usernamesCollectionName = "usernames"
+	emailsCollectionName    = "emails"
+)
+
+type userDocData struct {
+	Username      string   `firestore:"username"`
+	UsernameKey   string   `firestore:"username_key"`
+	Email         string   `firestore:"email"`
+	EmailKey      string   `firestore:"email_key"`
+	EmailVerified bool     `firestore:"email_verified"`
+	PasswordHash  string   `firestore:"password_hash"`
+	Bio           *string  `firestore:"bio"`
+	Image         *string  `firestore:"image"`
+	Roles         []string `firestore:"roles"`
+}
+
+func newUserDocData(username string, email string, emailVerified bool, passwordHash string, bio *string, image *string, roles []string) userDocData {
+	return userDocData{
+		Username:      username,
+		UsernameKey:   canonicalKey(username),
+		Email:         email,
+		EmailKey:      canonicalKey(email),
+		EmailVerified: emailVerified,
+		PasswordHash:  passwordHash,
+		Bio:           bio,
+		Image:         image,
+		Roles:         roles,
+	}
+}
+
+// reservationDocData claims a username or email for a single user. Reservations are written in
+// the same transaction as the user document, which makes the uniqueness checks atomic.
+type reservationDocData struct {
+	UserId string `firestore:"user_id"`
+	Value  string `firestore:"value"`
+}
+
+func (r *FirestoreUserRepository) Create(ctx context.Context, user User) (*User, error) {
+	userDocRef := r.Firestore.Collection(usersCollectionName).NewDoc()
+	userData := newUserDocData(user.Username, user.Email, user.EmailVerified, user.PasswordHash, user.Bio, user.Image, user.Roles)
+
+	err := r.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		err := r.checkUniqueness(tx, userDocRef.ID, userData)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Create(userDocRef, userData)
+		if err != nil {
+			return err
+		}
+
+		return r.reserve(tx, userDocRef.ID, userData)
+	})
+	if err != nil {
+		return nil, mapFirestoreUserError(err)
+	}
+
+	createdUser := NewUser(userDocRef.ID, userData.Username, userData.Email, userData.EmailVerified, userData.PasswordHash, userData.Bio, userData.Image, userData.Roles)
+
+	return &createdUser, nil
+}
+
+func (r *FirestoreUserRepository) GetById(ctx context.Context, id string) (*User, error) {
+	if !isValidUserId(id) {
+		return nil, &custom_errors.NotFoundError{Message: "User not found"}
+	}
+
+	userDocSnapshot, err := r.Firestore.Collection(usersCollectionName).Doc(id).Get(ctx)
+	if err != nil {
+		return nil, mapFirestoreUserError(err)
+	}
+
+	return userFromDocSnapshot(userDocSnapshot)
+}
+
+func (r *FirestoreUserRepository) GetByUsername(ctx context.Context, username string) (*User, error) {
+	return r.getFirstWhere(ctx, "username_key", canonicalKey(username))
+}
+
+func (r *FirestoreUserRepository) GetByIds(ctx context.Context, ids []string) ([]User, error) {
+	userDocRefs := []*firestore.DocumentRef{}
+	for _, id := range ids {
+		if !isValidUserId(id) {
+			continue
+		}
+		userDocRefs = append(userDocRefs, r.Firestore.Collection(usersCollectionName).Doc(id))
+	}
+
+	if len(userDocRefs) == 0 {
+		return []User{}, nil
+	}
+
+	userDocSnapshots, err := r.Firestore.GetAll(ctx, userDocRefs)
+	if err != nil {
+		return nil, err
+	}
+
+	users := []User{}
+	for _, userDocSnapshot := range userDocSnapshots {
+		if !userDocSnapshot.Exists() {
+			continue
+		}
+
+		user, err := userFromDocSnapshot(userDocSnapshot)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+
+	return users, nil
+}
+
+// Firestore's "in" filter accepts at most 10 values, so usernames are queried in chunks.
+const firestoreInFilterMaxValues = 10
+
+func (r *FirestoreUserRepository) GetByUsernames(ctx context.Context, usernames []string) ([]User, error) {
+	usernameKeys := []string{}
+	for _, username := range usernames {
+		usernameKeys = append(usernameKeys, canonicalKey(username))
+	}
+
+	users := []User{}
+	for start := 0; start < len(usernameKeys); start += firestoreInFilterMaxValues {
+		end := start + firestoreInFilterMaxValues
+		if end > len(usernameKeys) {
+			end = len(usernameKeys)
+		}
+
+		userDocSnapshots, err := r.Firestore.Collection(usersCollectionName).Where("username_key", "in", usernameKeys[start:end]).Documents(ctx).GetAll()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, userDocSnapshot := range userDocSnapshots {
+			user, err := userFromDocSnapshot(userDocSnapshot)
+			if err != nil {
+				return nil, err
+			}
+			users = append(users, *user)
+		}
+	}
+
+	return users, nil
+}
+
+func (r *FirestoreUserRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
+	return r.getFirstWhere(ctx, "email_key", canonicalKey(email))
+}
+
+func (r *FirestoreUserRepository) Update(ctx context.Context, user User) (*User, error) {
+	userDocRef := r.Firestore.Collection(usersCollectionName).Doc(user.Id)
+	userData := newUserDocData(user.Username, user.Email, user.EmailVerified, user.PasswordHash, user.Bio, user.Image, user.Roles)
+
+	err := r.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		userDocSnapshot, err := tx.Get(userDocRef)
+		if err != nil {
+			return err
+		}
+
+		previousUserData := userDocData{}
+		err = userDocSnapshot.DataTo(&previousUserData)
+		if err != nil {
+			return err
+		}
+
+		err = r.checkUniqueness(tx, user.Id, userData)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Set(userDocRef, userData)
+		if err != nil {
+			return err
+		}
+
+		err = r.release(tx, previousUserData, userData)
+		if err != nil {
+			return err
+		}
+
+		return r.reserve(tx, user.Id, userData)
+	})
+	if err != nil {
+		return nil, mapFirestoreUserError(err)
+	}
+
+	return &user, nil
+}
+
+func (r *FirestoreUserRepository) Delete(ctx context.Context, id string) error {
+	userDocRef := r.Firestore.Collection(usersCollectionName).Doc(id)
+
+	err := r.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		userDocSnapshot, err := tx.Get(userDocRef)
+		if err != nil {
+			return err
+		}
+
+		userData := userDocData{}
+		err = userDocSnapshot.DataTo(&userData)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Delete(userDocRef)
+		if err != nil {
+			return err
+		}
+
+		return r.release(tx, userData, userDocData{})
+	})
+	if err != nil {
+		return mapFirestoreUserError(err)
+	}
+
+	return nil
+}
+
+func (r *FirestoreUserRepository) List(ctx context.Context) ([]User, error) {
+	userDocs := r.Firestore.Collection(usersCollectionName).OrderBy("username", firestore.Asc).Documents(ctx)
+	defer userDocs.Stop()
+
+	users := []User{}
+	for {
+		userDocSnapshot, err := userDocs.Next()
+		if err == iterator.Done {
+			return users, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		user, err := userFromDocSnapshot(userDocSnapshot)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, *user)
+	}
+}
+
+// BackfillCanonicalKeys adds the canonical keys and the reservations to the users stored before
+// they existed, then deletes the reservations made before then, which were keyed by the trimmed
+// value. Users whose keys collide with another user's are logged and left untouched.
+func (r *FirestoreUserRepository) BackfillCanonicalKeys(ctx context.Context) (int, error) {
+	backfilled, err := r.backfillUserCanonicalKeys(ctx)
+	if err != nil {
+		return backfilled, err
+	}
+
+	for _, reservationsCollectionName := range []string{usernamesCollectionName, emailsCollectionName} {
+		deleted, err := r.deleteLegacyReservations(ctx, reservationsCollectionName)
+		if err != nil {
+			return backfilled, err
+		}
+
+		if deleted > 0 {
+			log.Info().Msgf("Deleted %d legacy reservations of the %s collection", deleted, reservationsCollectionName)
+		}
+	}
+
+	return backfilled, nil
+}
+
+func (r *FirestoreUserRepository) backfillUserCanonicalKeys(ctx context.Context) (int, error) {
+	userDocs := r.Firestore.Collection(usersCollectionName).Documents(ctx)
+	defer userDocs.Stop()
+
+	backfilled := 0
+	for {
+		userDocSnapshot, err := userDocs.Next()
+		if err == iterator.Done {
+			return backfilled, nil
+		} else if err != nil {
+			return backfilled, err
+		}
+
+		userData := userDocData{}
+		err = userDocSnapshot.DataTo(&userData)
+		if err != nil {
+			return backfilled, err
+		}
+
+		if userData.UsernameKey == canonicalKey(userData.Username) && userData.EmailKey == canonicalKey(userData.Email) {
+			continue
+		}
+
+		userDocRef := userDocSnapshot.Ref
+		err = r.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			userDocSnapshot, err := tx.Get(userDocRef)
+			if err != nil {
+				return err
+			}
+
+			previousUserData := userDocData{}
+			err = userDocSnapshot.DataTo(&previousUserData)
+			if err != nil {
+				return err
+			}
+
+			userData := newUserDocData(previousUserData.Username, previousUserData.Email, previousUserData.EmailVerified, previousUserData.PasswordHash, previousUserData.Bio, previousUserData.Image, previousUserData.Roles)
+
+			err = r.checkUniqueness(tx, userDocRef.ID, userData)
+			if err != nil {
+				return err
+			}
+
+			err = tx.Set(userDocRef, userData)
+			if err != nil {
+				return err
+			}
+
+			return r.reserve(tx, userDocRef.ID, userData)
+		})
+		if err != nil {
+			if _, ok := err.(*custom_errors.AlreadyExistsError); ok {
+				log.Warn().Err(err).Msgf("Skipping User %s, whose username or email conflicts with another User's", userDocRef.ID)
+				continue
+			}
+			return backfilled, err
+		}
+
+		backfilled++
+	}
+}
+
+// deleteLegacyReservations deletes the reservations whose document id isn't the hash of their
+// value's canonical key. The values stay protected by the reservations of the canonical keys.
+func (r *FirestoreUserRepository) deleteLegacyReservations(ctx context.Context, reservationsCollectionName string) (int, error) {
+	reservationDocs := r.Firestore.Collection(reservationsCollectionName).Documents(ctx)
+	defer reservationDocs.Stop()
+
+	deleted := 0
+	for {
+		reservationDocSnapshot, err := reservationDocs.Next()
+		if err == iterator.Done {
+			return deleted, nil
+		} else if err != nil {
+			return deleted, err
+		}
+
+		reservationData := reservationDocData{}
+		err = reservationDocSnapshot.DataTo(&reservationData)
+		if err != nil {
+			return deleted, err
+		}
+
+		if reservationDocSnapshot.Ref.ID == r.reservationDocRef(reservationsCollectionName, canonicalKey(reservationData.Value)).ID {
+			continue
+		}
+
+		_, err = reservationDocSnapshot.Ref.Delete(ctx, firestore.LastUpdateTime(reservationDocSnapshot.UpdateTime))
+		if err != nil {
+			if status.Code(err) == codes.NotFound || status.Code(err) == codes.FailedPrecondition {
+				continue
+			}
+			return deleted, err
+		}
+
+		deleted++
+	}
+}
+
+func (r *FirestoreUserRepository) checkUniqueness(tx *firestore.Transaction, userId string, userData userDocData) error {
+	isTaken, err := r.isTaken(tx, usernamesCollectionName, userData.UsernameKey, userId)
+	if err != nil {
+		return err
+	}
+	if isTaken {
+		return &custom_errors.AlreadyExistsError{Message: "User already exists"}
+	}
+
+	isTaken, err = r.isTaken(tx, emailsCollectionName, userData.EmailKey, userId)
+	if err != nil {
+		return err
+	}
+	if isTaken {
+		return &custom_errors.AlreadyExistsError{Message: "Email is taken"}
+	}
+
+	return nil
+}
+
+func (r *FirestoreUserRepository) isTaken(tx *firestore.Transaction, reservationsCollectionName string, key string, userId string) (bool, error) {
+	reservationDocSnapshot, err := tx.Get(r.reservationDocRef(reservationsCollectionName, key))
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	reservationData := reservationDocData{}
+	err = reservationDocSnapshot.DataTo(&reservationData)
+	if err != nil {
+		return false, err
+	}
+
+	return reservationData.UserId != userId, nil
+}
+
+func (r *FirestoreUserRepository) reserve(tx *firestore.Transaction, userId string, userData userDocData) error {
+	err := tx.Set(r.reservationDocRef(usernamesCollectionName, userData.UsernameKey), reservationDocData{UserId: userId, Value: userData.Username})
+	if err != nil {
+		return err
+	}
+
+	return tx.Set(r.reservationDocRef(emailsCollectionName, userData.EmailKey), reservationDocData{UserId: userId, Value: userData.Email})
+}
+
+// release deletes the reservations of previousUserData that userData no longer holds.
+func (r *FirestoreUserRepository) release(tx *firestore.Transaction, previousUserData userDocData, userData userDocData) error {
+	if previousUserData.UsernameKey != userData.UsernameKey {
+		previousUsernameRef := r.reservationDocRef(usernamesCollectionName, previousUserData.UsernameKey)
+		err := tx.Delete(previousUsernameRef)
+		if err != nil {
+			return err
+		}
+	}
+
+	if previousUserData.EmailKey != userData.EmailKey {
+		previousEmailRef := r.reservationDocRef(emailsCollectionName, previousUserData.EmailKey)
+		err := tx.Delete(previousEmailRef)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reservationDocRef hashes the canonical key, as usernames and emails may contain characters that
+// aren't allowed in document ids.
+func (r *FirestoreUserRepository) reservationDocRef(reservationsCollectionName string, key string) *firestore.DocumentRef {
+	hash := sha256.Sum256([]byte(key))
+	return r.Firestore.Collection(reservationsCollectionName).Doc(hex.EncodeToString(hash[:]))
+}
+
+func (r *FirestoreUserRepository) getFirstWhere(ctx context.Context, field string, value string) (*User, error) {
+	query := r.Firestore.Collection(usersCollectionName).Where(field, "==", value).Limit(1)
+	userDocs := query.Documents(ctx)
+	defer userDocs.Stop()
+
+	userDocSnapshot, err := userDocs.Next()
+	if err == iterator.Done {
+		return nil, &custom_errors.NotFoundError{Message: "User not found"}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return userFromDocSnapshot(userDocSnapshot)
+}
+
+func userFromDocSnapshot(userDocSnapshot *firestore.DocumentSnapshot) (*User, error) {
+	userData := userDocData{}
+	err := userDocSnapshot.DataTo(&userData)
+	if err != nil {
+		return nil, err
+	}
+
+	user := NewUser(userDocSnapshot.Ref.ID, userData.Username, userData.Email, userData.EmailVerified, userData.PasswordHash, userData.Bio, userData.Image, userData.Roles)
+
+	return &user, nil
+}
+
+func mapFirestoreUserError(err error) error {
+	switch status.Code(err) {
+	case codes.NotFound:
+		return &custom_errors.NotFoundError{Message: "User not found"}
+	case codes.AlreadyExists:
+		return &custom_errors.AlreadyExistsError{Message: "User already exists"}
+	}
+
+	return err
+}
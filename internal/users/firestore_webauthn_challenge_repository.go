@@ -0,0 +1,79 @@
+package users
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type FirestoreWebauthnChallengeRepository struct {
+	Firestore *firestore.Client
+}
+
+func NewFirestoreWebauthnChallengeRepository(firestore *firestore.Client) *FirestoreWebauthnChallengeRepository {
+	return &FirestoreWebauthnChallengeRepository{
+		Firestore: firestore,
+	}
+}
+
+const webauthnChallengesCollectionName = "webauthn_challenges"
+
+type webauthnChallengeDocData struct {
+	Ceremony  string    `firestore:"ceremony"`
+	UserId    string    `firestore:"user_id"`
+	CreatedAt time.Time `firestore:"created_at"`
+	ExpiresAt time.Time `firestore:"expires_at"`
+}
+
+func (r *FirestoreWebauthnChallengeRepository) Create(ctx context.Context, webauthnChallenge WebauthnChallenge) error {
+	webauthnChallengeData := webauthnChallengeDocData{
+		Ceremony:  string(webauthnChallenge.Ceremony),
+		UserId:    webauthnChallenge.UserId,
+		CreatedAt: webauthnChallenge.CreatedAt,
+		ExpiresAt: webauthnChallenge.ExpiresAt,
+	}
+
+	_, err := r.Firestore.Collection(webauthnChallengesCollectionName).Doc(webauthnChallenge.ChallengeHash).Create(ctx, webauthnChallengeData)
+	if err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			return &custom_errors.AlreadyExistsError{Message: "Challenge already exists"}
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *FirestoreWebauthnChallengeRepository) Consume(ctx context.Context, challengeHash string) (*WebauthnChallenge, error) {
+	webauthnChallengeDocRef := r.Firestore.Collection(webauthnChallengesCollectionName).Doc(challengeHash)
+
+	var webauthnChallenge WebauthnChallenge
+	err := r.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		webauthnChallengeDocSnapshot, err := tx.Get(webauthnChallengeDocRef)
+		if err != nil {
+			return err
+		}
+
+		webauthnChallengeData := webauthnChallengeDocData{}
+		err = webauthnChallengeDocSnapshot.DataTo(&webauthnChallengeData)
+		if err != nil {
+			return err
+		}
+
+		webauthnChallenge = NewWebauthnChallenge(challengeHash, WebauthnCeremony(webauthnChallengeData.Ceremony), webauthnChallengeData.UserId, webauthnChallengeData.CreatedAt, webauthnChallengeData.ExpiresAt)
+
+		return tx.Delete(webauthnChallengeDocRef)
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, &custom_errors.NotFoundError{Message: "Challenge not found"}
+		}
+		return nil, err
+	}
+
+	return &webauthnChallenge, nil
+}
@@ -0,0 +1,114 @@
+package users
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FirestoreWebauthnCredentialRepository keeps the credentials of each user in a subcollection of
+// the user's document, keyed by credential id.
+type FirestoreWebauthnCredentialRepository struct {
+	Firestore *firestore.Client
+}
+
+func NewFirestoreWebauthnCredentialRepository(firestore *firestore.Client) *FirestoreWebauthnCredentialRepository {
+	return &FirestoreWebauthnCredentialRepository{
+		Firestore: firestore,
+	}
+}
+
+const webauthnCredentialsCollectionName = "webauthn_credentials"
+
+type webauthnCredentialDocData struct {
+	Name       string     `firestore:"name"`
+	PublicKey  []byte     `firestore:"public_key"`
+	SignCount  int64      `firestore:"sign_count"`
+	CreatedAt  time.Time  `firestore:"created_at"`
+	LastUsedAt *time.Time `firestore:"last_used_at"`
+}
+
+func (r *FirestoreWebauthnCredentialRepository) Create(ctx context.Context, webauthnCredential WebauthnCredential) error {
+	webauthnCredentialData := webauthnCredentialDocData{
+		Name:       webauthnCredential.Name,
+		PublicKey:  webauthnCredential.PublicKey,
+		SignCount:  int64(webauthnCredential.SignCount),
+		CreatedAt:  webauthnCredential.CreatedAt,
+		LastUsedAt: webauthnCredential.LastUsedAt,
+	}
+
+	_, err := r.webauthnCredentials(webauthnCredential.UserId).Doc(webauthnCredential.Id).Create(ctx, webauthnCredentialData)
+	if err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			return &custom_errors.AlreadyExistsError{Message: "Credential already registered"}
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *FirestoreWebauthnCredentialRepository) GetById(ctx context.Context, userId string, id string) (*WebauthnCredential, error) {
+	webauthnCredentialDocSnapshot, err := r.webauthnCredentials(userId).Doc(id).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, &custom_errors.NotFoundError{Message: "Credential not found"}
+		}
+		return nil, err
+	}
+
+	return webauthnCredentialFromDocSnapshot(userId, webauthnCredentialDocSnapshot)
+}
+
+func (r *FirestoreWebauthnCredentialRepository) ListByUserId(ctx context.Context, userId string) ([]WebauthnCredential, error) {
+	webauthnCredentialDocSnapshots, err := r.webauthnCredentials(userId).OrderBy("created_at", firestore.Asc).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	webauthnCredentials := []WebauthnCredential{}
+	for _, webauthnCredentialDocSnapshot := range webauthnCredentialDocSnapshots {
+		webauthnCredential, err := webauthnCredentialFromDocSnapshot(userId, webauthnCredentialDocSnapshot)
+		if err != nil {
+			return nil, err
+		}
+		webauthnCredentials = append(webauthnCredentials, *webauthnCredential)
+	}
+
+	return webauthnCredentials, nil
+}
+
+func (r *FirestoreWebauthnCredentialRepository) UpdateSignCount(ctx context.Context, userId string, id string, signCount uint32, lastUsedAt time.Time) error {
+	_, err := r.webauthnCredentials(userId).Doc(id).Update(ctx, []firestore.Update{
+		{Path: "sign_count", Value: int64(signCount)},
+		{Path: "last_used_at", Value: lastUsedAt},
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return &custom_errors.NotFoundError{Message: "Credential not found"}
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *FirestoreWebauthnCredentialRepository) webauthnCredentials(userId string) *firestore.CollectionRef {
+	return r.Firestore.Collection(usersCollectionName).Doc(userId).Collection(webauthnCredentialsCollectionName)
+}
+
+func webauthnCredentialFromDocSnapshot(userId string, webauthnCredentialDocSnapshot *firestore.DocumentSnapshot) (*WebauthnCredential, error) {
+	webauthnCredentialData := webauthnCredentialDocData{}
+	err := webauthnCredentialDocSnapshot.DataTo(&webauthnCredentialData)
+	if err != nil {
+		return nil, err
+	}
+
+	webauthnCredential := NewWebauthnCredential(webauthnCredentialDocSnapshot.Ref.ID, userId, webauthnCredentialData.Name, webauthnCredentialData.PublicKey, uint32(webauthnCredentialData.SignCount), webauthnCredentialData.CreatedAt, webauthnCredentialData.LastUsedAt)
+
+	return &webauthnCredential, nil
+}
@@ -0,0 +1,22 @@
+package users
+
+import "time"
+
+type Follow struct {
+	FollowerId string
+	FolloweeId string
+	CreatedAt  time.Time
+}
+
+func NewFollow(followerId string, followeeId string, createdAt time.Time) Follow {
+	return Follow{
+		FollowerId: followerId,
+		FolloweeId: followeeId,
+		CreatedAt:  createdAt,
+	}
+}
+
+type FollowCounts struct {
+	Followers int
+	Following int
+}
@@ -0,0 +1,45 @@
+package users
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// followCursor is the position of a follow in a listing. Clients get it base64 encoded and must
+// treat it as opaque.
+type followCursor struct {
+	FollowerId string    `json:"r"`
+	FolloweeId string    `json:"e"`
+	CreatedAt  time.Time `json:"t"`
+}
+
+func encodeFollowCursor(follow Follow) (*string, error) {
+	cursorBytes, err := json.Marshal(followCursor{
+		FollowerId: follow.FollowerId,
+		FolloweeId: follow.FolloweeId,
+		CreatedAt:  follow.CreatedAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cursor := base64.RawURLEncoding.EncodeToString(cursorBytes)
+	return &cursor, nil
+}
+
+func decodeFollowCursor(cursor string) (*Follow, error) {
+	cursorBytes, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	decodedCursor := followCursor{}
+	err = json.Unmarshal(cursorBytes, &decodedCursor)
+	if err != nil {
+		return nil, err
+	}
+
+	follow := NewFollow(decodedCursor.FollowerId, decodedCursor.FolloweeId, decodedCursor.CreatedAt)
+	return &follow, nil
+}
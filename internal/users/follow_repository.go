@@ -0,0 +1,16 @@
+package users
+
+import "context"
+
+// FollowRepository stores who follows whom. Following twice or unfollowing a user that isn't
+// followed are no-ops.
+type FollowRepository interface {
+	Create(ctx context.Context, follow Follow) error
+	Delete(ctx context.Context, followerId string, followeeId string) error
+	Exists(ctx context.Context, followerId string, followeeId string) (bool, error)
+	// ListFollowers and ListFollowing return follows newest first, starting after the given follow
+	// if not nil.
+	ListFollowers(ctx context.Context, followeeId string, limit int, after *Follow) ([]Follow, error)
+	ListFollowing(ctx context.Context, followerId string, limit int, after *Follow) ([]Follow, error)
+	GetCounts(ctx context.Context, userId string) (*FollowCounts, error)
+}
@@ -0,0 +1,51 @@
+package users
+
+import (
+	"context"
+	"sync"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+)
+
+type externalIdentityKey struct {
+	provider string
+	subject  string
+}
+
+type InMemoryExternalIdentityRepository struct {
+	mu                 sync.RWMutex
+	externalIdentities map[externalIdentityKey]ExternalIdentity
+}
+
+func NewInMemoryExternalIdentityRepository() *InMemoryExternalIdentityRepository {
+	return &InMemoryExternalIdentityRepository{
+		externalIdentities: map[externalIdentityKey]ExternalIdentity{},
+	}
+}
+
+func (r *InMemoryExternalIdentityRepository) Create(ctx context.Context, externalIdentity ExternalIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := externalIdentityKey{provider: externalIdentity.Provider, subject: externalIdentity.Subject}
+
+	if _, ok := r.externalIdentities[key]; ok {
+		return &custom_errors.AlreadyExistsError{Message: "External identity already linked"}
+	}
+
+	r.externalIdentities[key] = externalIdentity
+
+	return nil
+}
+
+func (r *InMemoryExternalIdentityRepository) GetByProviderSubject(ctx context.Context, provider string, subject string) (*ExternalIdentity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	externalIdentity, ok := r.externalIdentities[externalIdentityKey{provider: provider, subject: subject}]
+	if !ok {
+		return nil, &custom_errors.NotFoundError{Message: "External identity not found"}
+	}
+
+	return &externalIdentity, nil
+}
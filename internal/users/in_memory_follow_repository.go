@@ -0,0 +1,122 @@
+package users
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+type InMemoryFollowRepository struct {
+	mu      sync.RWMutex
+	follows map[followKey]Follow
+}
+
+type followKey struct {
+	followerId string
+	followeeId string
+}
+
+func NewInMemoryFollowRepository() *InMemoryFollowRepository {
+	return &InMemoryFollowRepository{
+		follows: map[followKey]Follow{},
+	}
+}
+
+func (r *InMemoryFollowRepository) Create(ctx context.Context, follow Follow) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := followKey{followerId: follow.FollowerId, followeeId: follow.FolloweeId}
+	if _, ok := r.follows[key]; !ok {
+		r.follows[key] = follow
+	}
+
+	return nil
+}
+
+func (r *InMemoryFollowRepository) Delete(ctx context.Context, followerId string, followeeId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.follows, followKey{followerId: followerId, followeeId: followeeId})
+
+	return nil
+}
+
+func (r *InMemoryFollowRepository) Exists(ctx context.Context, followerId string, followeeId string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.follows[followKey{followerId: followerId, followeeId: followeeId}]
+
+	return ok, nil
+}
+
+func (r *InMemoryFollowRepository) ListFollowers(ctx context.Context, followeeId string, limit int, after *Follow) ([]Follow, error) {
+	return r.list(limit, after, func(follow Follow) bool {
+		return follow.FolloweeId == followeeId
+	}, func(follow Follow) string {
+		return follow.FollowerId
+	})
+}
+
+func (r *InMemoryFollowRepository) ListFollowing(ctx context.Context, followerId string, limit int, after *Follow) ([]Follow, error) {
+	return r.list(limit, after, func(follow Follow) bool {
+		return follow.FollowerId == followerId
+	}, func(follow Follow) string {
+		return follow.FolloweeId
+	})
+}
+
+func (r *InMemoryFollowRepository) GetCounts(ctx context.Context, userId string) (*FollowCounts, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	followCounts := FollowCounts{}
+	for key := range r.follows {
+		if key.followeeId == userId {
+			followCounts.Followers++
+		}
+		if key.followerId == userId {
+			followCounts.Following++
+		}
+	}
+
+	return &followCounts, nil
+}
+
+// list orders follows by creation time and then by the id of the listed user, both descending.
+func (r *InMemoryFollowRepository) list(limit int, after *Follow, matches func(Follow) bool, listedId func(Follow) string) ([]Follow, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	isBefore := func(a Follow, b Follow) bool {
+		if a.CreatedAt.Equal(b.CreatedAt) {
+			return listedId(a) > listedId(b)
+		}
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+
+	follows := []Follow{}
+	for _, follow := range r.follows {
+		if !matches(follow) {
+			continue
+		}
+
+		if after != nil && !isBefore(*after, follow) {
+			continue
+		}
+
+		follows = append(follows, follow)
+	}
+
+	sort.Slice(follows, func(i, j int) bool {
+		return isBefore(follows[i], follows[j])
+	})
+
+	if len(follows) > limit {
+		follows = follows[:limit]
+	}
+
+	return follows, nil
+}
@@ -0,0 +1,65 @@
+package users
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+)
+
+// inMemoryOauthStatesSweepInterval is the number of states created between sweeps of the expired
+// ones, which keeps abandoned logins from piling up.
+const inMemoryOauthStatesSweepInterval = 1024
+
+type InMemoryOauthStateRepository struct {
+	mu          sync.Mutex
+	oauthStates map[string]OauthState
+	creates     int
+}
+
+func NewInMemoryOauthStateRepository() *InMemoryOauthStateRepository {
+	return &InMemoryOauthStateRepository{
+		oauthStates: map[string]OauthState{},
+	}
+}
+
+func (r *InMemoryOauthStateRepository) Create(ctx context.Context, oauthState OauthState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.creates++
+	if r.creates%inMemoryOauthStatesSweepInterval == 0 {
+		r.sweep(time.Now())
+	}
+
+	if _, ok := r.oauthStates[oauthState.StateHash]; ok {
+		return &custom_errors.AlreadyExistsError{Message: "State already exists"}
+	}
+
+	r.oauthStates[oauthState.StateHash] = oauthState
+
+	return nil
+}
+
+func (r *InMemoryOauthStateRepository) Consume(ctx context.Context, stateHash string) (*OauthState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	oauthState, ok := r.oauthStates[stateHash]
+	if !ok {
+		return nil, &custom_errors.NotFoundError{Message: "State not found"}
+	}
+
+	delete(r.oauthStates, stateHash)
+
+	return &oauthState, nil
+}
+
+func (r *InMemoryOauthStateRepository) sweep(now time.Time) {
+	for stateHash, oauthState := range r.oauthStates {
+		if !now.Before(oauthState.ExpiresAt) {
+			delete(r.oauthStates, stateHash)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package users
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+)
+
+type InMemoryOneTimeTokenRepository struct {
+	mu            sync.Mutex
+	oneTimeTokens map[string]OneTimeToken
+}
+
+func NewInMemoryOneTimeTokenRepository() *InMemoryOneTimeTokenRepository {
+	return &InMemoryOneTimeTokenRepository{
+		oneTimeTokens: map[string]OneTimeToken{},
+	}
+}
+
+func (r *InMemoryOneTimeTokenRepository) Create(ctx context.Context, oneTimeToken OneTimeToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.oneTimeTokens[oneTimeToken.TokenHash]; ok {
+		return &custom_errors.AlreadyExistsError{Message: "Token already exists"}
+	}
+
+	r.oneTimeTokens[oneTimeToken.TokenHash] = oneTimeToken
+
+	return nil
+}
+
+func (r *InMemoryOneTimeTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*OneTimeToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	oneTimeToken, ok := r.oneTimeTokens[tokenHash]
+	if !ok {
+		return nil, &custom_errors.NotFoundError{Message: "Token not found"}
+	}
+
+	return &oneTimeToken, nil
+}
+
+func (r *InMemoryOneTimeTokenRepository) MarkUsed(ctx context.Context, tokenHash string, usedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	oneTimeToken, ok := r.oneTimeTokens[tokenHash]
+	if !ok {
+		return false, &custom_errors.NotFoundError{Message: "Token not found"}
+	}
+
+	if oneTimeToken.UsedAt != nil {
+		return false, nil
+	}
+
+	oneTimeToken.UsedAt = &usedAt
+	r.oneTimeTokens[tokenHash] = oneTimeToken
+
+	return true, nil
+}
+
+func (r *InMemoryOneTimeTokenRepository) MarkAllUsedForUser(ctx context.Context, userId string, purpose OneTimeTokenPurpose, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for tokenHash, oneTimeToken := range r.oneTimeTokens {
+		if oneTimeToken.UserId == userId && oneTimeToken.Purpose == purpose && oneTimeToken.UsedAt == nil {
+			oneTimeToken.UsedAt = &usedAt
+			r.oneTimeTokens[tokenHash] = oneTimeToken
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package users
+
+import (
+	"context"
+	"sync"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+)
+
+type InMemoryTotpEnrollmentRepository struct {
+	mu              sync.Mutex
+	totpEnrollments map[string]TotpEnrollment
+}
+
+func NewInMemoryTotpEnrollmentRepository() *InMemoryTotpEnrollmentRepository {
+	return &InMemoryTotpEnrollmentRepository{
+		totpEnrollments: map[string]TotpEnrollment{},
+	}
+}
+
+func (r *InMemoryTotpEnrollmentRepository) GetByUserId(ctx context.Context, userId string) (*TotpEnrollment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	totpEnrollment, ok := r.totpEnrollments[userId]
+	if !ok {
+		return nil, &custom_errors.NotFoundError{Message: "TOTP enrollment not found"}
+	}
+
+	totpEnrollment.RecoveryCodeHashes = append([]string{}, totpEnrollment.RecoveryCodeHashes...)
+
+	return &totpEnrollment, nil
+}
+
+func (r *InMemoryTotpEnrollmentRepository) Update(ctx context.Context, userId string, update func(totpEnrollment *TotpEnrollment) (*TotpEnrollment, error)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var currentTotpEnrollment *TotpEnrollment
+	if totpEnrollment, ok := r.totpEnrollments[userId]; ok {
+		totpEnrollment.RecoveryCodeHashes = append([]string{}, totpEnrollment.RecoveryCodeHashes...)
+		currentTotpEnrollment = &totpEnrollment
+	}
+
+	updatedTotpEnrollment, err := update(currentTotpEnrollment)
+	if err != nil {
+		return err
+	}
+
+	if updatedTotpEnrollment == nil {
+		delete(r.totpEnrollments, userId)
+	} else {
+		r.totpEnrollments[userId] = *updatedTotpEnrollment
+	}
+
+	return nil
+}
@@ -0,0 +1,213 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+)
+
+type InMemoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[string]User
+}
+
+func NewInMemoryUserRepository() *InMemoryUserRepository {
+	return &InMemoryUserRepository{
+		users: map[string]User{},
+	}
+}
+
+func (r *InMemoryUserRepository) Create(ctx context.Context, user User) (*User, error) {
+	id, err := newInMemoryId()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err = r.checkUniqueness(user)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Id = id
+	r.users[id] = copyUser(user)
+
+	return &user, nil
+}
+
+func (r *InMemoryUserRepository) GetById(ctx context.Context, id string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, &custom_errors.NotFoundError{Message: "User not found"}
+	}
+
+	user = copyUser(user)
+	return &user, nil
+}
+
+func (r *InMemoryUserRepository) GetByUsername(ctx context.Context, username string) (*User, error) {
+	return r.getFirstWhere(func(user User) bool {
+		return canonicalKey(user.Username) == canonicalKey(username)
+	})
+}
+
+func (r *InMemoryUserRepository) GetByIds(ctx context.Context, ids []string) ([]User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := []User{}
+	for _, id := range ids {
+		if user, ok := r.users[id]; ok {
+			users = append(users, copyUser(user))
+		}
+	}
+
+	return users, nil
+}
+
+func (r *InMemoryUserRepository) GetByUsernames(ctx context.Context, usernames []string) ([]User, error) {
+	usernameKeys := map[string]bool{}
+	for _, username := range usernames {
+		usernameKeys[canonicalKey(username)] = true
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := []User{}
+	for _, user := range r.users {
+		if usernameKeys[canonicalKey(user.Username)] {
+			users = append(users, copyUser(user))
+		}
+	}
+
+	return users, nil
+}
+
+func (r *InMemoryUserRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
+	return r.getFirstWhere(func(user User) bool {
+		return canonicalKey(user.Email) == canonicalKey(email)
+	})
+}
+
+func (r *InMemoryUserRepository) Update(ctx context.Context, user User) (*User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user.Id]; !ok {
+		return nil, &custom_errors.NotFoundError{Message: "User not found"}
+	}
+
+	err := r.checkUniqueness(user)
+	if err != nil {
+		return nil, err
+	}
+
+	r.users[user.Id] = copyUser(user)
+
+	return &user, nil
+}
+
+func (r *InMemoryUserRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[id]; !ok {
+		return &custom_errors.NotFoundError{Message: "User not found"}
+	}
+
+	delete(r.users, id)
+
+	return nil
+}
+
+func (r *InMemoryUserRepository) List(ctx context.Context) ([]User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]User, 0, len(r.users))
+	for _, user := range r.users {
+		users = append(users, copyUser(user))
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Username < users[j].Username
+	})
+
+	return users, nil
+}
+
+// checkUniqueness must be called with the lock held.
+func (r *InMemoryUserRepository) checkUniqueness(user User) error {
+	for _, existingUser := range r.users {
+		if existingUser.Id == user.Id {
+			continue
+		}
+
+		if canonicalKey(existingUser.Username) == canonicalKey(user.Username) {
+			return &custom_errors.AlreadyExistsError{Message: "User already exists"}
+		}
+
+		if canonicalKey(existingUser.Email) == canonicalKey(user.Email) {
+			return &custom_errors.AlreadyExistsError{Message: "Email is taken"}
+		}
+	}
+
+	return nil
+}
+
+func (r *InMemoryUserRepository) getFirstWhere(predicate func(user User) bool) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if predicate(user) {
+			user = copyUser(user)
+			return &user, nil
+		}
+	}
+
+	return nil, &custom_errors.NotFoundError{Message: "User not found"}
+}
+
+// copyUser detaches the optional fields and roles so callers can't mutate stored users through them.
+func copyUser(user User) User {
+	if user.Bio != nil {
+		bio := *user.Bio
+		user.Bio = &bio
+	}
+
+	if user.Image != nil {
+		image := *user.Image
+		user.Image = &image
+	}
+
+	user.Roles = append([]string(nil), user.Roles...)
+
+	return user
+}
+
+const inMemoryIdAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// newInMemoryId generates ids shaped like Firestore's auto-generated document ids.
+func newInMemoryId() (string, error) {
+	id := make([]byte, 20)
+	for i := range id {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(inMemoryIdAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		id[i] = inMemoryIdAlphabet[n.Int64()]
+	}
+
+	return string(id), nil
+}
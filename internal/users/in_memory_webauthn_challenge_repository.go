@@ -0,0 +1,65 @@
+package users
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+)
+
+// inMemoryWebauthnChallengesSweepInterval is the number of challenges created between sweeps of
+// the expired ones, which keeps abandoned ceremonies from piling up.
+const inMemoryWebauthnChallengesSweepInterval = 1024
+
+type InMemoryWebauthnChallengeRepository struct {
+	mu                 sync.Mutex
+	webauthnChallenges map[string]WebauthnChallenge
+	creates            int
+}
+
+func NewInMemoryWebauthnChallengeRepository() *InMemoryWebauthnChallengeRepository {
+	return &InMemoryWebauthnChallengeRepository{
+		webauthnChallenges: map[string]WebauthnChallenge{},
+	}
+}
+
+func (r *InMemoryWebauthnChallengeRepository) Create(ctx context.Context, webauthnChallenge WebauthnChallenge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.creates++
+	if r.creates%inMemoryWebauthnChallengesSweepInterval == 0 {
+		r.sweep(time.Now())
+	}
+
+	if _, ok := r.webauthnChallenges[webauthnChallenge.ChallengeHash]; ok {
+		return &custom_errors.AlreadyExistsError{Message: "Challenge already exists"}
+	}
+
+	r.webauthnChallenges[webauthnChallenge.ChallengeHash] = webauthnChallenge
+
+	return nil
+}
+
+func (r *InMemoryWebauthnChallengeRepository) Consume(ctx context.Context, challengeHash string) (*WebauthnChallenge, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	webauthnChallenge, ok := r.webauthnChallenges[challengeHash]
+	if !ok {
+		return nil, &custom_errors.NotFoundError{Message: "Challenge not found"}
+	}
+
+	delete(r.webauthnChallenges, challengeHash)
+
+	return &webauthnChallenge, nil
+}
+
+func (r *InMemoryWebauthnChallengeRepository) sweep(now time.Time) {
+	for challengeHash, webauthnChallenge := range r.webauthnChallenges {
+		if !now.Before(webauthnChallenge.ExpiresAt) {
+			delete(r.webauthnChallenges, challengeHash)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package users
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+)
+
+type InMemoryWebauthnCredentialRepository struct {
+	mu                  sync.Mutex
+	webauthnCredentials map[string]map[string]WebauthnCredential
+}
+
+func NewInMemoryWebauthnCredentialRepository() *InMemoryWebauthnCredentialRepository {
+	return &InMemoryWebauthnCredentialRepository{
+		webauthnCredentials: map[string]map[string]WebauthnCredential{},
+	}
+}
+
+func (r *InMemoryWebauthnCredentialRepository) Create(ctx context.Context, webauthnCredential WebauthnCredential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	userWebauthnCredentials, ok := r.webauthnCredentials[webauthnCredential.UserId]
+	if !ok {
+		userWebauthnCredentials = map[string]WebauthnCredential{}
+		r.webauthnCredentials[webauthnCredential.UserId] = userWebauthnCredentials
+	}
+
+	if _, ok := userWebauthnCredentials[webauthnCredential.Id]; ok {
+		return &custom_errors.AlreadyExistsError{Message: "Credential already registered"}
+	}
+
+	userWebauthnCredentials[webauthnCredential.Id] = webauthnCredential
+
+	return nil
+}
+
+func (r *InMemoryWebauthnCredentialRepository) GetById(ctx context.Context, userId string, id string) (*WebauthnCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	webauthnCredential, ok := r.webauthnCredentials[userId][id]
+	if !ok {
+		return nil, &custom_errors.NotFoundError{Message: "Credential not found"}
+	}
+
+	return &webauthnCredential, nil
+}
+
+func (r *InMemoryWebauthnCredentialRepository) ListByUserId(ctx context.Context, userId string) ([]WebauthnCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	webauthnCredentials := []WebauthnCredential{}
+	for _, webauthnCredential := range r.webauthnCredentials[userId] {
+		webauthnCredentials = append(webauthnCredentials, webauthnCredential)
+	}
+
+	sort.Slice(webauthnCredentials, func(i, j int) bool {
+		return webauthnCredentials[i].CreatedAt.Before(webauthnCredentials[j].CreatedAt)
+	})
+
+	return webauthnCredentials, nil
+}
+
+func (r *InMemoryWebauthnCredentialRepository) UpdateSignCount(ctx context.Context, userId string, id string, signCount uint32, lastUsedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	webauthnCredential, ok := r.webauthnCredentials[userId][id]
+	if !ok {
+		return &custom_errors.NotFoundError{Message: "Credential not found"}
+	}
+
+	webauthnCredential.SignCount = signCount
+	webauthnCredential.LastUsedAt = &lastUsedAt
+	r.webauthnCredentials[userId][id] = webauthnCredential
+
+	return nil
+}
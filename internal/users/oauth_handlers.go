@@ -0,0 +1,156 @@
+package users
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/auth"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"github.com/rs/zerolog/log"
+)
+
+// oauthStateCookieName is the cookie binding a social login to the browser it was started from,
+// so that nobody can sign a victim in to the attacker's account with the attacker's callback URL.
+const oauthStateCookieName = "oauth_state"
+
+type OauthHandlers struct {
+	OauthService        OauthService
+	TwoFactorService    TwoFactorService
+	JwtService          auth.JwtService
+	RefreshTokenService auth.RefreshTokenService
+}
+
+func NewOauthHandlers(oauthService OauthService, twoFactorService TwoFactorService, jwtService auth.JwtService, refreshTokenService auth.RefreshTokenService) OauthHandlers {
+	return OauthHandlers{
+		OauthService:        oauthService,
+		TwoFactorService:    twoFactorService,
+		JwtService:          jwtService,
+		RefreshTokenService: refreshTokenService,
+	}
+}
+
+// StartLogin redirects the user to the provider, which redirects back to Callback.
+func (h *OauthHandlers) StartLogin(w http.ResponseWriter, r *http.Request) {
+	providerName := chi.URLParam(r, "provider")
+
+	authorizationUrl, state, err := h.OauthService.StartLogin(r.Context(), providerName)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error starting login with provider %s", providerName)
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			notFound(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	h.setStateCookie(w, *state, h.OauthService.StateSecondsToExpire)
+
+	http.Redirect(w, r, *authorizationUrl, http.StatusFound)
+}
+
+// Callback issues the same tokens as Login, or a two-factor challenge, for the user the
+// provider's identity is linked to.
+func (h *OauthHandlers) Callback(w http.ResponseWriter, r *http.Request) {
+	providerName := chi.URLParam(r, "provider")
+	query := r.URL.Query()
+
+	// The state is single use, whatever the outcome.
+	h.setStateCookie(w, "", -1)
+
+	if len(query.Get("error")) > 0 {
+		log.Warn().Msgf("Login with provider %s failed: %s %s", providerName, query.Get("error"), query.Get("error_description"))
+		unauthorized(w, r)
+		return
+	}
+
+	state := query.Get("state")
+	stateCookie, err := r.Cookie(oauthStateCookieName)
+	if err != nil || len(state) == 0 || subtle.ConstantTimeCompare([]byte(stateCookie.Value), []byte(state)) != 1 {
+		log.Error().Err(err).Msgf("Error matching the state of the login with provider %s", providerName)
+		unprocessableEntity(w, r, []error{fmt.Errorf("Invalid or expired state")})
+		return
+	}
+
+	user, err := h.OauthService.FinishLogin(r.Context(), providerName, state, query.Get("code"))
+	if err != nil {
+		log.Error().Err(err).Msgf("Error finishing login with provider %s", providerName)
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			notFound(w, r, []error{err})
+			return
+		}
+
+		if _, ok := err.(*custom_errors.InvalidArgumentError); ok {
+			unprocessableEntity(w, r, []error{err})
+			return
+		}
+
+		if _, ok := err.(*custom_errors.AlreadyExistsError); ok {
+			unprocessableEntity(w, r, []error{err})
+			return
+		}
+
+		if _, ok := err.(*custom_errors.UnauthenticatedError); ok {
+			unauthorized(w, r)
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	twoFactorEnabled, err := h.TwoFactorService.IsEnabled(r.Context(), user.Id)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error checking two-factor authentication for User %s", user.Username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	if twoFactorEnabled {
+		twoFactorChallenge(w, r, &h.TwoFactorService, *user)
+		return
+	}
+
+	token, err := h.JwtService.GenerateToken(user.Id, user.Username, user.GetRoles())
+	if err != nil {
+		log.Error().Err(err).Msgf("Error generating token for User %s", user.Username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	refreshToken, err := h.RefreshTokenService.IssueToken(r.Context(), user.Id)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error issuing refresh token for User %s", user.Username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	responseBody := newUserResponse(user.Email, user.EmailVerified, *token, refreshToken, user.Username, user.Bio, user.Image)
+
+	response, err := json.Marshal(responseBody)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error marshalling response body for User %s", user.Username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.Write(response)
+}
+
+func (h *OauthHandlers) setStateCookie(w http.ResponseWriter, state string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookieName,
+		Value:    state,
+		Path:     "/users/oauth",
+		MaxAge:   maxAge,
+		Secure:   strings.HasPrefix(h.OauthService.CallbackBaseUrl, "https://"),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
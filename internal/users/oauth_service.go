@@ -0,0 +1,231 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/auth"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/oauth"
+)
+
+const (
+	maxGeneratedUsernameLength   = 32
+	maxGeneratedUsernameAttempts = 5
+	defaultGeneratedUsername     = "user"
+)
+
+type OauthService struct {
+	UsersService               UsersService
+	OauthStateRepository       OauthStateRepository
+	ExternalIdentityRepository ExternalIdentityRepository
+	Providers                  map[string]oauth.Provider
+	CallbackBaseUrl            string
+	StateSecondsToExpire       int
+}
+
+func NewOauthService(usersService UsersService, oauthStateRepository OauthStateRepository, externalIdentityRepository ExternalIdentityRepository, providers map[string]oauth.Provider, callbackBaseUrl string, stateSecondsToExpire int) OauthService {
+	return OauthService{
+		UsersService:               usersService,
+		OauthStateRepository:       oauthStateRepository,
+		ExternalIdentityRepository: externalIdentityRepository,
+		Providers:                  providers,
+		CallbackBaseUrl:            strings.TrimSuffix(callbackBaseUrl, "/"),
+		StateSecondsToExpire:       stateSecondsToExpire,
+	}
+}
+
+// StartLogin returns the URL to send the user to in order to sign in with the provider, and the
+// state the callback must be called with.
+func (s *OauthService) StartLogin(ctx context.Context, providerName string) (*string, *string, error) {
+	provider, err := s.getProvider(providerName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	state, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	codeVerifier, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nonce, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+
+	err = s.OauthStateRepository.Create(ctx, NewOauthState(auth.HashOpaqueToken(*state), provider.Name(), *codeVerifier, *nonce, now, now.Add(time.Second*time.Duration(s.StateSecondsToExpire))))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	authorizationUrl, err := provider.AuthorizationUrl(ctx, *state, oauth.CodeChallenge(*codeVerifier), *nonce, s.callbackUrl(provider.Name()))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return authorizationUrl, state, nil
+}
+
+// FinishLogin exchanges the code for the user's identity at the provider, and returns the user
+// the identity is linked to. Identities not linked yet are linked to the user with the same
+// email, provided both the provider and we verified it, or else to a new user.
+func (s *OauthService) FinishLogin(ctx context.Context, providerName string, state string, code string) (*User, error) {
+	provider, err := s.getProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	invalidStateErr := &custom_errors.InvalidArgumentError{Message: "Invalid or expired state"}
+
+	oauthState, err := s.OauthStateRepository.Consume(ctx, auth.HashOpaqueToken(state))
+	if err != nil {
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			return nil, invalidStateErr
+		}
+		return nil, err
+	}
+
+	if oauthState.Provider != provider.Name() || !time.Now().Before(oauthState.ExpiresAt) {
+		return nil, invalidStateErr
+	}
+
+	identity, err := provider.Exchange(ctx, code, oauthState.CodeVerifier, oauthState.Nonce, s.callbackUrl(provider.Name()))
+	if err != nil {
+		return nil, &custom_errors.UnauthenticatedError{Message: fmt.Sprintf("Error exchanging the authorization code: %s", err)}
+	}
+
+	externalIdentity, err := s.ExternalIdentityRepository.GetByProviderSubject(ctx, provider.Name(), identity.Subject)
+	if err == nil {
+		return s.UsersService.GetUserById(ctx, externalIdentity.UserId)
+	}
+	if _, ok := err.(*custom_errors.NotFoundError); !ok {
+		return nil, err
+	}
+
+	if len(identity.Email) == 0 {
+		return nil, &custom_errors.InvalidArgumentError{Message: fmt.Sprintf("Your %s account has no email address", provider.Name())}
+	}
+
+	if !identity.EmailVerified {
+		return nil, &custom_errors.InvalidArgumentError{Message: fmt.Sprintf("The email address of your %s account is not verified", provider.Name())}
+	}
+
+	user, err := s.UsersService.GetUserByEmail(ctx, identity.Email)
+	if err != nil {
+		if _, ok := err.(*custom_errors.NotFoundError); !ok {
+			return nil, err
+		}
+
+		user, err = s.registerUser(ctx, *identity)
+		if err != nil {
+			return nil, err
+		}
+	} else if !user.EmailVerified {
+		// Otherwise whoever registered the email first, without owning it, would get the account.
+		return nil, &custom_errors.AlreadyExistsError{Message: "Email is taken, sign in with your password and verify your email to link your account"}
+	}
+
+	err = s.ExternalIdentityRepository.Create(ctx, NewExternalIdentity(provider.Name(), identity.Subject, user.Id, identity.Email, time.Now()))
+	if err != nil {
+		// The identity was linked by a concurrent login.
+		if _, ok := err.(*custom_errors.AlreadyExistsError); ok {
+			externalIdentity, err := s.ExternalIdentityRepository.GetByProviderSubject(ctx, provider.Name(), identity.Subject)
+			if err != nil {
+				return nil, err
+			}
+			return s.UsersService.GetUserById(ctx, externalIdentity.UserId)
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// registerUser creates a user with a verified email and an unguessable password, which can be
+// replaced with a password reset, under a free username derived from the identity.
+func (s *OauthService) registerUser(ctx context.Context, identity oauth.Identity) (*User, error) {
+	password, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	baseUsername := generatedUsername(identity)
+	username := baseUsername
+
+	var user *User
+	for attempt := 1; ; attempt++ {
+		user, err = s.UsersService.RegisterUser(ctx, username, identity.Email, *password)
+		if err == nil {
+			break
+		}
+
+		alreadyExistsErr, ok := err.(*custom_errors.AlreadyExistsError)
+		if !ok || alreadyExistsErr.Message != "User already exists" || attempt == maxGeneratedUsernameAttempts {
+			return nil, err
+		}
+
+		suffix, err := rand.Int(rand.Reader, big.NewInt(10000))
+		if err != nil {
+			return nil, err
+		}
+
+		username = fmt.Sprintf("%s-%04d", baseUsername, suffix.Int64())
+	}
+
+	return s.UsersService.MarkEmailVerified(ctx, user.Id, identity.Email)
+}
+
+func (s *OauthService) getProvider(providerName string) (oauth.Provider, error) {
+	provider, ok := s.Providers[providerName]
+	if !ok {
+		return nil, &custom_errors.NotFoundError{Message: fmt.Sprintf("Unknown provider %s", providerName)}
+	}
+
+	return provider, nil
+}
+
+func (s *OauthService) callbackUrl(providerName string) string {
+	return fmt.Sprintf("%s/users/oauth/%s/callback", s.CallbackBaseUrl, providerName)
+}
+
+// generatedUsername derives a username from the preferred username, the email or the name of the
+// identity, in that order, keeping letters, digits, dots, dashes and underscores.
+func generatedUsername(identity oauth.Identity) string {
+	emailLocalPart := identity.Email
+	if at := strings.LastIndex(emailLocalPart, "@"); at >= 0 {
+		emailLocalPart = emailLocalPart[:at]
+	}
+
+	for _, candidate := range []string{identity.PreferredUsername, emailLocalPart, identity.Name} {
+		username := strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' || r == '_' {
+				return r
+			}
+			return -1
+		}, candidate)
+
+		username = strings.Trim(username, ".-_")
+
+		if len(username) > maxGeneratedUsernameLength {
+			username = strings.Trim(string([]rune(username)[:maxGeneratedUsernameLength]), ".-_")
+		}
+
+		if len(username) > 0 {
+			return username
+		}
+	}
+
+	return defaultGeneratedUsername
+}
@@ -0,0 +1,25 @@
+package users
+
+import "time"
+
+// OauthState is the server-side state of a social login, keyed by the hash of the state sent to
+// the provider. CodeVerifier is the PKCE verifier the code is exchanged with.
+type OauthState struct {
+	StateHash    string
+	Provider     string
+	CodeVerifier string
+	Nonce        string
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+func NewOauthState(stateHash string, provider string, codeVerifier string, nonce string, createdAt time.Time, expiresAt time.Time) OauthState {
+	return OauthState{
+		StateHash:    stateHash,
+		Provider:     provider,
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
+		CreatedAt:    createdAt,
+		ExpiresAt:    expiresAt,
+	}
+}
@@ -0,0 +1,10 @@
+package users
+
+import "context"
+
+type OauthStateRepository interface {
+	Create(ctx context.Context, oauthState OauthState) error
+	// Consume atomically deletes the state and returns it, so that every authorization response
+	// is used at most once.
+	Consume(ctx context.Context, stateHash string) (*OauthState, error)
+}
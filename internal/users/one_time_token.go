@@ -0,0 +1,37 @@
+package users
+
+import "time"
+
+type OneTimeTokenPurpose string
+
+const (
+	PasswordResetPurpose     OneTimeTokenPurpose = "password_reset"
+	EmailVerificationPurpose OneTimeTokenPurpose = "email_verification"
+	// TwoFactorChallengePurpose tokens stand for a correct password until the second factor is
+	// verified. They're returned by Login rather than mailed.
+	TwoFactorChallengePurpose OneTimeTokenPurpose = "two_factor_challenge"
+)
+
+// OneTimeToken is the server-side record of a single-use token mailed to a user. Only the hash
+// of the token is stored, along with the address it was sent to.
+type OneTimeToken struct {
+	TokenHash string
+	Purpose   OneTimeTokenPurpose
+	UserId    string
+	Email     string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+func NewOneTimeToken(tokenHash string, purpose OneTimeTokenPurpose, userId string, email string, createdAt time.Time, expiresAt time.Time, usedAt *time.Time) OneTimeToken {
+	return OneTimeToken{
+		TokenHash: tokenHash,
+		Purpose:   purpose,
+		UserId:    userId,
+		Email:     email,
+		CreatedAt: createdAt,
+		ExpiresAt: expiresAt,
+		UsedAt:    usedAt,
+	}
+}
@@ -0,0 +1,14 @@
+package users
+
+import (
+	"context"
+	"time"
+)
+
+type OneTimeTokenRepository interface {
+	Create(ctx context.Context, oneTimeToken OneTimeToken) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*OneTimeToken, error)
+	// MarkUsed atomically flags the token as used, returning false if it had already been used.
+	MarkUsed(ctx context.Context, tokenHash string, usedAt time.Time) (bool, error)
+	MarkAllUsedForUser(ctx context.Context, userId string, purpose OneTimeTokenPurpose, usedAt time.Time) error
+}
@@ -0,0 +1,104 @@
+package users
+
+import (
+	"context"
+	"time"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/auth"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+)
+
+type OneTimeTokenService struct {
+	UsersService           UsersService
+	OneTimeTokenRepository OneTimeTokenRepository
+}
+
+func NewOneTimeTokenService(usersService UsersService, oneTimeTokenRepository OneTimeTokenRepository) OneTimeTokenService {
+	return OneTimeTokenService{
+		UsersService:           usersService,
+		OneTimeTokenRepository: oneTimeTokenRepository,
+	}
+}
+
+// IssueToken returns a new token for the user's current email, along with its expiry.
+func (s *OneTimeTokenService) IssueToken(ctx context.Context, purpose OneTimeTokenPurpose, user User, secondsToExpire int) (*string, *time.Time, error) {
+	token, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(time.Second * time.Duration(secondsToExpire))
+
+	err = s.OneTimeTokenRepository.Create(ctx, NewOneTimeToken(auth.HashOpaqueToken(*token), purpose, user.Id, user.Email, now, expiresAt, nil))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return token, &expiresAt, nil
+}
+
+// ConsumeToken marks the token as used and returns the user it was issued to. Tokens of another
+// purpose, already used, expired, or issued before the user's email changed are rejected.
+func (s *OneTimeTokenService) ConsumeToken(ctx context.Context, purpose OneTimeTokenPurpose, token string) (*User, error) {
+	oneTimeToken, user, err := s.getValidToken(ctx, purpose, token)
+	if err != nil {
+		return nil, err
+	}
+
+	marked, err := s.OneTimeTokenRepository.MarkUsed(ctx, oneTimeToken.TokenHash, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !marked {
+		return nil, &custom_errors.InvalidArgumentError{Message: "Invalid or expired token"}
+	}
+
+	return user, nil
+}
+
+// GetTokenUser returns the user the token was issued to, rejecting the same tokens as
+// ConsumeToken, without using the token up.
+func (s *OneTimeTokenService) GetTokenUser(ctx context.Context, purpose OneTimeTokenPurpose, token string) (*User, error) {
+	_, user, err := s.getValidToken(ctx, purpose, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *OneTimeTokenService) getValidToken(ctx context.Context, purpose OneTimeTokenPurpose, token string) (*OneTimeToken, *User, error) {
+	invalidTokenErr := &custom_errors.InvalidArgumentError{Message: "Invalid or expired token"}
+
+	oneTimeToken, err := s.OneTimeTokenRepository.GetByTokenHash(ctx, auth.HashOpaqueToken(token))
+	if err != nil {
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			return nil, nil, invalidTokenErr
+		}
+		return nil, nil, err
+	}
+
+	if oneTimeToken.Purpose != purpose || oneTimeToken.UsedAt != nil || !time.Now().Before(oneTimeToken.ExpiresAt) {
+		return nil, nil, invalidTokenErr
+	}
+
+	user, err := s.UsersService.GetUserById(ctx, oneTimeToken.UserId)
+	if err != nil {
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			return nil, nil, invalidTokenErr
+		}
+		return nil, nil, err
+	}
+
+	if canonicalKey(user.Email) != canonicalKey(oneTimeToken.Email) {
+		return nil, nil, invalidTokenErr
+	}
+
+	return oneTimeToken, user, nil
+}
+
+// RevokeAllForUser marks every unused token of the purpose issued to the user as used.
+func (s *OneTimeTokenService) RevokeAllForUser(ctx context.Context, purpose OneTimeTokenPurpose, userId string) error {
+	return s.OneTimeTokenRepository.MarkAllUsedForUser(ctx, userId, purpose, time.Now())
+}
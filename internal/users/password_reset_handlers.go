@@ -0,0 +1,104 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/auth"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/mail"
+	"github.com/rs/zerolog/log"
+)
+
+type PasswordResetHandlers struct {
+	PasswordResetService   PasswordResetService
+	RefreshTokenService    auth.RefreshTokenService
+	TokenRevocationService auth.TokenRevocationService
+}
+
+func NewPasswordResetHandlers(passwordResetService PasswordResetService, refreshTokenService auth.RefreshTokenService, tokenRevocationService auth.TokenRevocationService) PasswordResetHandlers {
+	return PasswordResetHandlers{
+		PasswordResetService:   passwordResetService,
+		RefreshTokenService:    refreshTokenService,
+		TokenRevocationService: tokenRevocationService,
+	}
+}
+
+// RequestPasswordReset always accepts the request, and mails the token in the background so
+// that the response time doesn't tell whether the email belongs to a user. The mail is localized
+// after the Accept-Language header.
+func (h *PasswordResetHandlers) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Email string `json:"email"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error decoding request")
+		unprocessableEntity(w, r, []error{err})
+		return
+	}
+
+	locales := mail.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+
+	go func() {
+		err := h.PasswordResetService.RequestPasswordReset(context.Background(), request.Email, locales...)
+		if err != nil {
+			log.Error().Err(err).Msgf("Error requesting password reset for email %s", request.Email)
+		}
+	}()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *PasswordResetHandlers) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error decoding request")
+		unprocessableEntity(w, r, []error{err})
+		return
+	}
+
+	user, err := h.PasswordResetService.ConfirmPasswordReset(r.Context(), request.Token, request.Password)
+	if err != nil {
+		log.Error().Err(err).Msg("Error confirming password reset")
+		if _, ok := err.(*custom_errors.InvalidArgumentError); ok {
+			unprocessableEntity(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	err = h.revokeAllTokens(r, *user)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error revoking the tokens of User %s", user.Username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// revokeAllTokens signs the user out everywhere, including tokens issued while subjects were
+// usernames.
+func (h *PasswordResetHandlers) revokeAllTokens(r *http.Request, user User) error {
+	err := h.TokenRevocationService.RevokeSubject(r.Context(), user.Id)
+	if err != nil {
+		return err
+	}
+
+	err = h.TokenRevocationService.RevokeLegacySubject(r.Context(), user.Username)
+	if err != nil {
+		return err
+	}
+
+	return h.RefreshTokenService.RevokeAllForUser(r.Context(), user.Id)
+}
@@ -0,0 +1,118 @@
+package users
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/mail"
+	"github.com/rs/zerolog/log"
+)
+
+type PasswordResetService struct {
+	UsersService        UsersService
+	OneTimeTokenService OneTimeTokenService
+	Mailer              mail.Mailer
+	SecondsToExpire     int
+	// ResetUrl is the page users are sent to with the token in its token query parameter. When
+	// empty, the token itself is mailed.
+	ResetUrl string
+}
+
+func NewPasswordResetService(usersService UsersService, oneTimeTokenService OneTimeTokenService, mailer mail.Mailer, secondsToExpire int, resetUrl string) PasswordResetService {
+	return PasswordResetService{
+		UsersService:        usersService,
+		OneTimeTokenService: oneTimeTokenService,
+		Mailer:              mailer,
+		SecondsToExpire:     secondsToExpire,
+		ResetUrl:            resetUrl,
+	}
+}
+
+// RequestPasswordReset mails a password reset token to the user with the email, in the first of
+// locales there's a template for. Unknown emails are ignored, so that callers can't tell whether
+// an account exists.
+func (s *PasswordResetService) RequestPasswordReset(ctx context.Context, email string, locales ...string) error {
+	user, err := s.UsersService.GetUserByEmail(ctx, email)
+	if err != nil {
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			log.Info().Msgf("Ignoring password reset request for unknown email %s", email)
+			return nil
+		}
+		return err
+	}
+
+	token, expiresAt, err := s.OneTimeTokenService.IssueToken(ctx, PasswordResetPurpose, *user, s.SecondsToExpire)
+	if err != nil {
+		return err
+	}
+
+	mailData, err := newTokenMailData(s.ResetUrl, *token, *expiresAt)
+	if err != nil {
+		return err
+	}
+
+	return s.Mailer.Send(ctx, user.Email, "password_reset", mailData, locales...)
+}
+
+// ConfirmPasswordReset sets the password of the user the token was issued to, and invalidates
+// every other password reset token and pending two-factor challenge of the user.
+func (s *PasswordResetService) ConfirmPasswordReset(ctx context.Context, token string, password string) (*User, error) {
+	err := validatePassword(password)
+	if err != nil {
+		return nil, &custom_errors.InvalidArgumentError{Message: err.Error()}
+	}
+
+	user, err := s.OneTimeTokenService.ConsumeToken(ctx, PasswordResetPurpose, token)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err = s.UsersService.UpdateUserById(ctx, user.Id, UserUpdate{Password: &password})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.OneTimeTokenService.RevokeAllForUser(ctx, PasswordResetPurpose, user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.OneTimeTokenService.RevokeAllForUser(ctx, TwoFactorChallengePurpose, user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// tokenMailData is the data of the mail templates carrying a one-time token. Link is pageUrl with
+// the token in its token query parameter, or empty when pageUrl is.
+type tokenMailData struct {
+	Link      string
+	Token     string
+	ExpiresAt time.Time
+}
+
+func newTokenMailData(pageUrl string, token string, expiresAt time.Time) (*tokenMailData, error) {
+	mailData := tokenMailData{
+		Token:     token,
+		ExpiresAt: expiresAt,
+	}
+
+	if len(pageUrl) > 0 {
+		link, err := url.Parse(pageUrl)
+		if err != nil {
+			return nil, err
+		}
+
+		query := link.Query()
+		query.Set("token", token)
+		link.RawQuery = query.Encode()
+
+		mailData.Link = link.String()
+	}
+
+	return &mailData, nil
+}
@@ -0,0 +1,52 @@
+package users
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+)
+
+type PostgresExternalIdentityRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewPostgresExternalIdentityRepository(pool *pgxpool.Pool) *PostgresExternalIdentityRepository {
+	return &PostgresExternalIdentityRepository{
+		Pool: pool,
+	}
+}
+
+func (r *PostgresExternalIdentityRepository) Create(ctx context.Context, externalIdentity ExternalIdentity) error {
+	_, err := r.Pool.Exec(ctx, `INSERT INTO external_identities (provider, subject, user_id, email, created_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		externalIdentity.Provider, externalIdentity.Subject, externalIdentity.UserId, externalIdentity.Email, externalIdentity.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return &custom_errors.AlreadyExistsError{Message: "External identity already linked"}
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *PostgresExternalIdentityRepository) GetByProviderSubject(ctx context.Context, provider string, subject string) (*ExternalIdentity, error) {
+	externalIdentity := ExternalIdentity{}
+	err := r.Pool.QueryRow(ctx, `SELECT provider, subject, user_id, email, created_at
+		FROM external_identities
+		WHERE provider = $1 AND subject = $2`, provider, subject).
+		Scan(&externalIdentity.Provider, &externalIdentity.Subject, &externalIdentity.UserId, &externalIdentity.Email, &externalIdentity.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &custom_errors.NotFoundError{Message: "External identity not found"}
+		}
+		return nil, err
+	}
+
+	return &externalIdentity, nil
+}
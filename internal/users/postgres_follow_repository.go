@@ -0,0 +1,103 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type PostgresFollowRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewPostgresFollowRepository(pool *pgxpool.Pool) *PostgresFollowRepository {
+	return &PostgresFollowRepository{
+		Pool: pool,
+	}
+}
+
+func (r *PostgresFollowRepository) Create(ctx context.Context, follow Follow) error {
+	_, err := r.Pool.Exec(ctx, `INSERT INTO follows (follower_id, followee_id, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (follower_id, followee_id) DO NOTHING`,
+		follow.FollowerId, follow.FolloweeId, follow.CreatedAt)
+	return err
+}
+
+func (r *PostgresFollowRepository) Delete(ctx context.Context, followerId string, followeeId string) error {
+	_, err := r.Pool.Exec(ctx, "DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2", followerId, followeeId)
+	return err
+}
+
+func (r *PostgresFollowRepository) Exists(ctx context.Context, followerId string, followeeId string) (bool, error) {
+	var exists bool
+	err := r.Pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM follows WHERE follower_id = $1 AND followee_id = $2)", followerId, followeeId).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func (r *PostgresFollowRepository) ListFollowers(ctx context.Context, followeeId string, limit int, after *Follow) ([]Follow, error) {
+	var afterCreatedAt *time.Time
+	var afterFollowerId *string
+	if after != nil {
+		afterCreatedAt = &after.CreatedAt
+		afterFollowerId = &after.FollowerId
+	}
+
+	return r.list(ctx, "followee_id", "follower_id", followeeId, limit, afterCreatedAt, afterFollowerId)
+}
+
+func (r *PostgresFollowRepository) ListFollowing(ctx context.Context, followerId string, limit int, after *Follow) ([]Follow, error) {
+	var afterCreatedAt *time.Time
+	var afterFolloweeId *string
+	if after != nil {
+		afterCreatedAt = &after.CreatedAt
+		afterFolloweeId = &after.FolloweeId
+	}
+
+	return r.list(ctx, "follower_id", "followee_id", followerId, limit, afterCreatedAt, afterFolloweeId)
+}
+
+func (r *PostgresFollowRepository) GetCounts(ctx context.Context, userId string) (*FollowCounts, error) {
+	followCounts := FollowCounts{}
+	err := r.Pool.QueryRow(ctx, `SELECT
+		(SELECT COUNT(*) FROM follows WHERE followee_id = $1),
+		(SELECT COUNT(*) FROM follows WHERE follower_id = $1)`, userId).
+		Scan(&followCounts.Followers, &followCounts.Following)
+	if err != nil {
+		return nil, err
+	}
+
+	return &followCounts, nil
+}
+
+// list filters by userColumn and orders by creation time and then by listedColumn, both
+// descending. Column names are never user input.
+func (r *PostgresFollowRepository) list(ctx context.Context, userColumn string, listedColumn string, userId string, limit int, afterCreatedAt *time.Time, afterListedId *string) ([]Follow, error) {
+	rows, err := r.Pool.Query(ctx, fmt.Sprintf(`SELECT follower_id, followee_id, created_at
+		FROM follows
+		WHERE %[1]s = $1 AND ($2::timestamptz IS NULL OR (created_at, %[2]s) < ($2, $3))
+		ORDER BY created_at DESC, %[2]s DESC
+		LIMIT $4`, userColumn, listedColumn), userId, afterCreatedAt, afterListedId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	follows := []Follow{}
+	for rows.Next() {
+		follow := Follow{}
+		err := rows.Scan(&follow.FollowerId, &follow.FolloweeId, &follow.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		follows = append(follows, follow)
+	}
+
+	return follows, rows.Err()
+}
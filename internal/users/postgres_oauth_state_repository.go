@@ -0,0 +1,43 @@
+package users
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+)
+
+type PostgresOauthStateRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewPostgresOauthStateRepository(pool *pgxpool.Pool) *PostgresOauthStateRepository {
+	return &PostgresOauthStateRepository{
+		Pool: pool,
+	}
+}
+
+func (r *PostgresOauthStateRepository) Create(ctx context.Context, oauthState OauthState) error {
+	_, err := r.Pool.Exec(ctx, `INSERT INTO oauth_states (state_hash, provider, code_verifier, nonce, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		oauthState.StateHash, oauthState.Provider, oauthState.CodeVerifier, oauthState.Nonce, oauthState.CreatedAt, oauthState.ExpiresAt)
+	return err
+}
+
+func (r *PostgresOauthStateRepository) Consume(ctx context.Context, stateHash string) (*OauthState, error) {
+	oauthState := OauthState{}
+	err := r.Pool.QueryRow(ctx, `DELETE FROM oauth_states
+		WHERE state_hash = $1
+		RETURNING state_hash, provider, code_verifier, nonce, created_at, expires_at`, stateHash).
+		Scan(&oauthState.StateHash, &oauthState.Provider, &oauthState.CodeVerifier, &oauthState.Nonce, &oauthState.CreatedAt, &oauthState.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &custom_errors.NotFoundError{Message: "State not found"}
+		}
+		return nil, err
+	}
+
+	return &oauthState, nil
+}
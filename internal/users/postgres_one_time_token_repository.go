@@ -0,0 +1,61 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+)
+
+type PostgresOneTimeTokenRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewPostgresOneTimeTokenRepository(pool *pgxpool.Pool) *PostgresOneTimeTokenRepository {
+	return &PostgresOneTimeTokenRepository{
+		Pool: pool,
+	}
+}
+
+func (r *PostgresOneTimeTokenRepository) Create(ctx context.Context, oneTimeToken OneTimeToken) error {
+	_, err := r.Pool.Exec(ctx, `INSERT INTO one_time_tokens (token_hash, purpose, user_id, email, created_at, expires_at, used_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		oneTimeToken.TokenHash, string(oneTimeToken.Purpose), oneTimeToken.UserId, oneTimeToken.Email, oneTimeToken.CreatedAt, oneTimeToken.ExpiresAt, oneTimeToken.UsedAt)
+	return err
+}
+
+func (r *PostgresOneTimeTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*OneTimeToken, error) {
+	oneTimeToken := OneTimeToken{}
+	var purpose string
+	err := r.Pool.QueryRow(ctx, `SELECT token_hash, purpose, user_id, email, created_at, expires_at, used_at
+		FROM one_time_tokens
+		WHERE token_hash = $1`, tokenHash).
+		Scan(&oneTimeToken.TokenHash, &purpose, &oneTimeToken.UserId, &oneTimeToken.Email, &oneTimeToken.CreatedAt, &oneTimeToken.ExpiresAt, &oneTimeToken.UsedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &custom_errors.NotFoundError{Message: "Token not found"}
+		}
+		return nil, err
+	}
+
+	oneTimeToken.Purpose = OneTimeTokenPurpose(purpose)
+
+	return &oneTimeToken, nil
+}
+
+func (r *PostgresOneTimeTokenRepository) MarkUsed(ctx context.Context, tokenHash string, usedAt time.Time) (bool, error) {
+	commandTag, err := r.Pool.Exec(ctx, "UPDATE one_time_tokens SET used_at = $2 WHERE token_hash = $1 AND used_at IS NULL", tokenHash, usedAt)
+	if err != nil {
+		return false, err
+	}
+
+	return commandTag.RowsAffected() == 1, nil
+}
+
+func (r *PostgresOneTimeTokenRepository) MarkAllUsedForUser(ctx context.Context, userId string, purpose OneTimeTokenPurpose, usedAt time.Time) error {
+	_, err := r.Pool.Exec(ctx, "UPDATE one_time_tokens SET used_at = $3 WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL", userId, string(purpose), usedAt)
+	return err
+}
@@ -0,0 +1,97 @@
+package users
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+)
+
+type PostgresTotpEnrollmentRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewPostgresTotpEnrollmentRepository(pool *pgxpool.Pool) *PostgresTotpEnrollmentRepository {
+	return &PostgresTotpEnrollmentRepository{
+		Pool: pool,
+	}
+}
+
+const totpEnrollmentColumns = "user_id, secret, recovery_code_hashes, last_used_step, created_at, confirmed_at"
+
+func (r *PostgresTotpEnrollmentRepository) GetByUserId(ctx context.Context, userId string) (*TotpEnrollment, error) {
+	totpEnrollment := TotpEnrollment{}
+	err := r.Pool.QueryRow(ctx, "SELECT "+totpEnrollmentColumns+" FROM totp_enrollments WHERE user_id = $1", userId).
+		Scan(&totpEnrollment.UserId, &totpEnrollment.Secret, &totpEnrollment.RecoveryCodeHashes, &totpEnrollment.LastUsedStep, &totpEnrollment.CreatedAt, &totpEnrollment.ConfirmedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &custom_errors.NotFoundError{Message: "TOTP enrollment not found"}
+		}
+		return nil, err
+	}
+
+	return &totpEnrollment, nil
+}
+
+// Update locks the user's row for the duration of the transaction. As there is no row to lock for
+// users without an enrollment, new ones are inserted with ON CONFLICT, and update is called again
+// if another transaction inserted the row first.
+func (r *PostgresTotpEnrollmentRepository) Update(ctx context.Context, userId string, update func(totpEnrollment *TotpEnrollment) (*TotpEnrollment, error)) error {
+	for {
+		retry := false
+
+		err := r.Pool.BeginFunc(ctx, func(tx pgx.Tx) error {
+			totpEnrollment := TotpEnrollment{}
+			err := tx.QueryRow(ctx, "SELECT "+totpEnrollmentColumns+" FROM totp_enrollments WHERE user_id = $1 FOR UPDATE", userId).
+				Scan(&totpEnrollment.UserId, &totpEnrollment.Secret, &totpEnrollment.RecoveryCodeHashes, &totpEnrollment.LastUsedStep, &totpEnrollment.CreatedAt, &totpEnrollment.ConfirmedAt)
+
+			var currentTotpEnrollment *TotpEnrollment
+			if err == nil {
+				currentTotpEnrollment = &totpEnrollment
+			} else if !errors.Is(err, pgx.ErrNoRows) {
+				return err
+			}
+
+			updatedTotpEnrollment, err := update(currentTotpEnrollment)
+			if err != nil {
+				return err
+			}
+
+			if updatedTotpEnrollment == nil {
+				if currentTotpEnrollment == nil {
+					return nil
+				}
+				_, err = tx.Exec(ctx, "DELETE FROM totp_enrollments WHERE user_id = $1", userId)
+				return err
+			}
+
+			if currentTotpEnrollment != nil {
+				_, err = tx.Exec(ctx, `UPDATE totp_enrollments
+					SET secret = $2, recovery_code_hashes = $3, last_used_step = $4, created_at = $5, confirmed_at = $6
+					WHERE user_id = $1`,
+					userId, updatedTotpEnrollment.Secret, updatedTotpEnrollment.RecoveryCodeHashes, updatedTotpEnrollment.LastUsedStep, updatedTotpEnrollment.CreatedAt, updatedTotpEnrollment.ConfirmedAt)
+				return err
+			}
+
+			commandTag, err := tx.Exec(ctx, `INSERT INTO totp_enrollments (`+totpEnrollmentColumns+`)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				ON CONFLICT (user_id) DO NOTHING`,
+				userId, updatedTotpEnrollment.Secret, updatedTotpEnrollment.RecoveryCodeHashes, updatedTotpEnrollment.LastUsedStep, updatedTotpEnrollment.CreatedAt, updatedTotpEnrollment.ConfirmedAt)
+			if err != nil {
+				return err
+			}
+
+			retry = commandTag.RowsAffected() == 0
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if !retry {
+			return nil
+		}
+	}
+}
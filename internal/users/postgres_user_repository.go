@@ -0,0 +1,226 @@
+package users
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"github.com/rs/zerolog/log"
+)
+
+type PostgresUserRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewPostgresUserRepository(pool *pgxpool.Pool) *PostgresUserRepository {
+	return &PostgresUserRepository{
+		Pool: pool,
+	}
+}
+
+const userColumns = "id, username, email, email_verified, password_hash, bio, image, roles"
+
+func (r *PostgresUserRepository) Create(ctx context.Context, user User) (*User, error) {
+	row := r.Pool.QueryRow(ctx, `INSERT INTO users (username, username_key, email, email_key, email_verified, password_hash, bio, image, roles)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING `+userColumns, user.Username, canonicalKey(user.Username), user.Email, canonicalKey(user.Email), user.EmailVerified, user.PasswordHash, user.Bio, user.Image, user.GetRoles())
+
+	createdUser, err := scanUser(row)
+	if err != nil {
+		return nil, mapPostgresUserError(err)
+	}
+
+	return createdUser, nil
+}
+
+func (r *PostgresUserRepository) GetById(ctx context.Context, id string) (*User, error) {
+	return r.getFirstWhere(ctx, "id", id)
+}
+
+func (r *PostgresUserRepository) GetByUsername(ctx context.Context, username string) (*User, error) {
+	return r.getFirstWhere(ctx, "username_key", canonicalKey(username))
+}
+
+func (r *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
+	return r.getFirstWhere(ctx, "email_key", canonicalKey(email))
+}
+
+func (r *PostgresUserRepository) GetByIds(ctx context.Context, ids []string) ([]User, error) {
+	return r.getAllWhereIn(ctx, "id", ids)
+}
+
+func (r *PostgresUserRepository) GetByUsernames(ctx context.Context, usernames []string) ([]User, error) {
+	usernameKeys := []string{}
+	for _, username := range usernames {
+		usernameKeys = append(usernameKeys, canonicalKey(username))
+	}
+
+	return r.getAllWhereIn(ctx, "username_key", usernameKeys)
+}
+
+func (r *PostgresUserRepository) Update(ctx context.Context, user User) (*User, error) {
+	row := r.Pool.QueryRow(ctx, `UPDATE users
+		SET username = $2, username_key = $3, email = $4, email_key = $5, email_verified = $6, password_hash = $7, bio = $8, image = $9, roles = $10
+		WHERE id = $1
+		RETURNING `+userColumns, user.Id, user.Username, canonicalKey(user.Username), user.Email, canonicalKey(user.Email), user.EmailVerified, user.PasswordHash, user.Bio, user.Image, user.GetRoles())
+
+	updatedUser, err := scanUser(row)
+	if err != nil {
+		return nil, mapPostgresUserError(err)
+	}
+
+	return updatedUser, nil
+}
+
+func (r *PostgresUserRepository) Delete(ctx context.Context, id string) error {
+	commandTag, err := r.Pool.Exec(ctx, "DELETE FROM users WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return &custom_errors.NotFoundError{Message: "User not found"}
+	}
+
+	return nil
+}
+
+func (r *PostgresUserRepository) List(ctx context.Context) ([]User, error) {
+	rows, err := r.Pool.Query(ctx, "SELECT "+userColumns+" FROM users ORDER BY username")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+
+	return users, rows.Err()
+}
+
+// BackfillCanonicalKeys rewrites the canonical keys which don't match canonicalKey, such as the
+// ones the migrations computed with lower() and normalize(), which may disagree with Go for
+// non-ASCII input. Users whose keys collide with another user's are logged and left untouched, as
+// are users updated since they were read, whose keys the update already computed.
+func (r *PostgresUserRepository) BackfillCanonicalKeys(ctx context.Context) (int, error) {
+	rows, err := r.Pool.Query(ctx, "SELECT id, username, email, username_key, email_key FROM users")
+	if err != nil {
+		return 0, err
+	}
+
+	type userKeys struct {
+		id          string
+		username    string
+		email       string
+		usernameKey string
+		emailKey    string
+	}
+
+	staleUserKeys := []userKeys{}
+	for rows.Next() {
+		var id, username, email, usernameKey, emailKey string
+		err := rows.Scan(&id, &username, &email, &usernameKey, &emailKey)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+
+		if usernameKey != canonicalKey(username) || emailKey != canonicalKey(email) {
+			staleUserKeys = append(staleUserKeys, userKeys{id: id, username: username, email: email, usernameKey: canonicalKey(username), emailKey: canonicalKey(email)})
+		}
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return 0, rows.Err()
+	}
+
+	backfilled := 0
+	for _, keys := range staleUserKeys {
+		commandTag, err := r.Pool.Exec(ctx, "UPDATE users SET username_key = $2, email_key = $3 WHERE id = $1 AND username = $4 AND email = $5", keys.id, keys.usernameKey, keys.emailKey, keys.username, keys.email)
+		if err != nil {
+			err = mapPostgresUserError(err)
+			if _, ok := err.(*custom_errors.AlreadyExistsError); ok {
+				log.Warn().Err(err).Msgf("Skipping User %s, whose username or email conflicts with another User's", keys.id)
+				continue
+			}
+			return backfilled, err
+		}
+
+		if commandTag.RowsAffected() == 0 {
+			continue
+		}
+
+		backfilled++
+	}
+
+	return backfilled, nil
+}
+
+// getFirstWhere must only be called with trusted column names.
+func (r *PostgresUserRepository) getFirstWhere(ctx context.Context, column string, value string) (*User, error) {
+	row := r.Pool.QueryRow(ctx, "SELECT "+userColumns+" FROM users WHERE "+column+" = $1", value)
+
+	user, err := scanUser(row)
+	if err != nil {
+		return nil, mapPostgresUserError(err)
+	}
+
+	return user, nil
+}
+
+// getAllWhereIn must only be called with trusted column names.
+func (r *PostgresUserRepository) getAllWhereIn(ctx context.Context, column string, values []string) ([]User, error) {
+	rows, err := r.Pool.Query(ctx, "SELECT "+userColumns+" FROM users WHERE "+column+" = ANY($1)", values)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+
+	return users, rows.Err()
+}
+
+func scanUser(row pgx.Row) (*User, error) {
+	user := User{}
+	err := row.Scan(&user.Id, &user.Username, &user.Email, &user.EmailVerified, &user.PasswordHash, &user.Bio, &user.Image, &user.Roles)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func mapPostgresUserError(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &custom_errors.NotFoundError{Message: "User not found"}
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		switch pgErr.ConstraintName {
+		case "users_username_key_unique":
+			return &custom_errors.AlreadyExistsError{Message: "User already exists"}
+		case "users_email_key_unique":
+			return &custom_errors.AlreadyExistsError{Message: "Email is taken"}
+		}
+	}
+
+	return err
+}
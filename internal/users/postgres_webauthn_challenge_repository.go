@@ -0,0 +1,46 @@
+package users
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+)
+
+type PostgresWebauthnChallengeRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewPostgresWebauthnChallengeRepository(pool *pgxpool.Pool) *PostgresWebauthnChallengeRepository {
+	return &PostgresWebauthnChallengeRepository{
+		Pool: pool,
+	}
+}
+
+func (r *PostgresWebauthnChallengeRepository) Create(ctx context.Context, webauthnChallenge WebauthnChallenge) error {
+	_, err := r.Pool.Exec(ctx, `INSERT INTO webauthn_challenges (challenge_hash, ceremony, user_id, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		webauthnChallenge.ChallengeHash, string(webauthnChallenge.Ceremony), webauthnChallenge.UserId, webauthnChallenge.CreatedAt, webauthnChallenge.ExpiresAt)
+	return err
+}
+
+func (r *PostgresWebauthnChallengeRepository) Consume(ctx context.Context, challengeHash string) (*WebauthnChallenge, error) {
+	webauthnChallenge := WebauthnChallenge{}
+	var ceremony string
+	err := r.Pool.QueryRow(ctx, `DELETE FROM webauthn_challenges
+		WHERE challenge_hash = $1
+		RETURNING challenge_hash, ceremony, user_id, created_at, expires_at`, challengeHash).
+		Scan(&webauthnChallenge.ChallengeHash, &ceremony, &webauthnChallenge.UserId, &webauthnChallenge.CreatedAt, &webauthnChallenge.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &custom_errors.NotFoundError{Message: "Challenge not found"}
+		}
+		return nil, err
+	}
+
+	webauthnChallenge.Ceremony = WebauthnCeremony(ceremony)
+
+	return &webauthnChallenge, nil
+}
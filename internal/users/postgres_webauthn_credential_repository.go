@@ -0,0 +1,95 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+)
+
+type PostgresWebauthnCredentialRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewPostgresWebauthnCredentialRepository(pool *pgxpool.Pool) *PostgresWebauthnCredentialRepository {
+	return &PostgresWebauthnCredentialRepository{
+		Pool: pool,
+	}
+}
+
+const webauthnCredentialColumns = "id, user_id, name, public_key, sign_count, created_at, last_used_at"
+
+func (r *PostgresWebauthnCredentialRepository) Create(ctx context.Context, webauthnCredential WebauthnCredential) error {
+	_, err := r.Pool.Exec(ctx, "INSERT INTO webauthn_credentials ("+webauthnCredentialColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		webauthnCredential.Id, webauthnCredential.UserId, webauthnCredential.Name, webauthnCredential.PublicKey, int64(webauthnCredential.SignCount), webauthnCredential.CreatedAt, webauthnCredential.LastUsedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return &custom_errors.AlreadyExistsError{Message: "Credential already registered"}
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *PostgresWebauthnCredentialRepository) GetById(ctx context.Context, userId string, id string) (*WebauthnCredential, error) {
+	webauthnCredential, err := scanWebauthnCredential(r.Pool.QueryRow(ctx, "SELECT "+webauthnCredentialColumns+" FROM webauthn_credentials WHERE user_id = $1 AND id = $2", userId, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &custom_errors.NotFoundError{Message: "Credential not found"}
+		}
+		return nil, err
+	}
+
+	return webauthnCredential, nil
+}
+
+func (r *PostgresWebauthnCredentialRepository) ListByUserId(ctx context.Context, userId string) ([]WebauthnCredential, error) {
+	rows, err := r.Pool.Query(ctx, "SELECT "+webauthnCredentialColumns+" FROM webauthn_credentials WHERE user_id = $1 ORDER BY created_at", userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webauthnCredentials := []WebauthnCredential{}
+	for rows.Next() {
+		webauthnCredential, err := scanWebauthnCredential(rows)
+		if err != nil {
+			return nil, err
+		}
+		webauthnCredentials = append(webauthnCredentials, *webauthnCredential)
+	}
+
+	return webauthnCredentials, rows.Err()
+}
+
+func (r *PostgresWebauthnCredentialRepository) UpdateSignCount(ctx context.Context, userId string, id string, signCount uint32, lastUsedAt time.Time) error {
+	commandTag, err := r.Pool.Exec(ctx, "UPDATE webauthn_credentials SET sign_count = $3, last_used_at = $4 WHERE user_id = $1 AND id = $2", userId, id, int64(signCount), lastUsedAt)
+	if err != nil {
+		return err
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return &custom_errors.NotFoundError{Message: "Credential not found"}
+	}
+
+	return nil
+}
+
+func scanWebauthnCredential(row pgx.Row) (*WebauthnCredential, error) {
+	webauthnCredential := WebauthnCredential{}
+	var signCount int64
+	err := row.Scan(&webauthnCredential.Id, &webauthnCredential.UserId, &webauthnCredential.Name, &webauthnCredential.PublicKey, &signCount, &webauthnCredential.CreatedAt, &webauthnCredential.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	webauthnCredential.SignCount = uint32(signCount)
+
+	return &webauthnCredential, nil
+}
@@ -0,0 +1,256 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/auth"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"github.com/rs/zerolog/log"
+)
+
+type ProfilesHandlers struct {
+	ProfilesService ProfilesService
+	UsersService    UsersService
+}
+
+func NewProfilesHandlers(profilesService ProfilesService, usersService UsersService) ProfilesHandlers {
+	return ProfilesHandlers{
+		ProfilesService: profilesService,
+		UsersService:    usersService,
+	}
+}
+
+type profileResponse struct {
+	Profile profileResponseProfile `json:"profile"`
+}
+
+type profileResponseProfile struct {
+	Username       string  `json:"username"`
+	Bio            *string `json:"bio"`
+	Image          *string `json:"image"`
+	Following      bool    `json:"following"`
+	FollowersCount *int    `json:"followersCount,omitempty"`
+	FollowingCount *int    `json:"followingCount,omitempty"`
+}
+
+func newProfileResponseProfile(profile Profile) profileResponseProfile {
+	profileResponseProfile := profileResponseProfile{
+		Username:  profile.Username,
+		Bio:       profile.Bio,
+		Image:     profile.Image,
+		Following: profile.Following,
+	}
+
+	if profile.FollowCounts != nil {
+		profileResponseProfile.FollowersCount = &profile.FollowCounts.Followers
+		profileResponseProfile.FollowingCount = &profile.FollowCounts.Following
+	}
+
+	return profileResponseProfile
+}
+
+func newProfileResponse(profile Profile) profileResponse {
+	return profileResponse{
+		Profile: newProfileResponseProfile(profile),
+	}
+}
+
+type profilesResponse struct {
+	Profiles   []profileResponseProfile `json:"profiles"`
+	NextCursor *string                  `json:"nextCursor"`
+}
+
+func newProfilesResponse(profilesPage ProfilesPage) profilesResponse {
+	profiles := []profileResponseProfile{}
+	for _, profile := range profilesPage.Profiles {
+		profiles = append(profiles, newProfileResponseProfile(profile))
+	}
+
+	return profilesResponse{
+		Profiles:   profiles,
+		NextCursor: profilesPage.NextCursor,
+	}
+}
+
+const defaultProfilesPageSize = 20
+
+func (h *ProfilesHandlers) GetProfile(w http.ResponseWriter, r *http.Request) {
+	username := chi.URLParam(r, "username")
+
+	viewerId, ok := h.getViewerId(w, r)
+	if !ok {
+		return
+	}
+
+	profile, err := h.ProfilesService.GetProfile(r.Context(), username, viewerId)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting Profile %s", username)
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			notFound(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	writeProfileResponse(w, r, *profile)
+}
+
+func (h *ProfilesHandlers) ListFollowers(w http.ResponseWriter, r *http.Request) {
+	h.listProfiles(w, r, h.ProfilesService.ListFollowers)
+}
+
+func (h *ProfilesHandlers) ListFollowing(w http.ResponseWriter, r *http.Request) {
+	h.listProfiles(w, r, h.ProfilesService.ListFollowing)
+}
+
+func (h *ProfilesHandlers) listProfiles(w http.ResponseWriter, r *http.Request, list func(ctx context.Context, username string, viewerId string, limit int, cursor string) (*ProfilesPage, error)) {
+	username := chi.URLParam(r, "username")
+
+	limit := defaultProfilesPageSize
+	if len(r.URL.Query().Get("limit")) > 0 {
+		var err error
+		limit, err = strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil {
+			log.Error().Err(err).Msgf("Error parsing limit")
+			unprocessableEntity(w, r, []error{&custom_errors.InvalidArgumentError{Message: "Limit must be an integer"}})
+			return
+		}
+	}
+
+	viewerId, ok := h.getViewerId(w, r)
+	if !ok {
+		return
+	}
+
+	profilesPage, err := list(r.Context(), username, viewerId, limit, r.URL.Query().Get("cursor"))
+	if err != nil {
+		log.Error().Err(err).Msgf("Error listing Profiles of User %s", username)
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			notFound(w, r, []error{err})
+			return
+		}
+
+		if _, ok := err.(*custom_errors.InvalidArgumentError); ok {
+			unprocessableEntity(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	response, err := json.Marshal(newProfilesResponse(*profilesPage))
+	if err != nil {
+		log.Error().Err(err).Msgf("Error marshalling response body for Profiles of User %s", username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.Write(response)
+}
+
+func (h *ProfilesHandlers) FollowUser(w http.ResponseWriter, r *http.Request) {
+	username := chi.URLParam(r, "username")
+
+	follower, err := GetAuthenticatedUser(r, &h.UsersService)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting the authenticated User")
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			notFound(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	profile, err := h.ProfilesService.FollowUser(r.Context(), follower.Id, username)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error following User %s by User %s", username, follower.Username)
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			notFound(w, r, []error{err})
+			return
+		}
+
+		if _, ok := err.(*custom_errors.InvalidArgumentError); ok {
+			unprocessableEntity(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	writeProfileResponse(w, r, *profile)
+}
+
+func (h *ProfilesHandlers) UnfollowUser(w http.ResponseWriter, r *http.Request) {
+	username := chi.URLParam(r, "username")
+
+	follower, err := GetAuthenticatedUser(r, &h.UsersService)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting the authenticated User")
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			notFound(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	profile, err := h.ProfilesService.UnfollowUser(r.Context(), follower.Id, username)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error unfollowing User %s by User %s", username, follower.Username)
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			notFound(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	writeProfileResponse(w, r, *profile)
+}
+
+// getViewerId returns the id of the authenticated user, or an empty string for anonymous requests.
+// It writes the error response and returns false if the user can't be resolved.
+func (h *ProfilesHandlers) getViewerId(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if r.Context().Value(auth.UsernameContextKey) == nil {
+		return "", true
+	}
+
+	viewer, err := GetAuthenticatedUser(r, &h.UsersService)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting the authenticated User")
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			unauthorized(w, r)
+			return "", false
+		}
+
+		internalServerError(w, r, err)
+		return "", false
+	}
+
+	return viewer.Id, true
+}
+
+func writeProfileResponse(w http.ResponseWriter, r *http.Request, profile Profile) {
+	response, err := json.Marshal(newProfileResponse(profile))
+	if err != nil {
+		log.Error().Err(err).Msgf("Error marshalling response body for Profile %s", profile.Username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.Write(response)
+}
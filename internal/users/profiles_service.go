@@ -0,0 +1,189 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+)
+
+type ProfilesService struct {
+	UsersService     UsersService
+	FollowRepository FollowRepository
+}
+
+func NewProfilesService(usersService UsersService, followRepository FollowRepository) ProfilesService {
+	return ProfilesService{
+		UsersService:     usersService,
+		FollowRepository: followRepository,
+	}
+}
+
+type Profile struct {
+	Username  string
+	Bio       *string
+	Image     *string
+	Following bool
+	// Only set on single profiles, not on listings.
+	FollowCounts *FollowCounts
+}
+
+type ProfilesPage struct {
+	Profiles   []Profile
+	NextCursor *string
+}
+
+const MaxProfilesPageSize = 100
+
+// GetProfile returns the profile of the user with username as seen by the user with viewerId,
+// which is empty for anonymous viewers.
+func (s *ProfilesService) GetProfile(ctx context.Context, username string, viewerId string) (*Profile, error) {
+	user, err := s.UsersService.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := s.getProfile(ctx, *user, viewerId)
+	if err != nil {
+		return nil, err
+	}
+
+	profile.FollowCounts, err = s.FollowRepository.GetCounts(ctx, user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+// ListFollowers returns the profiles of the users following the user with username, newest
+// follows first, as seen by the user with viewerId. cursor is empty for the first page, and
+// otherwise the NextCursor of the previous page.
+func (s *ProfilesService) ListFollowers(ctx context.Context, username string, viewerId string, limit int, cursor string) (*ProfilesPage, error) {
+	return s.listProfiles(ctx, username, viewerId, limit, cursor, s.FollowRepository.ListFollowers, func(follow Follow) string {
+		return follow.FollowerId
+	})
+}
+
+// ListFollowing returns the profiles of the users followed by the user with username, like
+// ListFollowers.
+func (s *ProfilesService) ListFollowing(ctx context.Context, username string, viewerId string, limit int, cursor string) (*ProfilesPage, error) {
+	return s.listProfiles(ctx, username, viewerId, limit, cursor, s.FollowRepository.ListFollowing, func(follow Follow) string {
+		return follow.FolloweeId
+	})
+}
+
+func (s *ProfilesService) FollowUser(ctx context.Context, followerId string, username string) (*Profile, error) {
+	user, err := s.UsersService.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Id == followerId {
+		return nil, &custom_errors.InvalidArgumentError{Message: "Users can't follow themselves"}
+	}
+
+	err = s.FollowRepository.Create(ctx, NewFollow(followerId, user.Id, time.Now()))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getProfile(ctx, *user, followerId)
+}
+
+func (s *ProfilesService) UnfollowUser(ctx context.Context, followerId string, username string) (*Profile, error) {
+	user, err := s.UsersService.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.FollowRepository.Delete(ctx, followerId, user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getProfile(ctx, *user, followerId)
+}
+
+func (s *ProfilesService) IsFollowing(ctx context.Context, followerId string, followeeId string) (bool, error) {
+	return s.FollowRepository.Exists(ctx, followerId, followeeId)
+}
+
+func (s *ProfilesService) listProfiles(ctx context.Context, username string, viewerId string, limit int, cursor string, listFollows func(ctx context.Context, userId string, limit int, after *Follow) ([]Follow, error), listedId func(Follow) string) (*ProfilesPage, error) {
+	if limit < 1 || limit > MaxProfilesPageSize {
+		return nil, &custom_errors.InvalidArgumentError{Message: fmt.Sprintf("Limit must be between 1 and %d", MaxProfilesPageSize)}
+	}
+
+	var after *Follow
+	if len(cursor) > 0 {
+		var err error
+		after, err = decodeFollowCursor(cursor)
+		if err != nil {
+			return nil, &custom_errors.InvalidArgumentError{Message: "Invalid cursor"}
+		}
+	}
+
+	user, err := s.UsersService.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	follows, err := listFollows(ctx, user.Id, limit+1, after)
+	if err != nil {
+		return nil, err
+	}
+
+	profilesPage := ProfilesPage{
+		Profiles: []Profile{},
+	}
+
+	if len(follows) > limit {
+		follows = follows[:limit]
+		nextCursor, err := encodeFollowCursor(follows[limit-1])
+		if err != nil {
+			return nil, err
+		}
+		profilesPage.NextCursor = nextCursor
+	}
+
+	listedUserIds := []string{}
+	for _, follow := range follows {
+		listedUserIds = append(listedUserIds, listedId(follow))
+	}
+
+	// Users deleted since they followed or were followed are left out.
+	usersBatch, err := s.UsersService.BatchGetUsers(ctx, listedUserIds, []string{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, listedUser := range usersBatch.Users {
+		profile, err := s.getProfile(ctx, listedUser, viewerId)
+		if err != nil {
+			return nil, err
+		}
+
+		profilesPage.Profiles = append(profilesPage.Profiles, *profile)
+	}
+
+	return &profilesPage, nil
+}
+
+func (s *ProfilesService) getProfile(ctx context.Context, user User, viewerId string) (*Profile, error) {
+	following := false
+	if len(viewerId) > 0 && viewerId != user.Id {
+		var err error
+		following, err = s.FollowRepository.Exists(ctx, viewerId, user.Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Profile{
+		Username:  user.Username,
+		Bio:       user.Bio,
+		Image:     user.Image,
+		Following: following,
+	}, nil
+}
@@ -0,0 +1,29 @@
+package users
+
+import "time"
+
+// TotpEnrollment is the TOTP secret of a user, which turns two-factor authentication on once
+// confirmed with a code. Only the hashes of the recovery codes are stored.
+type TotpEnrollment struct {
+	UserId             string
+	Secret             string
+	RecoveryCodeHashes []string
+	LastUsedStep       int64
+	CreatedAt          time.Time
+	ConfirmedAt        *time.Time
+}
+
+func NewTotpEnrollment(userId string, secret string, recoveryCodeHashes []string, lastUsedStep int64, createdAt time.Time, confirmedAt *time.Time) TotpEnrollment {
+	return TotpEnrollment{
+		UserId:             userId,
+		Secret:             secret,
+		RecoveryCodeHashes: recoveryCodeHashes,
+		LastUsedStep:       lastUsedStep,
+		CreatedAt:          createdAt,
+		ConfirmedAt:        confirmedAt,
+	}
+}
+
+func (e *TotpEnrollment) IsConfirmed() bool {
+	return e.ConfirmedAt != nil
+}
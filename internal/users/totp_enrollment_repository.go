@@ -0,0 +1,11 @@
+package users
+
+import "context"
+
+type TotpEnrollmentRepository interface {
+	GetByUserId(ctx context.Context, userId string) (*TotpEnrollment, error)
+	// Update atomically replaces the enrollment of the user with the result of update, which is
+	// given nil when there is none. Returning nil deletes it, and returning an error leaves it
+	// untouched and is returned by Update. update may be called more than once.
+	Update(ctx context.Context, userId string, update func(totpEnrollment *TotpEnrollment) (*TotpEnrollment, error)) error
+}
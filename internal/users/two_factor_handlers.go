@@ -0,0 +1,293 @@
+package users
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/auth"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"github.com/rs/zerolog/log"
+)
+
+type twoFactorChallengeResponse struct {
+	Challenge twoFactorChallengeResponseChallenge `json:"challenge"`
+}
+
+type twoFactorChallengeResponseChallenge struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type totpEnrollmentResponse struct {
+	Totp totpEnrollmentResponseTotp `json:"totp"`
+}
+
+type totpEnrollmentResponseTotp struct {
+	Secret string `json:"secret"`
+	Uri    string `json:"uri"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+func (h *UsersHandlers) EnrollTotp(w http.ResponseWriter, r *http.Request) {
+	username := r.Context().Value(auth.UsernameContextKey).(string)
+
+	user, err := GetAuthenticatedUser(r, &h.UsersService)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting User %s", username)
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			notFound(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	secret, provisioningUri, err := h.TwoFactorService.EnrollTotp(r.Context(), *user)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error enrolling TOTP for User %s", username)
+		if _, ok := err.(*custom_errors.InvalidArgumentError); ok {
+			unprocessableEntity(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	response, err := json.Marshal(totpEnrollmentResponse{
+		Totp: totpEnrollmentResponseTotp{
+			Secret: *secret,
+			Uri:    *provisioningUri,
+		},
+	})
+	if err != nil {
+		log.Error().Err(err).Msgf("Error marshalling response body for User %s", username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.Write(response)
+}
+
+func (h *UsersHandlers) ConfirmTotp(w http.ResponseWriter, r *http.Request) {
+	username := r.Context().Value(auth.UsernameContextKey).(string)
+
+	var request struct {
+		Code string `json:"code"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error decoding request")
+		unprocessableEntity(w, r, []error{err})
+		return
+	}
+
+	user, err := GetAuthenticatedUser(r, &h.UsersService)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting User %s", username)
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			notFound(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	recoveryCodes, err := h.TwoFactorService.ConfirmTotp(r.Context(), user.Id, request.Code)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error confirming TOTP for User %s", username)
+		if _, ok := err.(*custom_errors.InvalidArgumentError); ok {
+			unprocessableEntity(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	response, err := json.Marshal(recoveryCodesResponse{
+		RecoveryCodes: recoveryCodes,
+	})
+	if err != nil {
+		log.Error().Err(err).Msgf("Error marshalling response body for User %s", username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.Write(response)
+}
+
+func (h *UsersHandlers) DisableTotp(w http.ResponseWriter, r *http.Request) {
+	username := r.Context().Value(auth.UsernameContextKey).(string)
+
+	var request struct {
+		Password string `json:"password"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error decoding request")
+		unprocessableEntity(w, r, []error{err})
+		return
+	}
+
+	user, err := GetAuthenticatedUser(r, &h.UsersService)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting User %s", username)
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			notFound(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	err = h.TwoFactorService.DisableTotp(r.Context(), *user, request.Password)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error disabling TOTP for User %s", username)
+		if _, ok := err.(*custom_errors.InvalidArgumentError); ok {
+			unprocessableEntity(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// VerifyTwoFactorLogin completes the login of users with two-factor authentication enabled,
+// exchanging the challenge token returned by Login and either a TOTP code or a recovery code for
+// access tokens. Failed codes are throttled like failed logins, under a key of their own so that
+// knowing the password doesn't reset them.
+func (h *UsersHandlers) VerifyTwoFactorLogin(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Token        string `json:"token"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recoveryCode"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error decoding request")
+		unprocessableEntity(w, r, []error{err})
+		return
+	}
+
+	user, err := h.TwoFactorService.GetChallengeUser(r.Context(), request.Token)
+	if err != nil {
+		log.Error().Err(err).Msg("Error getting the User of the two-factor challenge")
+		if _, ok := err.(*custom_errors.InvalidArgumentError); ok {
+			unprocessableEntity(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	account := fmt.Sprintf("two_factor:%s", user.Id)
+	ip := auth.ClientIp(r)
+
+	retryAfter, err := h.LoginThrottleService.Attempt(r.Context(), account, ip)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error throttling two-factor login for User %s from %s", user.Username, ip)
+		internalServerError(w, r, err)
+		return
+	}
+	if retryAfter > 0 {
+		log.Warn().Msgf("Throttling two-factor login for User %s from %s for %s", user.Username, ip, retryAfter)
+		tooManyRequests(w, r, retryAfter, fmt.Errorf("Too many failed login attempts"))
+		return
+	}
+
+	isValidSecondFactor, err := h.TwoFactorService.VerifySecondFactor(r.Context(), user.Id, request.Code, request.RecoveryCode)
+	if err != nil || !isValidSecondFactor {
+		log.Error().Err(err).Msgf("Error verifying the second factor of User %s", user.Username)
+		unauthorized(w, r)
+		return
+	}
+
+	user, err = h.TwoFactorService.CompleteChallenge(r.Context(), request.Token)
+	if err != nil {
+		log.Error().Err(err).Msg("Error completing the two-factor challenge")
+		if _, ok := err.(*custom_errors.InvalidArgumentError); ok {
+			unprocessableEntity(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	err = h.LoginThrottleService.Succeeded(r.Context(), account, ip)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error resetting failed two-factor logins for User %s from %s", user.Username, ip)
+		internalServerError(w, r, err)
+		return
+	}
+
+	token, err := h.JwtService.GenerateToken(user.Id, user.Username, user.GetRoles())
+	if err != nil {
+		log.Error().Err(err).Msgf("Error generating token for User %s", user.Username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	refreshToken, err := h.RefreshTokenService.IssueToken(r.Context(), user.Id)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error issuing refresh token for User %s", user.Username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	responseBody := newUserResponse(user.Email, user.EmailVerified, *token, refreshToken, user.Username, user.Bio, user.Image)
+
+	response, err := json.Marshal(responseBody)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error marshalling response body for User %s", user.Username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.Write(response)
+}
+
+// twoFactorChallenge answers the login of a user with two-factor authentication enabled with a
+// challenge token rather than access tokens.
+func twoFactorChallenge(w http.ResponseWriter, r *http.Request, twoFactorService *TwoFactorService, user User) {
+	challengeToken, expiresAt, err := twoFactorService.IssueChallenge(r.Context(), user)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error issuing two-factor challenge for User %s", user.Username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	response, err := json.Marshal(twoFactorChallengeResponse{
+		Challenge: twoFactorChallengeResponseChallenge{
+			Token:     *challengeToken,
+			ExpiresAt: *expiresAt,
+		},
+	})
+	if err != nil {
+		log.Error().Err(err).Msgf("Error marshalling response body for User %s", user.Username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.Write(response)
+}
@@ -0,0 +1,215 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+	"time"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/auth"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+)
+
+const recoveryCodesCount = 10
+
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+type TwoFactorService struct {
+	UsersService             UsersService
+	OneTimeTokenService      OneTimeTokenService
+	TotpEnrollmentRepository TotpEnrollmentRepository
+	TotpIssuer               string
+	ChallengeSecondsToExpire int
+}
+
+func NewTwoFactorService(usersService UsersService, oneTimeTokenService OneTimeTokenService, totpEnrollmentRepository TotpEnrollmentRepository, totpIssuer string, challengeSecondsToExpire int) TwoFactorService {
+	return TwoFactorService{
+		UsersService:             usersService,
+		OneTimeTokenService:      oneTimeTokenService,
+		TotpEnrollmentRepository: totpEnrollmentRepository,
+		TotpIssuer:               totpIssuer,
+		ChallengeSecondsToExpire: challengeSecondsToExpire,
+	}
+}
+
+// EnrollTotp replaces any unconfirmed enrollment of the user with a new secret, returning it along
+// with its provisioning URI.
+func (s *TwoFactorService) EnrollTotp(ctx context.Context, user User) (*string, *string, error) {
+	secret, err := auth.NewTotpSecret()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = s.TotpEnrollmentRepository.Update(ctx, user.Id, func(totpEnrollment *TotpEnrollment) (*TotpEnrollment, error) {
+		if totpEnrollment != nil && totpEnrollment.IsConfirmed() {
+			return nil, &custom_errors.InvalidArgumentError{Message: "Two-factor authentication is already enabled"}
+		}
+
+		newTotpEnrollment := NewTotpEnrollment(user.Id, *secret, []string{}, 0, time.Now(), nil)
+		return &newTotpEnrollment, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	provisioningUri := auth.TotpProvisioningUri(*secret, s.TotpIssuer, user.Email)
+
+	return secret, &provisioningUri, nil
+}
+
+// ConfirmTotp enables two-factor authentication once the user proves the secret was enrolled
+// with a code, and returns the recovery codes. They're only ever returned here.
+func (s *TwoFactorService) ConfirmTotp(ctx context.Context, userId string, code string) ([]string, error) {
+	recoveryCodes, recoveryCodeHashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.TotpEnrollmentRepository.Update(ctx, userId, func(totpEnrollment *TotpEnrollment) (*TotpEnrollment, error) {
+		if totpEnrollment == nil || totpEnrollment.IsConfirmed() {
+			return nil, &custom_errors.InvalidArgumentError{Message: "No pending TOTP enrollment"}
+		}
+
+		now := time.Now()
+
+		step, ok := auth.ValidateTotpCode(totpEnrollment.Secret, code, now, totpEnrollment.LastUsedStep)
+		if !ok {
+			return nil, &custom_errors.InvalidArgumentError{Message: "Invalid code"}
+		}
+
+		totpEnrollment.RecoveryCodeHashes = recoveryCodeHashes
+		totpEnrollment.LastUsedStep = step
+		totpEnrollment.ConfirmedAt = &now
+
+		return totpEnrollment, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+func (s *TwoFactorService) IsEnabled(ctx context.Context, userId string) (bool, error) {
+	totpEnrollment, err := s.TotpEnrollmentRepository.GetByUserId(ctx, userId)
+	if err != nil {
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return totpEnrollment.IsConfirmed(), nil
+}
+
+// DisableTotp removes the enrollment of the user, who must confirm their password, and
+// invalidates the user's pending challenges.
+func (s *TwoFactorService) DisableTotp(ctx context.Context, user User, password string) error {
+	isCorrectPassword, err := s.UsersService.IsCorrectPassword(ctx, user.Email, password)
+	if err != nil {
+		return err
+	}
+	if !isCorrectPassword {
+		return &custom_errors.InvalidArgumentError{Message: "Incorrect password"}
+	}
+
+	err = s.TotpEnrollmentRepository.Update(ctx, user.Id, func(totpEnrollment *TotpEnrollment) (*TotpEnrollment, error) {
+		if totpEnrollment == nil || !totpEnrollment.IsConfirmed() {
+			return nil, &custom_errors.InvalidArgumentError{Message: "Two-factor authentication is not enabled"}
+		}
+
+		return nil, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.OneTimeTokenService.RevokeAllForUser(ctx, TwoFactorChallengePurpose, user.Id)
+}
+
+// IssueChallenge returns a token standing for the user's correct password, to be exchanged for
+// access tokens along with the second factor.
+func (s *TwoFactorService) IssueChallenge(ctx context.Context, user User) (*string, *time.Time, error) {
+	return s.OneTimeTokenService.IssueToken(ctx, TwoFactorChallengePurpose, user, s.ChallengeSecondsToExpire)
+}
+
+func (s *TwoFactorService) GetChallengeUser(ctx context.Context, challengeToken string) (*User, error) {
+	return s.OneTimeTokenService.GetTokenUser(ctx, TwoFactorChallengePurpose, challengeToken)
+}
+
+// VerifySecondFactor checks either the TOTP code or the recovery code of the user. Codes can't be
+// used twice: the code's time step must be after the last one used, and recovery codes are
+// removed once used.
+func (s *TwoFactorService) VerifySecondFactor(ctx context.Context, userId string, code string, recoveryCode string) (bool, error) {
+	errInvalidSecondFactor := &custom_errors.UnauthenticatedError{Message: "Invalid second factor"}
+
+	err := s.TotpEnrollmentRepository.Update(ctx, userId, func(totpEnrollment *TotpEnrollment) (*TotpEnrollment, error) {
+		if totpEnrollment == nil || !totpEnrollment.IsConfirmed() {
+			return nil, errInvalidSecondFactor
+		}
+
+		if len(recoveryCode) > 0 {
+			recoveryCodeHash := hashRecoveryCode(recoveryCode)
+
+			for i, storedRecoveryCodeHash := range totpEnrollment.RecoveryCodeHashes {
+				if storedRecoveryCodeHash == recoveryCodeHash {
+					totpEnrollment.RecoveryCodeHashes = append(totpEnrollment.RecoveryCodeHashes[:i], totpEnrollment.RecoveryCodeHashes[i+1:]...)
+					return totpEnrollment, nil
+				}
+			}
+
+			return nil, errInvalidSecondFactor
+		}
+
+		step, ok := auth.ValidateTotpCode(totpEnrollment.Secret, code, time.Now(), totpEnrollment.LastUsedStep)
+		if !ok {
+			return nil, errInvalidSecondFactor
+		}
+
+		totpEnrollment.LastUsedStep = step
+
+		return totpEnrollment, nil
+	})
+	if err != nil {
+		if err == errInvalidSecondFactor {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// CompleteChallenge uses the challenge token up, once the second factor was verified.
+func (s *TwoFactorService) CompleteChallenge(ctx context.Context, challengeToken string) (*User, error) {
+	return s.OneTimeTokenService.ConsumeToken(ctx, TwoFactorChallengePurpose, challengeToken)
+}
+
+// newRecoveryCodes returns recovery codes formatted as xxxxx-xxxxx, along with their hashes.
+func newRecoveryCodes() ([]string, []string, error) {
+	recoveryCodes := []string{}
+	recoveryCodeHashes := []string{}
+
+	for i := 0; i < recoveryCodesCount; i++ {
+		recoveryCodeBytes := make([]byte, 10)
+		_, err := rand.Read(recoveryCodeBytes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		recoveryCode := recoveryCodeEncoding.EncodeToString(recoveryCodeBytes)[:10]
+		recoveryCode = recoveryCode[:5] + "-" + recoveryCode[5:]
+
+		recoveryCodes = append(recoveryCodes, recoveryCode)
+		recoveryCodeHashes = append(recoveryCodeHashes, hashRecoveryCode(recoveryCode))
+	}
+
+	return recoveryCodes, recoveryCodeHashes, nil
+}
+
+// hashRecoveryCode ignores case, spaces and dashes, which users may get wrong when typing codes.
+func hashRecoveryCode(recoveryCode string) string {
+	normalizedRecoveryCode := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(recoveryCode))
+	return auth.HashOpaqueToken(normalizedRecoveryCode)
+}
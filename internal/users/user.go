@@ -1,21 +1,49 @@
 package users
 
+import (
+	"strings"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/auth"
+)
+
 type User struct {
-	Id           string
-	Username     string
-	Email        string
-	PasswordHash string
-	Bio          *string
-	Image        *string
+	Id            string
+	Username      string
+	Email         string
+	EmailVerified bool
+	PasswordHash  string
+	Bio           *string
+	Image         *string
+	Roles         []string
 }
 
-func NewUser(id string, username string, email string, passwordHash string, bio *string, image *string) User {
+func NewUser(id string, username string, email string, emailVerified bool, passwordHash string, bio *string, image *string, roles []string) User {
 	return User{
-		Id:           id,
-		Username:     username,
-		Email:        email,
-		PasswordHash: passwordHash,
-		Bio:          bio,
-		Image:        image,
+		Id:            id,
+		Username:      username,
+		Email:         email,
+		EmailVerified: emailVerified,
+		PasswordHash:  passwordHash,
+		Bio:           bio,
+		Image:         image,
+		Roles:         roles,
+	}
+}
+
+// GetRoles returns the roles of the user, which are the user role for users stored before roles.
+func (u *User) GetRoles() []string {
+	if len(u.Roles) == 0 {
+		return []string{auth.RoleUser}
 	}
+	return u.Roles
+}
+
+func (u *User) HasRole(role string) bool {
+	return contains(u.GetRoles(), role)
+}
+
+// isValidUserId rejects the ids that can't address a user document, as ids with slashes would
+// address documents in other Firestore collections.
+func isValidUserId(id string) bool {
+	return len(id) > 0 && !strings.Contains(id, "/")
 }
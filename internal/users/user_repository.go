@@ -0,0 +1,16 @@
+package users
+
+import "context"
+
+type UserRepository interface {
+	Create(ctx context.Context, user User) (*User, error)
+	GetById(ctx context.Context, id string) (*User, error)
+	GetByUsername(ctx context.Context, username string) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	// GetByIds and GetByUsernames return the users found, in no particular order.
+	GetByIds(ctx context.Context, ids []string) ([]User, error)
+	GetByUsernames(ctx context.Context, usernames []string) ([]User, error)
+	Update(ctx context.Context, user User) (*User, error)
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context) ([]User, error)
+}
@@ -0,0 +1,155 @@
+package users
+
+import (
+	"context"
+	"time"
+
+	usersv1 "github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/api/users/v1"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/auth"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// UsersGrpcServer serves the internal gRPC API to the other microservices. It has no
+// authentication of its own and must only be reachable from within the deployment.
+type UsersGrpcServer struct {
+	usersv1.UnimplementedUsersServiceServer
+	UsersService           UsersService
+	ProfilesService        ProfilesService
+	JwtService             auth.JwtService
+	TokenRevocationService auth.TokenRevocationService
+}
+
+func NewUsersGrpcServer(usersService UsersService, profilesService ProfilesService, jwtService auth.JwtService, tokenRevocationService auth.TokenRevocationService) *UsersGrpcServer {
+	return &UsersGrpcServer{
+		UsersService:           usersService,
+		ProfilesService:        profilesService,
+		JwtService:             jwtService,
+		TokenRevocationService: tokenRevocationService,
+	}
+}
+
+func (s *UsersGrpcServer) GetUser(ctx context.Context, request *usersv1.GetUserRequest) (*usersv1.GetUserResponse, error) {
+	var user *User
+	var err error
+
+	switch lookup := request.Lookup.(type) {
+	case *usersv1.GetUserRequest_Id:
+		if !isValidUserId(lookup.Id) {
+			return nil, status.Error(codes.InvalidArgument, "Invalid id")
+		}
+		user, err = s.UsersService.GetUserById(ctx, lookup.Id)
+	case *usersv1.GetUserRequest_Username:
+		user, err = s.UsersService.GetUserByUsername(ctx, lookup.Username)
+	default:
+		return nil, status.Error(codes.InvalidArgument, "Either id or username must be set")
+	}
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting User %s", request.String())
+		return nil, toGrpcError(err)
+	}
+
+	return &usersv1.GetUserResponse{
+		User: newGrpcUser(*user),
+	}, nil
+}
+
+func (s *UsersGrpcServer) BatchGetUsers(ctx context.Context, request *usersv1.BatchGetUsersRequest) (*usersv1.BatchGetUsersResponse, error) {
+	usersBatch, err := s.UsersService.BatchGetUsers(ctx, request.Ids, request.Usernames)
+	if err != nil {
+		log.Error().Err(err).Msg("Error batch getting Users")
+		return nil, toGrpcError(err)
+	}
+
+	grpcUsers := []*usersv1.User{}
+	for _, user := range usersBatch.Users {
+		grpcUsers = append(grpcUsers, newGrpcUser(user))
+	}
+
+	return &usersv1.BatchGetUsersResponse{
+		Users:            grpcUsers,
+		MissingIds:       usersBatch.MissingIds,
+		MissingUsernames: usersBatch.MissingUsernames,
+	}, nil
+}
+
+func (s *UsersGrpcServer) ValidateToken(ctx context.Context, request *usersv1.ValidateTokenRequest) (*usersv1.ValidateTokenResponse, error) {
+	claims, err := s.JwtService.GetClaims(request.Token)
+	if err != nil {
+		log.Error().Err(err).Msg("Error getting JWT Token claims")
+		return nil, status.Error(codes.Unauthenticated, "Invalid token")
+	}
+
+	isRevoked, err := s.TokenRevocationService.IsRevoked(ctx, claims)
+	if err != nil {
+		log.Error().Err(err).Msg("Error checking whether the JWT Token is revoked")
+		return nil, toGrpcError(err)
+	}
+	if isRevoked {
+		return nil, status.Error(codes.Unauthenticated, "Token has been revoked")
+	}
+
+	var user *User
+	if claims.IsLegacySubject() {
+		user, err = s.UsersService.GetUserByUsername(ctx, claims.Subject)
+	} else {
+		user, err = s.UsersService.GetUserById(ctx, claims.Subject)
+	}
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting User %s", claims.Subject)
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			return nil, status.Error(codes.Unauthenticated, "User not found")
+		}
+		return nil, toGrpcError(err)
+	}
+
+	return &usersv1.ValidateTokenResponse{
+		User:      newGrpcUser(*user),
+		TokenId:   claims.Id,
+		ExpiresAt: timestamppb.New(time.Unix(claims.ExpiresAt, 0)),
+	}, nil
+}
+
+func (s *UsersGrpcServer) IsFollowing(ctx context.Context, request *usersv1.IsFollowingRequest) (*usersv1.IsFollowingResponse, error) {
+	if len(request.FollowerId) == 0 || len(request.FolloweeId) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Both follower_id and followee_id must be set")
+	}
+
+	following, err := s.ProfilesService.IsFollowing(ctx, request.FollowerId, request.FolloweeId)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error checking whether User %s follows User %s", request.FollowerId, request.FolloweeId)
+		return nil, toGrpcError(err)
+	}
+
+	return &usersv1.IsFollowingResponse{
+		Following: following,
+	}, nil
+}
+
+func newGrpcUser(user User) *usersv1.User {
+	return &usersv1.User{
+		Id:       user.Id,
+		Username: user.Username,
+		Email:    user.Email,
+		Bio:      user.Bio,
+		Image:    user.Image,
+	}
+}
+
+func toGrpcError(err error) error {
+	switch err.(type) {
+	case *custom_errors.NotFoundError:
+		return status.Error(codes.NotFound, err.Error())
+	case *custom_errors.InvalidArgumentError:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case *custom_errors.AlreadyExistsError:
+		return status.Error(codes.AlreadyExists, err.Error())
+	case *custom_errors.UnauthenticatedError:
+		return status.Error(codes.Unauthenticated, err.Error())
+	default:
+		return status.Error(codes.Internal, "Internal error")
+	}
+}
@@ -1,24 +1,41 @@
 package users
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/auth"
 	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/mail"
 	"github.com/rs/zerolog/log"
 )
 
 type UsersHandlers struct {
-	UsersService UsersService
-	JwtService   auth.JwtService
+	UsersService             UsersService
+	JwtService               auth.JwtService
+	RefreshTokenService      auth.RefreshTokenService
+	TokenRevocationService   auth.TokenRevocationService
+	EmailVerificationService EmailVerificationService
+	LoginThrottleService     auth.LoginThrottleService
+	TwoFactorService         TwoFactorService
 }
 
-func NewUsersHandlers(usersService UsersService, jwtService auth.JwtService) UsersHandlers {
+func NewUsersHandlers(usersService UsersService, jwtService auth.JwtService, refreshTokenService auth.RefreshTokenService, tokenRevocationService auth.TokenRevocationService, emailVerificationService EmailVerificationService, loginThrottleService auth.LoginThrottleService, twoFactorService TwoFactorService) UsersHandlers {
 	return UsersHandlers{
-		UsersService: usersService,
-		JwtService:   jwtService,
+		UsersService:             usersService,
+		JwtService:               jwtService,
+		RefreshTokenService:      refreshTokenService,
+		TokenRevocationService:   tokenRevocationService,
+		EmailVerificationService: emailVerificationService,
+		LoginThrottleService:     loginThrottleService,
+		TwoFactorService:         twoFactorService,
 	}
 }
 
@@ -27,21 +44,25 @@ type userResponse struct {
 }
 
 type userResponseUser struct {
-	Email    string  `json:"email"`
-	Token    string  `json:"token"`
-	Username string  `json:"username"`
-	Bio      *string `json:"bio"`
-	Image    *string `json:"image"`
+	Email         string  `json:"email"`
+	EmailVerified bool    `json:"emailVerified"`
+	Token         string  `json:"token"`
+	RefreshToken  *string `json:"refreshToken,omitempty"`
+	Username      string  `json:"username"`
+	Bio           *string `json:"bio"`
+	Image         *string `json:"image"`
 }
 
-func newUserResponse(email string, token string, username string, bio *string, image *string) userResponse {
+func newUserResponse(email string, emailVerified bool, token string, refreshToken *string, username string, bio *string, image *string) userResponse {
 	return userResponse{
 		User: userResponseUser{
-			Email:    email,
-			Token:    token,
-			Username: username,
-			Bio:      bio,
-			Image:    image,
+			Email:         email,
+			EmailVerified: emailVerified,
+			Token:         token,
+			RefreshToken:  refreshToken,
+			Username:      username,
+			Bio:           bio,
+			Image:         image,
 		},
 	}
 }
@@ -68,6 +89,48 @@ func newGetUserResponse(email string, username string, bio *string, image *strin
 	}
 }
 
+type userRolesResponse struct {
+	User userRolesResponseUser `json:"user"`
+}
+
+type userRolesResponseUser struct {
+	Username string   `json:"username"`
+	Roles    []string `json:"roles"`
+}
+
+type batchGetUsersResponse struct {
+	Users            []batchGetUsersResponseUser `json:"users"`
+	MissingIds       []string                    `json:"missingIds"`
+	MissingUsernames []string                    `json:"missingUsernames"`
+}
+
+// batchGetUsersResponseUser is the public profile of a user, without the email, as anyone can
+// batch get users.
+type batchGetUsersResponseUser struct {
+	Id       string  `json:"id"`
+	Username string  `json:"username"`
+	Bio      *string `json:"bio"`
+	Image    *string `json:"image"`
+}
+
+func newBatchGetUsersResponse(usersBatch UsersBatch) batchGetUsersResponse {
+	users := []batchGetUsersResponseUser{}
+	for _, user := range usersBatch.Users {
+		users = append(users, batchGetUsersResponseUser{
+			Id:       user.Id,
+			Username: user.Username,
+			Bio:      user.Bio,
+			Image:    user.Image,
+		})
+	}
+
+	return batchGetUsersResponse{
+		Users:            users,
+		MissingIds:       usersBatch.MissingIds,
+		MissingUsernames: usersBatch.MissingUsernames,
+	}
+}
+
 type errorResponse struct {
 	Errors errorResponseErrors `json:"errors"`
 }
@@ -123,14 +186,23 @@ func (h *UsersHandlers) RegisterUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.JwtService.GenerateToken(user.Username)
+	h.sendVerificationEmail(r, *user)
+
+	token, err := h.JwtService.GenerateToken(user.Id, user.Username, user.GetRoles())
 	if err != nil {
 		log.Error().Err(err).Msgf("Error generating Token for User %s, email %s", request.User.Username, request.User.Email)
 		internalServerError(w, r, err)
 		return
 	}
 
-	responseBody := newUserResponse(user.Email, *token, user.Username, user.Bio, user.Image)
+	refreshToken, err := h.RefreshTokenService.IssueToken(r.Context(), user.Id)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error issuing refresh token for User %s, email %s", request.User.Username, request.User.Email)
+		internalServerError(w, r, err)
+		return
+	}
+
+	responseBody := newUserResponse(user.Email, user.EmailVerified, *token, refreshToken, user.Username, user.Bio, user.Image)
 
 	response, err := json.Marshal(responseBody)
 	if err != nil {
@@ -159,6 +231,21 @@ func (h *UsersHandlers) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	account := canonicalKey(request.User.Email)
+	ip := auth.ClientIp(r)
+
+	retryAfter, err := h.LoginThrottleService.Attempt(r.Context(), account, ip)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error throttling login for email %s from %s", request.User.Email, ip)
+		internalServerError(w, r, err)
+		return
+	}
+	if retryAfter > 0 {
+		log.Warn().Msgf("Throttling login for email %s from %s for %s", request.User.Email, ip, retryAfter)
+		tooManyRequests(w, r, retryAfter, fmt.Errorf("Too many failed login attempts"))
+		return
+	}
+
 	IsCorrectPassword, err := h.UsersService.IsCorrectPassword(r.Context(), request.User.Email, request.User.Password)
 	if err != nil || !IsCorrectPassword {
 		log.Error().Err(err).Msgf("Error verifying password for email %s", request.User.Email)
@@ -166,6 +253,13 @@ func (h *UsersHandlers) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = h.LoginThrottleService.Succeeded(r.Context(), account, ip)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error resetting failed logins for email %s from %s", request.User.Email, ip)
+		internalServerError(w, r, err)
+		return
+	}
+
 	user, err := h.UsersService.GetUserByEmail(r.Context(), request.User.Email)
 	if err != nil {
 		log.Error().Err(err).Msgf("Error getting User by email %s", request.User.Email)
@@ -173,14 +267,33 @@ func (h *UsersHandlers) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.JwtService.GenerateToken(user.Username)
+	twoFactorEnabled, err := h.TwoFactorService.IsEnabled(r.Context(), user.Id)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error checking two-factor authentication for email %s", request.User.Email)
+		internalServerError(w, r, err)
+		return
+	}
+
+	if twoFactorEnabled {
+		twoFactorChallenge(w, r, &h.TwoFactorService, *user)
+		return
+	}
+
+	token, err := h.JwtService.GenerateToken(user.Id, user.Username, user.GetRoles())
 	if err != nil {
 		log.Error().Err(err).Msgf("Error generating token for email %s", request.User.Email)
 		internalServerError(w, r, err)
 		return
 	}
 
-	responseBody := newUserResponse(user.Email, *token, user.Username, user.Bio, user.Image)
+	refreshToken, err := h.RefreshTokenService.IssueToken(r.Context(), user.Id)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error issuing refresh token for email %s", request.User.Email)
+		internalServerError(w, r, err)
+		return
+	}
+
+	responseBody := newUserResponse(user.Email, user.EmailVerified, *token, refreshToken, user.Username, user.Bio, user.Image)
 
 	response, err := json.Marshal(responseBody)
 	if err != nil {
@@ -195,8 +308,9 @@ func (h *UsersHandlers) Login(w http.ResponseWriter, r *http.Request) {
 
 func (h *UsersHandlers) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
 	username := r.Context().Value(auth.UsernameContextKey).(string)
+	token := r.Context().Value(auth.TokenContextKey).(string)
 
-	user, err := h.UsersService.GetUserByUsername(r.Context(), username)
+	user, err := GetAuthenticatedUser(r, &h.UsersService)
 	if err != nil {
 		log.Error().Err(err).Msgf("Error getting current User %s", username)
 		if _, ok := err.(*custom_errors.NotFoundError); ok {
@@ -208,14 +322,7 @@ func (h *UsersHandlers) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.JwtService.GenerateToken(user.Username)
-	if err != nil {
-		log.Error().Err(err).Msgf("Error generating token for User %s", username)
-		internalServerError(w, r, err)
-		return
-	}
-
-	responseBody := newUserResponse(user.Email, *token, user.Username, user.Bio, user.Image)
+	responseBody := newUserResponse(user.Email, user.EmailVerified, token, nil, user.Username, user.Bio, user.Image)
 
 	response, err := json.Marshal(responseBody)
 	if err != nil {
@@ -256,10 +363,46 @@ func (h *UsersHandlers) GetUserByUsername(w http.ResponseWriter, r *http.Request
 	w.Write(response)
 }
 
+func (h *UsersHandlers) BatchGetUsers(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Ids       []string `json:"ids"`
+		Usernames []string `json:"usernames"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error decoding request")
+		unprocessableEntity(w, r, []error{err})
+		return
+	}
+
+	usersBatch, err := h.UsersService.BatchGetUsers(r.Context(), request.Ids, request.Usernames)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error batch getting %d Users", len(request.Ids)+len(request.Usernames))
+		if _, ok := err.(*custom_errors.InvalidArgumentError); ok {
+			unprocessableEntity(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	response, err := json.Marshal(newBatchGetUsersResponse(*usersBatch))
+	if err != nil {
+		log.Error().Err(err).Msgf("Error marshalling response body for %d Users", len(usersBatch.Users))
+		internalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.Write(response)
+}
+
 func (h *UsersHandlers) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	username := r.Context().Value(auth.UsernameContextKey).(string)
 
-	user, err := h.UsersService.GetUserByUsername(r.Context(), username)
+	user, err := GetAuthenticatedUser(r, &h.UsersService)
 	if err != nil {
 		log.Error().Err(err).Msgf("Error getting User %s", username)
 
@@ -289,6 +432,8 @@ func (h *UsersHandlers) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	previousEmail := user.Email
+
 	userUpdate := UserUpdate{
 		Username: request.User.Username,
 		Email:    request.User.Email,
@@ -297,7 +442,7 @@ func (h *UsersHandlers) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		Image:    request.User.Image,
 	}
 
-	user, err = h.UsersService.UpdateUserByUsername(r.Context(), username, userUpdate)
+	user, err = h.UsersService.UpdateUserById(r.Context(), user.Id, userUpdate)
 	if err != nil {
 		log.Error().Err(err).Msgf("Error updating User %s", username)
 
@@ -315,16 +460,252 @@ func (h *UsersHandlers) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.JwtService.GenerateToken(user.Username)
+	if canonicalKey(user.Email) != canonicalKey(previousEmail) {
+		h.sendVerificationEmail(r, *user)
+	}
+
+	var refreshToken *string
+	if userUpdate.Password != nil {
+		err = h.revokeAllTokens(r, user.Id)
+		if err != nil {
+			log.Error().Err(err).Msgf("Error revoking the tokens of User %s", username)
+			internalServerError(w, r, err)
+			return
+		}
+
+		refreshToken, err = h.RefreshTokenService.IssueToken(r.Context(), user.Id)
+		if err != nil {
+			log.Error().Err(err).Msgf("Error issuing refresh token for User %s", username)
+			internalServerError(w, r, err)
+			return
+		}
+	}
+
+	token, err := h.JwtService.GenerateToken(user.Id, user.Username, user.GetRoles())
 	if err != nil {
 		log.Error().Err(err).Msgf("Error generating token for User %s", username)
 		internalServerError(w, r, err)
 		return
 	}
 
-	responseBody := newUserResponse(user.Email, *token, user.Username, user.Bio, user.Image)
+	responseBody := newUserResponse(user.Email, user.EmailVerified, *token, refreshToken, user.Username, user.Bio, user.Image)
+
+	response, err := json.Marshal(responseBody)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error marshalling response body for User %s", username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.Write(response)
+}
+
+func (h *UsersHandlers) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error decoding request")
+		unprocessableEntity(w, r, []error{err})
+		return
+	}
+
+	refreshToken, newRefreshToken, err := h.RefreshTokenService.RotateToken(r.Context(), request.RefreshToken)
+	if err != nil {
+		log.Error().Err(err).Msg("Error rotating refresh token")
+		if _, ok := err.(*custom_errors.UnauthenticatedError); ok {
+			unauthorized(w, r)
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	user, err := h.UsersService.GetUserById(r.Context(), refreshToken.UserId)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting User %s", refreshToken.UserId)
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			unauthorized(w, r)
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	token, err := h.JwtService.GenerateToken(user.Id, user.Username, user.GetRoles())
+	if err != nil {
+		log.Error().Err(err).Msgf("Error generating token for User %s", user.Username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	responseBody := newUserResponse(user.Email, user.EmailVerified, *token, newRefreshToken, user.Username, user.Bio, user.Image)
 
 	response, err := json.Marshal(responseBody)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error marshalling response body for User %s", user.Username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.Write(response)
+}
+
+func (h *UsersHandlers) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Token string `json:"token"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error decoding request")
+		unprocessableEntity(w, r, []error{err})
+		return
+	}
+
+	_, err = h.EmailVerificationService.VerifyEmail(r.Context(), request.Token)
+	if err != nil {
+		log.Error().Err(err).Msg("Error verifying email")
+		if _, ok := err.(*custom_errors.InvalidArgumentError); ok {
+			unprocessableEntity(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UsersHandlers) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	username := r.Context().Value(auth.UsernameContextKey).(string)
+
+	user, err := GetAuthenticatedUser(r, &h.UsersService)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting User %s", username)
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			notFound(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	if !user.EmailVerified {
+		h.sendVerificationEmail(r, *user)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *UsersHandlers) Logout(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(auth.ClaimsContextKey).(*auth.Claims)
+
+	var request struct {
+		RefreshToken *string `json:"refreshToken"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil && err != io.EOF {
+		log.Error().Err(err).Msgf("Error decoding request")
+		unprocessableEntity(w, r, []error{err})
+		return
+	}
+
+	err = h.TokenRevocationService.RevokeToken(r.Context(), claims)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error revoking token of User %s", claims.Subject)
+		internalServerError(w, r, err)
+		return
+	}
+
+	if request.RefreshToken != nil {
+		err = h.RefreshTokenService.RevokeToken(r.Context(), *request.RefreshToken)
+		if err != nil {
+			log.Error().Err(err).Msgf("Error revoking refresh token of User %s", claims.Subject)
+			internalServerError(w, r, err)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UsersHandlers) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	username := r.Context().Value(auth.UsernameContextKey).(string)
+
+	user, err := GetAuthenticatedUser(r, &h.UsersService)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting User %s", username)
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			notFound(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	err = h.revokeAllTokens(r, user.Id)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error revoking the tokens of User %s", username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UpdateUserRoles replaces the roles of the user of the URL. The user's access tokens are revoked,
+// so the roles they carry are updated on the next login or refresh.
+func (h *UsersHandlers) UpdateUserRoles(w http.ResponseWriter, r *http.Request) {
+	adminId := r.Context().Value(auth.UserIdContextKey).(string)
+	username := chi.URLParam(r, "username")
+
+	var request struct {
+		Roles []string `json:"roles"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error decoding request")
+		unprocessableEntity(w, r, []error{err})
+		return
+	}
+
+	user, err := h.UsersService.UpdateUserRoles(r.Context(), adminId, username, request.Roles)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error updating the roles of User %s", username)
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			notFound(w, r, []error{err})
+			return
+		}
+
+		if _, ok := err.(*custom_errors.InvalidArgumentError); ok {
+			unprocessableEntity(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	err = h.TokenRevocationService.RevokeSubject(r.Context(), user.Id)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error revoking the tokens of User %s", username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	response, err := json.Marshal(userRolesResponse{User: userRolesResponseUser{Username: user.Username, Roles: user.GetRoles()}})
 	if err != nil {
 		log.Error().Err(err).Msgf("Error marshalling response body for User %s", username)
 		internalServerError(w, r, err)
@@ -335,6 +716,59 @@ func (h *UsersHandlers) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	w.Write(response)
 }
 
+// sendVerificationEmail mails the verification token in the background, so that a failing mail
+// server doesn't fail the registration or update. Users can ask for a new one with
+// ResendVerificationEmail. The mail is localized after the Accept-Language header.
+func (h *UsersHandlers) sendVerificationEmail(r *http.Request, user User) {
+	locales := mail.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+
+	go func() {
+		err := h.EmailVerificationService.SendVerificationEmail(context.Background(), user, locales...)
+		if err != nil {
+			log.Error().Err(err).Msgf("Error sending verification email to User %s", user.Username)
+		}
+	}()
+}
+
+// GetAuthenticatedUser returns the user of the request, which must have been authenticated. Users
+// are resolved by id or, for tokens issued while subjects were usernames, by username. The
+// fallback can be removed once all such tokens expired.
+func GetAuthenticatedUser(r *http.Request, usersService *UsersService) (*User, error) {
+	userId := r.Context().Value(auth.UserIdContextKey).(string)
+	if len(userId) == 0 {
+		username := r.Context().Value(auth.UsernameContextKey).(string)
+		return usersService.GetUserByUsername(r.Context(), username)
+	}
+
+	return usersService.GetUserById(r.Context(), userId)
+}
+
+// revokeAllTokens revokes every access token issued for the user and every refresh token of the
+// user. The presented token is also revoked by id, as the subject's revocation only covers tokens
+// issued in earlier seconds.
+func (h *UsersHandlers) revokeAllTokens(r *http.Request, userId string) error {
+	claims := r.Context().Value(auth.ClaimsContextKey).(*auth.Claims)
+
+	err := h.TokenRevocationService.RevokeToken(r.Context(), claims)
+	if err != nil {
+		return err
+	}
+
+	err = h.TokenRevocationService.RevokeSubject(r.Context(), userId)
+	if err != nil {
+		return err
+	}
+
+	if claims.IsLegacySubject() {
+		err = h.TokenRevocationService.RevokeLegacySubject(r.Context(), claims.Subject)
+		if err != nil {
+			return err
+		}
+	}
+
+	return h.RefreshTokenService.RevokeAllForUser(r.Context(), userId)
+}
+
 func unauthorized(w http.ResponseWriter, r *http.Request) {
 	http.Error(w, "Unauthorized", http.StatusUnauthorized)
 }
@@ -361,6 +795,19 @@ func unprocessableEntity(w http.ResponseWriter, r *http.Request, errors []error)
 	w.Write(response)
 }
 
+// tooManyRequests tells the client to retry after whole seconds, rounded up.
+func tooManyRequests(w http.ResponseWriter, r *http.Request, retryAfter time.Duration, err error) {
+	response, marshalErr := json.Marshal(newErrorResponse([]error{err}))
+	if marshalErr != nil {
+		internalServerError(w, r, marshalErr)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	w.WriteHeader(http.StatusTooManyRequests)
+	w.Write(response)
+}
+
 func internalServerError(w http.ResponseWriter, r *http.Request, err error) {
 	http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 }
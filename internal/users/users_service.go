@@ -6,42 +6,24 @@ import (
 	"fmt"
 	"strings"
 
-	"cloud.google.com/go/firestore"
 	"github.com/go-playground/validator/v10"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/auth"
 	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
 	"golang.org/x/crypto/bcrypt"
-	"google.golang.org/api/iterator"
 )
 
 type UsersService struct {
-	Validate  validator.Validate
-	Firestore firestore.Client
+	Validate       validator.Validate
+	UserRepository UserRepository
+	// AdminEmails are the emails of the users granted the admin role once they verify them.
+	AdminEmails []string
 }
 
-func NewUsersService(validate validator.Validate, firestore firestore.Client) UsersService {
+func NewUsersService(validate validator.Validate, userRepository UserRepository, adminEmails []string) UsersService {
 	return UsersService{
-		Validate:  validate,
-		Firestore: firestore,
-	}
-}
-
-const usersCollectionName = "users"
-
-type userDocData struct {
-	Username     string  `firestore:"username"`
-	Email        string  `firestore:"email"`
-	PasswordHash string  `firestore:"password_hash"`
-	Bio          *string `firestore:"bio"`
-	Image        *string `firestore:"image"`
-}
-
-func newUserDocData(username string, email string, passwordHash string, bio *string, image *string) userDocData {
-	return userDocData{
-		Username:     username,
-		Email:        email,
-		PasswordHash: passwordHash,
-		Bio:          bio,
-		Image:        image,
+		Validate:       validate,
+		UserRepository: userRepository,
+		AdminEmails:    adminEmails,
 	}
 }
 
@@ -60,92 +42,96 @@ func (s *UsersService) RegisterUser(ctx context.Context, username string, email
 		return nil, &custom_errors.InvalidArgumentError{Message: err.Error()}
 	}
 
-	existingUser, err := s.GetUserByUsername(ctx, username)
-	if err != nil {
-		if _, ok := err.(*custom_errors.NotFoundError); !ok {
-			return nil, err
-		}
-	}
-	if existingUser != nil {
-		return nil, &custom_errors.AlreadyExistsError{Message: "User already exists"}
-	}
-
-	existingUser, err = s.GetUserByEmail(ctx, email)
-	if err != nil {
-		if _, ok := err.(*custom_errors.NotFoundError); !ok {
-			return nil, err
-		}
-	}
-	if existingUser != nil {
-		return nil, &custom_errors.AlreadyExistsError{Message: "Email is taken"}
-	}
-
 	passwordHash, err := hashPassword(password)
 	if err != nil {
 		return nil, err
 	}
 
-	userDocRef := s.Firestore.Collection(usersCollectionName).NewDoc()
-	userData := newUserDocData(username, email, *passwordHash, nil, nil)
+	return s.UserRepository.Create(ctx, NewUser("", username, email, false, *passwordHash, nil, nil, []string{auth.RoleUser}))
+}
 
-	_, err = userDocRef.Create(ctx, userData)
-	if err != nil {
-		return nil, err
-	}
+func (s *UsersService) GetUserById(ctx context.Context, id string) (*User, error) {
+	return s.UserRepository.GetById(ctx, id)
+}
+
+func (s *UsersService) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	return s.UserRepository.GetByUsername(ctx, username)
+}
 
-	user := NewUser(userDocRef.ID, userData.Username, userData.Email, userData.PasswordHash, userData.Bio, userData.Image)
+func (s *UsersService) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	return s.UserRepository.GetByEmail(ctx, email)
+}
 
-	return &user, nil
+const MaxBatchGetUsersSize = 100
+
+type UsersBatch struct {
+	Users            []User
+	MissingIds       []string
+	MissingUsernames []string
 }
 
-func (s *UsersService) GetUserByUsername(ctx context.Context, username string) (*User, error) {
-	usersCollection := s.Firestore.Collection(usersCollectionName)
-	query := usersCollection.Where("username", "==", username).Limit(1)
-	userDocs := query.Documents(ctx)
-	defer userDocs.Stop()
-	for {
-		userDocSnapshot, err := userDocs.Next()
-		if err == iterator.Done {
-			return nil, &custom_errors.NotFoundError{Message: "User not found"}
-		} else if err != nil {
-			return nil, err
+// BatchGetUsers returns the users with the given ids or usernames, in the order they were asked
+// for and without duplicates, along with the ids and usernames that weren't found.
+func (s *UsersService) BatchGetUsers(ctx context.Context, ids []string, usernames []string) (*UsersBatch, error) {
+	if len(ids)+len(usernames) > MaxBatchGetUsersSize {
+		return nil, &custom_errors.InvalidArgumentError{Message: fmt.Sprintf("At most %d ids and usernames can be requested at once", MaxBatchGetUsersSize)}
+	}
+
+	usersBatch := UsersBatch{
+		Users:            []User{},
+		MissingIds:       []string{},
+		MissingUsernames: []string{},
+	}
+
+	addedUserIds := map[string]bool{}
+	addUser := func(user User) {
+		if !addedUserIds[user.Id] {
+			addedUserIds[user.Id] = true
+			usersBatch.Users = append(usersBatch.Users, user)
 		}
+	}
 
-		userData := userDocData{}
-		err = userDocSnapshot.DataTo(&userData)
+	if len(ids) > 0 {
+		users, err := s.UserRepository.GetByIds(ctx, ids)
 		if err != nil {
 			return nil, err
 		}
 
-		user := NewUser(userDocSnapshot.Ref.ID, userData.Username, userData.Email, userData.PasswordHash, userData.Bio, userData.Image)
-
-		return &user, nil
-	}
-}
+		usersById := map[string]User{}
+		for _, user := range users {
+			usersById[user.Id] = user
+		}
 
-func (s *UsersService) GetUserByEmail(ctx context.Context, email string) (*User, error) {
-	usersCollection := s.Firestore.Collection(usersCollectionName)
-	query := usersCollection.Where("email", "==", email).Limit(1)
-	userDocs := query.Documents(ctx)
-	defer userDocs.Stop()
-	for {
-		userDocSnapshot, err := userDocs.Next()
-		if err == iterator.Done {
-			return nil, &custom_errors.NotFoundError{Message: "User not found"}
-		} else if err != nil {
-			return nil, err
+		for _, id := range ids {
+			if user, ok := usersById[id]; ok {
+				addUser(user)
+			} else if !contains(usersBatch.MissingIds, id) {
+				usersBatch.MissingIds = append(usersBatch.MissingIds, id)
+			}
 		}
+	}
 
-		userData := userDocData{}
-		err = userDocSnapshot.DataTo(&userData)
+	if len(usernames) > 0 {
+		users, err := s.UserRepository.GetByUsernames(ctx, usernames)
 		if err != nil {
 			return nil, err
 		}
 
-		user := NewUser(userDocSnapshot.Ref.ID, userData.Username, userData.Email, userData.PasswordHash, userData.Bio, userData.Image)
+		usersByUsernameKey := map[string]User{}
+		for _, user := range users {
+			usersByUsernameKey[canonicalKey(user.Username)] = user
+		}
 
-		return &user, nil
+		for _, username := range usernames {
+			if user, ok := usersByUsernameKey[canonicalKey(username)]; ok {
+				addUser(user)
+			} else if !contains(usersBatch.MissingUsernames, username) {
+				usersBatch.MissingUsernames = append(usersBatch.MissingUsernames, username)
+			}
+		}
 	}
+
+	return &usersBatch, nil
 }
 
 type UserUpdate struct {
@@ -156,22 +142,13 @@ type UserUpdate struct {
 	Image    *string
 }
 
-func (s *UsersService) UpdateUserByUsername(ctx context.Context, username string, userUpdate UserUpdate) (*User, error) {
-	user, err := s.GetUserByUsername(ctx, username)
+func (s *UsersService) UpdateUserById(ctx context.Context, id string, userUpdate UserUpdate) (*User, error) {
+	user, err := s.GetUserById(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	if userUpdate.Username != nil && *userUpdate.Username != user.Username {
-		existingUser, err := s.GetUserByUsername(ctx, *userUpdate.Username)
-		if err != nil {
-			if _, ok := err.(*custom_errors.NotFoundError); !ok {
-				return nil, err
-			}
-		}
-		if existingUser != nil {
-			return nil, &custom_errors.AlreadyExistsError{Message: "User already exists"}
-		}
+	if userUpdate.Username != nil {
 		user.Username = *userUpdate.Username
 	}
 
@@ -180,14 +157,8 @@ func (s *UsersService) UpdateUserByUsername(ctx context.Context, username string
 		if err != nil {
 			return nil, &custom_errors.InvalidArgumentError{Message: "Invalid email"}
 		}
-		existingUser, err := s.GetUserByEmail(ctx, *userUpdate.Email)
-		if err != nil {
-			if _, ok := err.(*custom_errors.NotFoundError); !ok {
-				return nil, err
-			}
-		}
-		if existingUser != nil {
-			return nil, &custom_errors.AlreadyExistsError{Message: "Email is taken"}
+		if canonicalKey(*userUpdate.Email) != canonicalKey(user.Email) {
+			user.EmailVerified = false
 		}
 		user.Email = *userUpdate.Email
 	}
@@ -216,13 +187,94 @@ func (s *UsersService) UpdateUserByUsername(ctx context.Context, username string
 		user.Image = userUpdate.Image
 	}
 
-	userDocRef := s.Firestore.Doc(fmt.Sprintf("%s/%s", usersCollectionName, user.Id))
-	userDocData := newUserDocData(user.Username, user.Email, user.PasswordHash, user.Bio, user.Image)
-	_, err = userDocRef.Set(ctx, userDocData)
+	return s.UserRepository.Update(ctx, *user)
+}
+
+// MarkEmailVerified marks the email of the user as verified, provided it is still the given one.
+func (s *UsersService) MarkEmailVerified(ctx context.Context, id string, email string) (*User, error) {
+	user, err := s.GetUserById(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	return user, nil
+
+	if canonicalKey(user.Email) != canonicalKey(email) {
+		return nil, &custom_errors.InvalidArgumentError{Message: "Email has changed"}
+	}
+
+	if user.EmailVerified {
+		return user, nil
+	}
+
+	user.EmailVerified = true
+
+	if s.isAdminEmail(user.Email) && !user.HasRole(auth.RoleAdmin) {
+		user.Roles = append(user.GetRoles(), auth.RoleAdmin)
+	}
+
+	return s.UserRepository.Update(ctx, *user)
+}
+
+// GrantAdminRoles grants the admin role to the users who already verified an admin email.
+func (s *UsersService) GrantAdminRoles(ctx context.Context) error {
+	for _, email := range s.AdminEmails {
+		user, err := s.GetUserByEmail(ctx, email)
+		if err != nil {
+			if _, ok := err.(*custom_errors.NotFoundError); ok {
+				continue
+			}
+			return err
+		}
+
+		if !user.EmailVerified || user.HasRole(auth.RoleAdmin) {
+			continue
+		}
+
+		user.Roles = append(user.GetRoles(), auth.RoleAdmin)
+
+		_, err = s.UserRepository.Update(ctx, *user)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdateUserRoles replaces the roles of the user with the given ones, which always include the
+// user role. Admins can't revoke their own admin role, so there's always one left.
+func (s *UsersService) UpdateUserRoles(ctx context.Context, adminId string, username string, roles []string) (*User, error) {
+	user, err := s.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, role := range roles {
+		if !auth.IsRole(role) {
+			return nil, &custom_errors.InvalidArgumentError{Message: fmt.Sprintf("Unknown role %s", role)}
+		}
+	}
+
+	if user.Id == adminId && !contains(roles, auth.RoleAdmin) {
+		return nil, &custom_errors.InvalidArgumentError{Message: "Admins can't revoke their own admin role"}
+	}
+
+	user.Roles = []string{}
+	for _, role := range auth.Roles {
+		if role == auth.RoleUser || contains(roles, role) {
+			user.Roles = append(user.Roles, role)
+		}
+	}
+
+	return s.UserRepository.Update(ctx, *user)
+}
+
+func (s *UsersService) isAdminEmail(email string) bool {
+	for _, adminEmail := range s.AdminEmails {
+		if canonicalKey(adminEmail) == canonicalKey(email) {
+			return true
+		}
+	}
+	return false
 }
 
 func (s *UsersService) IsCorrectPassword(ctx context.Context, email string, password string) (bool, error) {
@@ -258,3 +310,12 @@ func hashPassword(password string) (*string, error) {
 	passwordHash := string(passwordHashBytes)
 	return &passwordHash, nil
 }
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
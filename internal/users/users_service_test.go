@@ -0,0 +1,195 @@
+package users
+
+import (
+	"context"
+	"testing"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/validator"
+)
+
+func newTestUsersService() UsersService {
+	return NewUsersService(*validator.InitValidator(), NewInMemoryUserRepository(), nil)
+}
+
+// createTestUser stores the user directly, skipping the slow password hashing of RegisterUser.
+func createTestUser(t *testing.T, s UsersService, username string, email string) *User {
+	user, err := s.UserRepository.Create(context.Background(), NewUser("", username, email, false, "hash", nil, nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return user
+}
+
+func TestGivenUsernameIsTakenWhenRegisterUserShouldReturnAlreadyExists(t *testing.T) {
+	s := newTestUsersService()
+	createTestUser(t, s, "Alice", "alice@example.com")
+
+	_, err := s.RegisterUser(context.Background(), " alice ", "other@example.com", "password123")
+
+	if _, ok := err.(*custom_errors.AlreadyExistsError); !ok {
+		t.Fatalf("got %v, want an AlreadyExistsError", err)
+	}
+}
+
+func TestGivenEmailIsTakenWhenRegisterUserShouldReturnAlreadyExists(t *testing.T) {
+	s := newTestUsersService()
+	createTestUser(t, s, "alice", "alice@example.com")
+
+	_, err := s.RegisterUser(context.Background(), "bob", "ALICE@example.com", "password123")
+
+	if _, ok := err.(*custom_errors.AlreadyExistsError); !ok {
+		t.Fatalf("got %v, want an AlreadyExistsError", err)
+	}
+}
+
+func TestWhenRegisterUserShouldBeFoundByCanonicalUsernameAndEmail(t *testing.T) {
+	s := newTestUsersService()
+
+	user, err := s.RegisterUser(context.Background(), "Alice", "Alice@Example.com", "password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	foundUser, err := s.GetUserByUsername(context.Background(), "ALICE")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if foundUser.Id != user.Id {
+		t.Fatalf("got %s, want %s", foundUser.Id, user.Id)
+	}
+
+	foundUser, err = s.GetUserByEmail(context.Background(), "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if foundUser.Id != user.Id {
+		t.Fatalf("got %s, want %s", foundUser.Id, user.Id)
+	}
+
+	if foundUser.Username != "Alice" {
+		t.Fatalf("got %s, want %s", foundUser.Username, "Alice")
+	}
+}
+
+func TestGivenUsernameIsTakenWhenUpdateUserShouldReturnAlreadyExists(t *testing.T) {
+	s := newTestUsersService()
+	createTestUser(t, s, "alice", "alice@example.com")
+	bob := createTestUser(t, s, "bob", "bob@example.com")
+
+	username := "ALICE"
+	_, err := s.UpdateUserById(context.Background(), bob.Id, UserUpdate{Username: &username})
+
+	if _, ok := err.(*custom_errors.AlreadyExistsError); !ok {
+		t.Fatalf("got %v, want an AlreadyExistsError", err)
+	}
+
+	storedBob, err := s.GetUserById(context.Background(), bob.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if storedBob.Username != "bob" {
+		t.Fatalf("got %s, want %s", storedBob.Username, "bob")
+	}
+}
+
+func TestGivenEmailIsTakenWhenUpdateUserShouldReturnAlreadyExists(t *testing.T) {
+	s := newTestUsersService()
+	createTestUser(t, s, "alice", "alice@example.com")
+	bob := createTestUser(t, s, "bob", "bob@example.com")
+
+	email := "Alice@Example.com"
+	_, err := s.UpdateUserById(context.Background(), bob.Id, UserUpdate{Email: &email})
+
+	if _, ok := err.(*custom_errors.AlreadyExistsError); !ok {
+		t.Fatalf("got %v, want an AlreadyExistsError", err)
+	}
+}
+
+func TestWhenUpdateUserWithOwnUsernameInOtherCaseShouldUpdateIt(t *testing.T) {
+	s := newTestUsersService()
+	alice := createTestUser(t, s, "alice", "alice@example.com")
+
+	username := "Alice"
+	user, err := s.UpdateUserById(context.Background(), alice.Id, UserUpdate{Username: &username})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.Username != "Alice" {
+		t.Fatalf("got %s, want %s", user.Username, "Alice")
+	}
+}
+
+func TestGivenUserDoesNotExistWhenGetUserShouldReturnNotFound(t *testing.T) {
+	s := newTestUsersService()
+
+	_, err := s.GetUserById(context.Background(), "unknown")
+	if _, ok := err.(*custom_errors.NotFoundError); !ok {
+		t.Fatalf("got %v, want a NotFoundError", err)
+	}
+
+	_, err = s.GetUserByUsername(context.Background(), "unknown")
+	if _, ok := err.(*custom_errors.NotFoundError); !ok {
+		t.Fatalf("got %v, want a NotFoundError", err)
+	}
+
+	_, err = s.GetUserByEmail(context.Background(), "unknown@example.com")
+	if _, ok := err.(*custom_errors.NotFoundError); !ok {
+		t.Fatalf("got %v, want a NotFoundError", err)
+	}
+}
+
+func TestGivenUserDoesNotExistWhenUpdateUserShouldReturnNotFound(t *testing.T) {
+	s := newTestUsersService()
+
+	bio := "Bio"
+	_, err := s.UpdateUserById(context.Background(), "unknown", UserUpdate{Bio: &bio})
+
+	if _, ok := err.(*custom_errors.NotFoundError); !ok {
+		t.Fatalf("got %v, want a NotFoundError", err)
+	}
+}
+
+func TestGivenUserDoesNotExistWhenRepositoryUpdatesOrDeletesShouldReturnNotFound(t *testing.T) {
+	userRepository := NewInMemoryUserRepository()
+
+	_, err := userRepository.Update(context.Background(), NewUser("unknown", "alice", "alice@example.com", false, "hash", nil, nil, nil))
+	if _, ok := err.(*custom_errors.NotFoundError); !ok {
+		t.Fatalf("got %v, want a NotFoundError", err)
+	}
+
+	err = userRepository.Delete(context.Background(), "unknown")
+	if _, ok := err.(*custom_errors.NotFoundError); !ok {
+		t.Fatalf("got %v, want a NotFoundError", err)
+	}
+}
+
+func TestWhenMutatingReturnedUserShouldNotChangeStoredUser(t *testing.T) {
+	s := newTestUsersService()
+	bio := "Bio"
+	alice, err := s.UserRepository.Create(context.Background(), NewUser("", "alice", "alice@example.com", false, "hash", &bio, nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := s.GetUserById(context.Background(), alice.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	*user.Bio = "Changed"
+
+	storedUser, err := s.GetUserById(context.Background(), alice.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *storedUser.Bio != "Bio" {
+		t.Fatalf("got %s, want %s", *storedUser.Bio, "Bio")
+	}
+}
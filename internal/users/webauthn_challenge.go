@@ -0,0 +1,30 @@
+package users
+
+import "time"
+
+type WebauthnCeremony string
+
+const (
+	WebauthnRegistrationCeremony   WebauthnCeremony = "registration"
+	WebauthnAuthenticationCeremony WebauthnCeremony = "authentication"
+)
+
+// WebauthnChallenge is the server-side state of a WebAuthn ceremony, keyed by the hash of its
+// challenge. UserId is empty for authentications of users who aren't known yet.
+type WebauthnChallenge struct {
+	ChallengeHash string
+	Ceremony      WebauthnCeremony
+	UserId        string
+	CreatedAt     time.Time
+	ExpiresAt     time.Time
+}
+
+func NewWebauthnChallenge(challengeHash string, ceremony WebauthnCeremony, userId string, createdAt time.Time, expiresAt time.Time) WebauthnChallenge {
+	return WebauthnChallenge{
+		ChallengeHash: challengeHash,
+		Ceremony:      ceremony,
+		UserId:        userId,
+		CreatedAt:     createdAt,
+		ExpiresAt:     expiresAt,
+	}
+}
@@ -0,0 +1,10 @@
+package users
+
+import "context"
+
+type WebauthnChallengeRepository interface {
+	Create(ctx context.Context, webauthnChallenge WebauthnChallenge) error
+	// Consume atomically deletes the challenge and returns it, so that every challenge is used
+	// at most once.
+	Consume(ctx context.Context, challengeHash string) (*WebauthnChallenge, error)
+}
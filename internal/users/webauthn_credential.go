@@ -0,0 +1,27 @@
+package users
+
+import "time"
+
+// WebauthnCredential is a passkey registered by a user. Id is the base64url encoded credential
+// id, and PublicKey the COSE encoded public key.
+type WebauthnCredential struct {
+	Id         string
+	UserId     string
+	Name       string
+	PublicKey  []byte
+	SignCount  uint32
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+}
+
+func NewWebauthnCredential(id string, userId string, name string, publicKey []byte, signCount uint32, createdAt time.Time, lastUsedAt *time.Time) WebauthnCredential {
+	return WebauthnCredential{
+		Id:         id,
+		UserId:     userId,
+		Name:       name,
+		PublicKey:  publicKey,
+		SignCount:  signCount,
+		CreatedAt:  createdAt,
+		LastUsedAt: lastUsedAt,
+	}
+}
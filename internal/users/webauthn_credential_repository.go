@@ -0,0 +1,13 @@
+package users
+
+import (
+	"context"
+	"time"
+)
+
+type WebauthnCredentialRepository interface {
+	Create(ctx context.Context, webauthnCredential WebauthnCredential) error
+	GetById(ctx context.Context, userId string, id string) (*WebauthnCredential, error)
+	ListByUserId(ctx context.Context, userId string) ([]WebauthnCredential, error)
+	UpdateSignCount(ctx context.Context, userId string, id string, signCount uint32, lastUsedAt time.Time) error
+}
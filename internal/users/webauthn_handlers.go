@@ -0,0 +1,295 @@
+package users
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/auth"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/webauthn"
+	"github.com/rs/zerolog/log"
+)
+
+type WebauthnHandlers struct {
+	WebauthnService     WebauthnService
+	UsersService        UsersService
+	TwoFactorService    TwoFactorService
+	JwtService          auth.JwtService
+	RefreshTokenService auth.RefreshTokenService
+}
+
+func NewWebauthnHandlers(webauthnService WebauthnService, usersService UsersService, twoFactorService TwoFactorService, jwtService auth.JwtService, refreshTokenService auth.RefreshTokenService) WebauthnHandlers {
+	return WebauthnHandlers{
+		WebauthnService:     webauthnService,
+		UsersService:        usersService,
+		TwoFactorService:    twoFactorService,
+		JwtService:          jwtService,
+		RefreshTokenService: refreshTokenService,
+	}
+}
+
+type creationOptionsResponse struct {
+	PublicKey webauthn.CreationOptions `json:"publicKey"`
+}
+
+type requestOptionsResponse struct {
+	PublicKey webauthn.RequestOptions `json:"publicKey"`
+}
+
+type passkeyResponse struct {
+	Passkey passkeyResponsePasskey `json:"passkey"`
+}
+
+type passkeysResponse struct {
+	Passkeys []passkeyResponsePasskey `json:"passkeys"`
+}
+
+type passkeyResponsePasskey struct {
+	Id         string     `json:"id"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+}
+
+func newPasskeyResponsePasskey(webauthnCredential WebauthnCredential) passkeyResponsePasskey {
+	return passkeyResponsePasskey{
+		Id:         webauthnCredential.Id,
+		Name:       webauthnCredential.Name,
+		CreatedAt:  webauthnCredential.CreatedAt,
+		LastUsedAt: webauthnCredential.LastUsedAt,
+	}
+}
+
+func (h *WebauthnHandlers) BeginRegistration(w http.ResponseWriter, r *http.Request) {
+	username := r.Context().Value(auth.UsernameContextKey).(string)
+
+	user, err := GetAuthenticatedUser(r, &h.UsersService)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting User %s", username)
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			notFound(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	creationOptions, err := h.WebauthnService.BeginRegistration(r.Context(), *user)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error beginning passkey registration for User %s", username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	response, err := json.Marshal(creationOptionsResponse{PublicKey: *creationOptions})
+	if err != nil {
+		log.Error().Err(err).Msgf("Error marshalling response body for User %s", username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.Write(response)
+}
+
+func (h *WebauthnHandlers) FinishRegistration(w http.ResponseWriter, r *http.Request) {
+	username := r.Context().Value(auth.UsernameContextKey).(string)
+
+	var request struct {
+		Name       string                        `json:"name"`
+		Credential webauthn.RegistrationResponse `json:"credential"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error decoding request")
+		unprocessableEntity(w, r, []error{err})
+		return
+	}
+
+	user, err := GetAuthenticatedUser(r, &h.UsersService)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting User %s", username)
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			notFound(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	webauthnCredential, err := h.WebauthnService.FinishRegistration(r.Context(), *user, request.Name, request.Credential)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error finishing passkey registration for User %s", username)
+		if _, ok := err.(*custom_errors.InvalidArgumentError); ok {
+			unprocessableEntity(w, r, []error{err})
+			return
+		}
+
+		if _, ok := err.(*custom_errors.AlreadyExistsError); ok {
+			unprocessableEntity(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	response, err := json.Marshal(passkeyResponse{Passkey: newPasskeyResponsePasskey(*webauthnCredential)})
+	if err != nil {
+		log.Error().Err(err).Msgf("Error marshalling response body for User %s", username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(response)
+}
+
+func (h *WebauthnHandlers) ListPasskeys(w http.ResponseWriter, r *http.Request) {
+	username := r.Context().Value(auth.UsernameContextKey).(string)
+
+	user, err := GetAuthenticatedUser(r, &h.UsersService)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting User %s", username)
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			notFound(w, r, []error{err})
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	webauthnCredentials, err := h.WebauthnService.ListCredentials(r.Context(), user.Id)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error listing the passkeys of User %s", username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	responseBody := passkeysResponse{Passkeys: []passkeyResponsePasskey{}}
+	for _, webauthnCredential := range webauthnCredentials {
+		responseBody.Passkeys = append(responseBody.Passkeys, newPasskeyResponsePasskey(webauthnCredential))
+	}
+
+	response, err := json.Marshal(responseBody)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error marshalling response body for User %s", username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.Write(response)
+}
+
+// BeginLogin takes an optional email, to restrict the ceremony to the user's passkeys for
+// authenticators that don't keep discoverable credentials.
+func (h *WebauthnHandlers) BeginLogin(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Email string `json:"email"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil && err != io.EOF {
+		log.Error().Err(err).Msgf("Error decoding request")
+		unprocessableEntity(w, r, []error{err})
+		return
+	}
+
+	requestOptions, err := h.WebauthnService.BeginAuthentication(r.Context(), request.Email)
+	if err != nil {
+		log.Error().Err(err).Msg("Error beginning passkey login")
+		internalServerError(w, r, err)
+		return
+	}
+
+	response, err := json.Marshal(requestOptionsResponse{PublicKey: *requestOptions})
+	if err != nil {
+		log.Error().Err(err).Msg("Error marshalling response body")
+		internalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.Write(response)
+}
+
+// FinishLogin issues the same tokens as Login. A passkey stands for both factors only when the
+// authenticator verified the user, otherwise users with two-factor authentication enabled are
+// challenged as on Login.
+func (h *WebauthnHandlers) FinishLogin(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Credential webauthn.AuthenticationResponse `json:"credential"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error decoding request")
+		unprocessableEntity(w, r, []error{err})
+		return
+	}
+
+	user, userVerified, err := h.WebauthnService.FinishAuthentication(r.Context(), request.Credential)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error finishing passkey login with credential %s", request.Credential.Id)
+		if _, ok := err.(*custom_errors.InvalidArgumentError); ok {
+			unprocessableEntity(w, r, []error{err})
+			return
+		}
+
+		if _, ok := err.(*custom_errors.UnauthenticatedError); ok {
+			unauthorized(w, r)
+			return
+		}
+
+		internalServerError(w, r, err)
+		return
+	}
+
+	if !userVerified {
+		twoFactorEnabled, err := h.TwoFactorService.IsEnabled(r.Context(), user.Id)
+		if err != nil {
+			log.Error().Err(err).Msgf("Error checking two-factor authentication for User %s", user.Username)
+			internalServerError(w, r, err)
+			return
+		}
+
+		if twoFactorEnabled {
+			twoFactorChallenge(w, r, &h.TwoFactorService, *user)
+			return
+		}
+	}
+
+	token, err := h.JwtService.GenerateToken(user.Id, user.Username, user.GetRoles())
+	if err != nil {
+		log.Error().Err(err).Msgf("Error generating token for User %s", user.Username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	refreshToken, err := h.RefreshTokenService.IssueToken(r.Context(), user.Id)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error issuing refresh token for User %s", user.Username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	responseBody := newUserResponse(user.Email, user.EmailVerified, *token, refreshToken, user.Username, user.Bio, user.Image)
+
+	response, err := json.Marshal(responseBody)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error marshalling response body for User %s", user.Username)
+		internalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.Write(response)
+}
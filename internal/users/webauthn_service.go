@@ -0,0 +1,233 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/auth"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/webauthn"
+)
+
+const (
+	defaultPasskeyName   = "Passkey"
+	maxPasskeyNameLength = 64
+)
+
+type WebauthnService struct {
+	UsersService                 UsersService
+	WebauthnCredentialRepository WebauthnCredentialRepository
+	WebauthnChallengeRepository  WebauthnChallengeRepository
+	RelyingParty                 webauthn.RelyingParty
+}
+
+func NewWebauthnService(usersService UsersService, webauthnCredentialRepository WebauthnCredentialRepository, webauthnChallengeRepository WebauthnChallengeRepository, relyingParty webauthn.RelyingParty) WebauthnService {
+	return WebauthnService{
+		UsersService:                 usersService,
+		WebauthnCredentialRepository: webauthnCredentialRepository,
+		WebauthnChallengeRepository:  webauthnChallengeRepository,
+		RelyingParty:                 relyingParty,
+	}
+}
+
+// BeginRegistration returns the options to create a passkey for the user with, excluding the
+// authenticators the user already registered.
+func (s *WebauthnService) BeginRegistration(ctx context.Context, user User) (*webauthn.CreationOptions, error) {
+	webauthnCredentials, err := s.WebauthnCredentialRepository.ListByUserId(ctx, user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	excludeCredentialIds := []string{}
+	for _, webauthnCredential := range webauthnCredentials {
+		excludeCredentialIds = append(excludeCredentialIds, webauthnCredential.Id)
+	}
+
+	challenge, err := s.issueChallenge(ctx, WebauthnRegistrationCeremony, user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	creationOptions := s.RelyingParty.CreationOptions(*challenge, user.Id, user.Username, excludeCredentialIds)
+
+	return &creationOptions, nil
+}
+
+func (s *WebauthnService) FinishRegistration(ctx context.Context, user User, name string, response webauthn.RegistrationResponse) (*WebauthnCredential, error) {
+	name = strings.TrimSpace(name)
+	if len(name) == 0 {
+		name = defaultPasskeyName
+	}
+	if len(name) > maxPasskeyNameLength {
+		return nil, &custom_errors.InvalidArgumentError{Message: fmt.Sprintf("Passkey name must contain at most %d characters", maxPasskeyNameLength)}
+	}
+
+	registration, err := webauthn.ParseRegistrationResponse(response)
+	if err != nil {
+		return nil, &custom_errors.InvalidArgumentError{Message: fmt.Sprintf("Invalid registration: %s", err)}
+	}
+
+	webauthnChallenge, err := s.consumeChallenge(ctx, WebauthnRegistrationCeremony, registration.Challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	if webauthnChallenge.UserId != user.Id {
+		return nil, &custom_errors.InvalidArgumentError{Message: "Invalid or expired challenge"}
+	}
+
+	err = s.RelyingParty.VerifyRegistration(registration, registration.Challenge)
+	if err != nil {
+		return nil, &custom_errors.InvalidArgumentError{Message: fmt.Sprintf("Invalid registration: %s", err)}
+	}
+
+	webauthnCredential := NewWebauthnCredential(registration.Credential.Id, user.Id, name, registration.Credential.PublicKey, registration.Credential.SignCount, time.Now(), nil)
+
+	err = s.WebauthnCredentialRepository.Create(ctx, webauthnCredential)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webauthnCredential, nil
+}
+
+func (s *WebauthnService) ListCredentials(ctx context.Context, userId string) ([]WebauthnCredential, error) {
+	return s.WebauthnCredentialRepository.ListByUserId(ctx, userId)
+}
+
+// BeginAuthentication returns the options to sign in with a passkey. Without an email, or with
+// an email no user has, any discoverable credential of the relying party may be used, so that the
+// options don't tell whether an account exists.
+func (s *WebauthnService) BeginAuthentication(ctx context.Context, email string) (*webauthn.RequestOptions, error) {
+	userId := ""
+	allowCredentialIds := []string{}
+
+	if len(email) > 0 {
+		user, err := s.UsersService.GetUserByEmail(ctx, email)
+		if err != nil {
+			if _, ok := err.(*custom_errors.NotFoundError); !ok {
+				return nil, err
+			}
+		} else {
+			webauthnCredentials, err := s.WebauthnCredentialRepository.ListByUserId(ctx, user.Id)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(webauthnCredentials) > 0 {
+				userId = user.Id
+				for _, webauthnCredential := range webauthnCredentials {
+					allowCredentialIds = append(allowCredentialIds, webauthnCredential.Id)
+				}
+			}
+		}
+	}
+
+	challenge, err := s.issueChallenge(ctx, WebauthnAuthenticationCeremony, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	requestOptions := s.RelyingParty.RequestOptions(*challenge, allowCredentialIds)
+
+	return &requestOptions, nil
+}
+
+// FinishAuthentication verifies the assertion against the stored credential, and returns the
+// user it belongs to and whether the authenticator verified them. The user is the one the
+// challenge was issued for or, for discoverable credentials, the one of the user handle.
+func (s *WebauthnService) FinishAuthentication(ctx context.Context, response webauthn.AuthenticationResponse) (*User, bool, error) {
+	unauthenticatedErr := &custom_errors.UnauthenticatedError{Message: "Invalid passkey"}
+
+	assertion, err := webauthn.ParseAuthenticationResponse(response)
+	if err != nil {
+		return nil, false, &custom_errors.InvalidArgumentError{Message: fmt.Sprintf("Invalid assertion: %s", err)}
+	}
+
+	webauthnChallenge, err := s.consumeChallenge(ctx, WebauthnAuthenticationCeremony, assertion.Challenge)
+	if err != nil {
+		return nil, false, err
+	}
+
+	userId := assertion.UserHandle
+	if len(webauthnChallenge.UserId) > 0 {
+		if len(userId) > 0 && userId != webauthnChallenge.UserId {
+			return nil, false, unauthenticatedErr
+		}
+		userId = webauthnChallenge.UserId
+	}
+	if len(userId) == 0 {
+		return nil, false, unauthenticatedErr
+	}
+
+	webauthnCredential, err := s.WebauthnCredentialRepository.GetById(ctx, userId, assertion.CredentialId)
+	if err != nil {
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			return nil, false, unauthenticatedErr
+		}
+		return nil, false, err
+	}
+
+	signCount, err := s.RelyingParty.VerifyAssertion(assertion, assertion.Challenge, webauthn.Credential{
+		Id:        webauthnCredential.Id,
+		PublicKey: webauthnCredential.PublicKey,
+		SignCount: webauthnCredential.SignCount,
+	})
+	if err != nil {
+		return nil, false, &custom_errors.UnauthenticatedError{Message: fmt.Sprintf("Invalid passkey: %s", err)}
+	}
+
+	err = s.WebauthnCredentialRepository.UpdateSignCount(ctx, userId, webauthnCredential.Id, signCount, time.Now())
+	if err != nil {
+		return nil, false, err
+	}
+
+	user, err := s.UsersService.GetUserById(ctx, userId)
+	if err != nil {
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			return nil, false, unauthenticatedErr
+		}
+		return nil, false, err
+	}
+
+	return user, assertion.UserVerified(), nil
+}
+
+// issueChallenge stores the hash of a new challenge, which expires with the ceremony's timeout.
+func (s *WebauthnService) issueChallenge(ctx context.Context, ceremony WebauthnCeremony, userId string) (*string, error) {
+	challenge, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	err = s.WebauthnChallengeRepository.Create(ctx, NewWebauthnChallenge(auth.HashOpaqueToken(*challenge), ceremony, userId, now, now.Add(s.RelyingParty.Timeout)))
+	if err != nil {
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+// consumeChallenge uses the challenge up, whether or not the ceremony then succeeds, so that
+// every challenge is only ever verified once.
+func (s *WebauthnService) consumeChallenge(ctx context.Context, ceremony WebauthnCeremony, challenge string) (*WebauthnChallenge, error) {
+	invalidChallengeErr := &custom_errors.InvalidArgumentError{Message: "Invalid or expired challenge"}
+
+	webauthnChallenge, err := s.WebauthnChallengeRepository.Consume(ctx, auth.HashOpaqueToken(challenge))
+	if err != nil {
+		if _, ok := err.(*custom_errors.NotFoundError); ok {
+			return nil, invalidChallengeErr
+		}
+		return nil, err
+	}
+
+	if webauthnChallenge.Ceremony != ceremony || !time.Now().Before(webauthnChallenge.ExpiresAt) {
+		return nil, invalidChallengeErr
+	}
+
+	return webauthnChallenge, nil
+}
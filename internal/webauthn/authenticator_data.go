@@ -0,0 +1,83 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	flagUserPresent            = 0x01
+	flagUserVerified           = 0x04
+	flagAttestedCredentialData = 0x40
+	flagExtensionData          = 0x80
+)
+
+// authenticatorData is the authenticator data structure of the WebAuthn spec. The credential is
+// only present in the authenticator data of registrations.
+type authenticatorData struct {
+	rpIdHash     []byte
+	flags        byte
+	signCount    uint32
+	credentialId []byte
+	publicKey    []byte
+}
+
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, fmt.Errorf("authenticator data is too short")
+	}
+
+	authData := authenticatorData{
+		rpIdHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+
+	offset := 37
+
+	if authData.flags&flagAttestedCredentialData != 0 {
+		// The AAGUID, which identifies the authenticator model, isn't used.
+		if len(data) < offset+18 {
+			return nil, fmt.Errorf("attested credential data is too short")
+		}
+		offset += 16
+
+		credentialIdLength := int(binary.BigEndian.Uint16(data[offset : offset+2]))
+		offset += 2
+
+		if credentialIdLength > 1023 || len(data) < offset+credentialIdLength {
+			return nil, fmt.Errorf("invalid credential id length %d", credentialIdLength)
+		}
+		authData.credentialId = data[offset : offset+credentialIdLength]
+		offset += credentialIdLength
+
+		_, publicKeyLength, err := decodeCbor(data[offset:])
+		if err != nil {
+			return nil, err
+		}
+		authData.publicKey = data[offset : offset+publicKeyLength]
+		offset += publicKeyLength
+	}
+
+	if authData.flags&flagExtensionData != 0 {
+		_, extensionsLength, err := decodeCbor(data[offset:])
+		if err != nil {
+			return nil, err
+		}
+		offset += extensionsLength
+	}
+
+	if offset != len(data) {
+		return nil, fmt.Errorf("trailing data after authenticator data")
+	}
+
+	return &authData, nil
+}
+
+func (d *authenticatorData) userPresent() bool {
+	return d.flags&flagUserPresent != 0
+}
+
+func (d *authenticatorData) userVerified() bool {
+	return d.flags&flagUserVerified != 0
+}
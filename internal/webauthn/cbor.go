@@ -0,0 +1,154 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// decodeCbor decodes the first CBOR data item of data, returning it along with the number of bytes
+// it spans. It supports the subset of RFC 8949 that authenticators emit: definite length
+// integers, byte and text strings, arrays, maps, tags, booleans and null. Integers are decoded as
+// int64, byte strings as []byte, text strings as string, arrays as []interface{} and maps as
+// map[interface{}]interface{}.
+func decodeCbor(data []byte) (interface{}, int, error) {
+	return decodeCborItem(data, 0)
+}
+
+// maxCborDepth bounds the nesting of arrays and maps, so that hostile input can't exhaust the
+// stack.
+const maxCborDepth = 16
+
+func decodeCborItem(data []byte, depth int) (interface{}, int, error) {
+	if depth > maxCborDepth {
+		return nil, 0, fmt.Errorf("cbor: nesting too deep")
+	}
+
+	if len(data) == 0 {
+		return nil, 0, fmt.Errorf("cbor: unexpected end of data")
+	}
+
+	majorType := data[0] >> 5
+	additionalInfo := data[0] & 0x1f
+
+	if majorType == 7 {
+		switch additionalInfo {
+		case 20:
+			return false, 1, nil
+		case 21:
+			return true, 1, nil
+		case 22:
+			return nil, 1, nil
+		default:
+			return nil, 0, fmt.Errorf("cbor: unsupported simple value %d", additionalInfo)
+		}
+	}
+
+	argument, offset, err := decodeCborArgument(data, additionalInfo)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch majorType {
+	case 0:
+		if argument > 1<<63-1 {
+			return nil, 0, fmt.Errorf("cbor: integer overflow")
+		}
+		return int64(argument), offset, nil
+	case 1:
+		if argument > 1<<63-1 {
+			return nil, 0, fmt.Errorf("cbor: integer overflow")
+		}
+		return -1 - int64(argument), offset, nil
+	case 2, 3:
+		if argument > uint64(len(data)-offset) {
+			return nil, 0, fmt.Errorf("cbor: unexpected end of data")
+		}
+		end := offset + int(argument)
+		if majorType == 2 {
+			return append([]byte{}, data[offset:end]...), end, nil
+		}
+		return string(data[offset:end]), end, nil
+	case 4:
+		if argument > uint64(len(data)) {
+			return nil, 0, fmt.Errorf("cbor: unexpected end of data")
+		}
+		array := make([]interface{}, 0, argument)
+		for i := uint64(0); i < argument; i++ {
+			item, length, err := decodeCborItem(data[offset:], depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			array = append(array, item)
+			offset += length
+		}
+		return array, offset, nil
+	case 5:
+		if argument > uint64(len(data)) {
+			return nil, 0, fmt.Errorf("cbor: unexpected end of data")
+		}
+		cborMap := make(map[interface{}]interface{}, argument)
+		for i := uint64(0); i < argument; i++ {
+			key, keyLength, err := decodeCborItem(data[offset:], depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			offset += keyLength
+
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, 0, fmt.Errorf("cbor: unsupported map key type %T", key)
+			}
+
+			if _, ok := cborMap[key]; ok {
+				return nil, 0, fmt.Errorf("cbor: duplicate map key %v", key)
+			}
+
+			value, valueLength, err := decodeCborItem(data[offset:], depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			offset += valueLength
+
+			cborMap[key] = value
+		}
+		return cborMap, offset, nil
+	case 6:
+		item, length, err := decodeCborItem(data[offset:], depth+1)
+		if err != nil {
+			return nil, 0, err
+		}
+		return item, offset + length, nil
+	default:
+		return nil, 0, fmt.Errorf("cbor: unsupported major type %d", majorType)
+	}
+}
+
+func decodeCborArgument(data []byte, additionalInfo byte) (uint64, int, error) {
+	switch {
+	case additionalInfo < 24:
+		return uint64(additionalInfo), 1, nil
+	case additionalInfo == 24:
+		if len(data) < 2 {
+			return 0, 0, fmt.Errorf("cbor: unexpected end of data")
+		}
+		return uint64(data[1]), 2, nil
+	case additionalInfo == 25:
+		if len(data) < 3 {
+			return 0, 0, fmt.Errorf("cbor: unexpected end of data")
+		}
+		return uint64(binary.BigEndian.Uint16(data[1:3])), 3, nil
+	case additionalInfo == 26:
+		if len(data) < 5 {
+			return 0, 0, fmt.Errorf("cbor: unexpected end of data")
+		}
+		return uint64(binary.BigEndian.Uint32(data[1:5])), 5, nil
+	case additionalInfo == 27:
+		if len(data) < 9 {
+			return 0, 0, fmt.Errorf("cbor: unexpected end of data")
+		}
+		return binary.BigEndian.Uint64(data[1:9]), 9, nil
+	default:
+		return 0, 0, fmt.Errorf("cbor: unsupported additional information %d", additionalInfo)
+	}
+}
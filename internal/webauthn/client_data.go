@@ -0,0 +1,29 @@
+package webauthn
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	ceremonyCreate = "webauthn.create"
+	ceremonyGet    = "webauthn.get"
+)
+
+// clientData is the collected client data the browser signs the challenge in.
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+func parseClientData(clientDataJson []byte) (*clientData, error) {
+	collectedClientData := clientData{}
+	err := json.Unmarshal(clientDataJson, &collectedClientData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client data: %w", err)
+	}
+
+	return &collectedClientData, nil
+}
@@ -0,0 +1,130 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+)
+
+// The COSE algorithms of RFC 8152 and RFC 8812 that credentials may use, by order of preference.
+const (
+	AlgorithmES256 int64 = -7
+	AlgorithmEdDSA int64 = -8
+	AlgorithmRS256 int64 = -257
+)
+
+var SupportedAlgorithms = []int64{AlgorithmES256, AlgorithmEdDSA, AlgorithmRS256}
+
+const (
+	coseKeyTypeOkp = 1
+	coseKeyTypeEc2 = 2
+	coseKeyTypeRsa = 3
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+)
+
+// publicKey is a credential public key, parsed from its COSE_Key encoding.
+type publicKey struct {
+	algorithm int64
+	key       crypto.PublicKey
+}
+
+func parsePublicKey(coseKey []byte) (*publicKey, error) {
+	decoded, length, err := decodeCbor(coseKey)
+	if err != nil {
+		return nil, err
+	}
+	if length != len(coseKey) {
+		return nil, fmt.Errorf("trailing data after COSE key")
+	}
+
+	return publicKeyFromCbor(decoded)
+}
+
+func publicKeyFromCbor(decoded interface{}) (*publicKey, error) {
+	coseMap, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("COSE key must be a map")
+	}
+
+	keyType, ok := coseMap[int64(1)].(int64)
+	if !ok {
+		return nil, fmt.Errorf("COSE key has no key type")
+	}
+
+	algorithm, ok := coseMap[int64(3)].(int64)
+	if !ok {
+		return nil, fmt.Errorf("COSE key has no algorithm")
+	}
+
+	switch {
+	case keyType == coseKeyTypeEc2 && algorithm == AlgorithmES256:
+		curve, _ := coseMap[int64(-1)].(int64)
+		x, _ := coseMap[int64(-2)].([]byte)
+		y, _ := coseMap[int64(-3)].([]byte)
+		if curve != coseCurveP256 || len(x) != 32 || len(y) != 32 {
+			return nil, fmt.Errorf("invalid ES256 COSE key")
+		}
+
+		key := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("ES256 COSE key is not on the curve")
+		}
+
+		return &publicKey{algorithm: algorithm, key: key}, nil
+	case keyType == coseKeyTypeOkp && algorithm == AlgorithmEdDSA:
+		curve, _ := coseMap[int64(-1)].(int64)
+		x, _ := coseMap[int64(-2)].([]byte)
+		if curve != coseCurveEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid EdDSA COSE key")
+		}
+
+		return &publicKey{algorithm: algorithm, key: ed25519.PublicKey(x)}, nil
+	case keyType == coseKeyTypeRsa && algorithm == AlgorithmRS256:
+		n, _ := coseMap[int64(-1)].([]byte)
+		e, _ := coseMap[int64(-2)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("invalid RS256 COSE key")
+		}
+
+		key := &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+
+		return &publicKey{algorithm: algorithm, key: key}, nil
+	default:
+		return nil, fmt.Errorf("unsupported COSE key type %d with algorithm %d", keyType, algorithm)
+	}
+}
+
+func (k *publicKey) verify(data []byte, signature []byte) error {
+	switch key := k.key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		if !ecdsa.VerifyASN1(key, digest[:], signature) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, data, signature) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature)
+	default:
+		return fmt.Errorf("unsupported public key type %T", k.key)
+	}
+}
@@ -0,0 +1,74 @@
+package webauthn
+
+// The options and responses below follow the JSON serialization of WebAuthn Level 3, where binary
+// values are base64url encoded, so that browsers can pass them to
+// PublicKeyCredential.parseCreationOptionsFromJSON and friends.
+
+const publicKeyCredentialType = "public-key"
+
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	Rp                     RelyingPartyEntity     `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameters `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RpId             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+type RelyingPartyEntity struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type CredentialParameters struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type string `json:"type"`
+	Id   string `json:"id"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+type RegistrationResponse struct {
+	Id       string `json:"id"`
+	RawId    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJson    string `json:"clientDataJSON"`
+		AttestationObject string `json:"attestationObject"`
+	} `json:"response"`
+}
+
+type AuthenticationResponse struct {
+	Id       string `json:"id"`
+	RawId    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJson    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response"`
+}
@@ -0,0 +1,332 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"time"
+)
+
+// RelyingParty verifies WebAuthn ceremonies for the relying party id, which is the domain
+// credentials are scoped to, from web pages served at any of the origins.
+type RelyingParty struct {
+	Id      string
+	Name    string
+	Origins []string
+	// Timeout is the time users are given to complete a ceremony.
+	Timeout time.Duration
+}
+
+func NewRelyingParty(id string, name string, origins []string, timeout time.Duration) RelyingParty {
+	return RelyingParty{
+		Id:      id,
+		Name:    name,
+		Origins: origins,
+		Timeout: timeout,
+	}
+}
+
+// Credential is what a relying party stores about a public key credential. PublicKey is COSE
+// encoded.
+type Credential struct {
+	Id        string
+	PublicKey []byte
+	SignCount uint32
+}
+
+// Registration is a parsed registration response. Its challenge must be checked against the one
+// issued with VerifyRegistration before the credential is trusted.
+type Registration struct {
+	Challenge  string
+	Credential Credential
+
+	clientData        *clientData
+	authenticatorData *authenticatorData
+	rawId             string
+}
+
+// Assertion is a parsed authentication response. UserHandle is the user id the credential was
+// registered with, when the authenticator returned it, as it does for discoverable credentials.
+type Assertion struct {
+	Challenge    string
+	CredentialId string
+	UserHandle   string
+
+	clientData        *clientData
+	clientDataJson    []byte
+	rawAuthData       []byte
+	authenticatorData *authenticatorData
+	signature         []byte
+}
+
+// UserVerified tells whether the authenticator verified the user, with a PIN or biometrics, on
+// top of testing their presence.
+func (a *Assertion) UserVerified() bool {
+	return a.authenticatorData.userVerified()
+}
+
+func (rp *RelyingParty) CreationOptions(challenge string, userId string, userName string, excludeCredentialIds []string) CreationOptions {
+	pubKeyCredParams := []CredentialParameters{}
+	for _, algorithm := range SupportedAlgorithms {
+		pubKeyCredParams = append(pubKeyCredParams, CredentialParameters{Type: publicKeyCredentialType, Alg: algorithm})
+	}
+
+	return CreationOptions{
+		Challenge: challenge,
+		Rp: RelyingPartyEntity{
+			Id:   rp.Id,
+			Name: rp.Name,
+		},
+		User: UserEntity{
+			Id:          base64.RawURLEncoding.EncodeToString([]byte(userId)),
+			Name:        userName,
+			DisplayName: userName,
+		},
+		PubKeyCredParams:   pubKeyCredParams,
+		Timeout:            rp.Timeout.Milliseconds(),
+		ExcludeCredentials: credentialDescriptors(excludeCredentialIds),
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "required",
+			UserVerification: "preferred",
+		},
+		Attestation: "none",
+	}
+}
+
+func (rp *RelyingParty) RequestOptions(challenge string, allowCredentialIds []string) RequestOptions {
+	return RequestOptions{
+		Challenge:        challenge,
+		Timeout:          rp.Timeout.Milliseconds(),
+		RpId:             rp.Id,
+		AllowCredentials: credentialDescriptors(allowCredentialIds),
+		UserVerification: "preferred",
+	}
+}
+
+func ParseRegistrationResponse(response RegistrationResponse) (*Registration, error) {
+	if response.Type != publicKeyCredentialType {
+		return nil, fmt.Errorf("unexpected credential type %s", response.Type)
+	}
+
+	clientDataJson, err := base64.RawURLEncoding.DecodeString(response.Response.ClientDataJson)
+	if err != nil {
+		return nil, fmt.Errorf("invalid clientDataJSON: %w", err)
+	}
+
+	collectedClientData, err := parseClientData(clientDataJson)
+	if err != nil {
+		return nil, err
+	}
+
+	attestationObject, err := base64.RawURLEncoding.DecodeString(response.Response.AttestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attestationObject: %w", err)
+	}
+
+	decodedAttestationObject, length, err := decodeCbor(attestationObject)
+	if err != nil {
+		return nil, err
+	}
+	if length != len(attestationObject) {
+		return nil, fmt.Errorf("trailing data after attestation object")
+	}
+
+	attestationMap, ok := decodedAttestationObject.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("attestation object must be a map")
+	}
+
+	format, ok := attestationMap["fmt"].(string)
+	if !ok {
+		return nil, fmt.Errorf("attestation object has no format")
+	}
+
+	attestationStatement, ok := attestationMap["attStmt"].(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("attestation object has no statement")
+	}
+
+	// Attestation isn't requested, as the service doesn't restrict which authenticators users
+	// register. Authenticators may still attest, and their statements are then ignored.
+	if format == "none" && len(attestationStatement) > 0 {
+		return nil, fmt.Errorf("none attestation with a statement")
+	}
+
+	rawAuthData, ok := attestationMap["authData"].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("attestation object has no authenticator data")
+	}
+
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+
+	if authData.credentialId == nil {
+		return nil, fmt.Errorf("authenticator data has no attested credential")
+	}
+
+	return &Registration{
+		Challenge: collectedClientData.Challenge,
+		Credential: Credential{
+			Id:        base64.RawURLEncoding.EncodeToString(authData.credentialId),
+			PublicKey: append([]byte{}, authData.publicKey...),
+			SignCount: authData.signCount,
+		},
+		clientData:        collectedClientData,
+		authenticatorData: authData,
+		rawId:             response.RawId,
+	}, nil
+}
+
+// VerifyRegistration verifies the registration ceremony of the spec, but for attestation.
+func (rp *RelyingParty) VerifyRegistration(registration *Registration, challenge string) error {
+	err := rp.verifyClientData(registration.clientData, ceremonyCreate, challenge)
+	if err != nil {
+		return err
+	}
+
+	err = rp.verifyAuthenticatorData(registration.authenticatorData)
+	if err != nil {
+		return err
+	}
+
+	if registration.rawId != registration.Credential.Id {
+		return fmt.Errorf("credential id mismatch")
+	}
+
+	_, err = parsePublicKey(registration.Credential.PublicKey)
+	return err
+}
+
+func ParseAuthenticationResponse(response AuthenticationResponse) (*Assertion, error) {
+	if response.Type != publicKeyCredentialType {
+		return nil, fmt.Errorf("unexpected credential type %s", response.Type)
+	}
+
+	clientDataJson, err := base64.RawURLEncoding.DecodeString(response.Response.ClientDataJson)
+	if err != nil {
+		return nil, fmt.Errorf("invalid clientDataJSON: %w", err)
+	}
+
+	collectedClientData, err := parseClientData(clientDataJson)
+	if err != nil {
+		return nil, err
+	}
+
+	rawAuthData, err := base64.RawURLEncoding.DecodeString(response.Response.AuthenticatorData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid authenticatorData: %w", err)
+	}
+
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(response.Response.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+
+	userHandle, err := base64.RawURLEncoding.DecodeString(response.Response.UserHandle)
+	if err != nil {
+		return nil, fmt.Errorf("invalid userHandle: %w", err)
+	}
+
+	credentialId, err := base64.RawURLEncoding.DecodeString(response.RawId)
+	if err != nil || len(credentialId) == 0 {
+		return nil, fmt.Errorf("invalid rawId")
+	}
+
+	return &Assertion{
+		Challenge:         collectedClientData.Challenge,
+		CredentialId:      base64.RawURLEncoding.EncodeToString(credentialId),
+		UserHandle:        string(userHandle),
+		clientData:        collectedClientData,
+		clientDataJson:    clientDataJson,
+		rawAuthData:       rawAuthData,
+		authenticatorData: authData,
+		signature:         signature,
+	}, nil
+}
+
+// VerifyAssertion verifies the authentication ceremony of the spec for the stored credential,
+// and returns the new sign count to store. A sign count that didn't increase is taken as a sign
+// of a cloned authenticator, unless the authenticator doesn't count signatures at all.
+func (rp *RelyingParty) VerifyAssertion(assertion *Assertion, challenge string, credential Credential) (uint32, error) {
+	err := rp.verifyClientData(assertion.clientData, ceremonyGet, challenge)
+	if err != nil {
+		return 0, err
+	}
+
+	err = rp.verifyAuthenticatorData(assertion.authenticatorData)
+	if err != nil {
+		return 0, err
+	}
+
+	key, err := parsePublicKey(credential.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+
+	clientDataHash := sha256.Sum256(assertion.clientDataJson)
+
+	err = key.verify(append(append([]byte{}, assertion.rawAuthData...), clientDataHash[:]...), assertion.signature)
+	if err != nil {
+		return 0, err
+	}
+
+	signCount := assertion.authenticatorData.signCount
+	if (signCount != 0 || credential.SignCount != 0) && signCount <= credential.SignCount {
+		return 0, fmt.Errorf("sign count %d did not increase from %d", signCount, credential.SignCount)
+	}
+
+	return signCount, nil
+}
+
+func (rp *RelyingParty) verifyClientData(collectedClientData *clientData, ceremony string, challenge string) error {
+	if collectedClientData.Type != ceremony {
+		return fmt.Errorf("unexpected client data type %s", collectedClientData.Type)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(collectedClientData.Challenge), []byte(challenge)) != 1 {
+		return fmt.Errorf("challenge mismatch")
+	}
+
+	if collectedClientData.CrossOrigin {
+		return fmt.Errorf("cross-origin ceremonies aren't allowed")
+	}
+
+	for _, origin := range rp.Origins {
+		if collectedClientData.Origin == origin {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unexpected origin %s", collectedClientData.Origin)
+}
+
+func (rp *RelyingParty) verifyAuthenticatorData(authData *authenticatorData) error {
+	rpIdHash := sha256.Sum256([]byte(rp.Id))
+	if !bytes.Equal(authData.rpIdHash, rpIdHash[:]) {
+		return fmt.Errorf("relying party id mismatch")
+	}
+
+	if !authData.userPresent() {
+		return fmt.Errorf("user not present")
+	}
+
+	return nil
+}
+
+func credentialDescriptors(credentialIds []string) []CredentialDescriptor {
+	descriptors := []CredentialDescriptor{}
+	for _, credentialId := range credentialIds {
+		descriptors = append(descriptors, CredentialDescriptor{Type: publicKeyCredentialType, Id: credentialId})
+	}
+
+	return descriptors
+}
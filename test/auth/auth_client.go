@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+type JwksResponse struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		Crv string `json:"crv"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		X   string `json:"x"`
+	} `json:"keys"`
+}
+
+func GetJwks() (*http.Response, error) {
+	const url = "http://localhost:8080/.well-known/jwks.json"
+
+	response, err := http.Get(url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func GetJwksAndDecode() (*JwksResponse, error) {
+	response, err := GetJwks()
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	defer response.Body.Close()
+
+	responseData := &JwksResponse{}
+	err = json.NewDecoder(response.Body).Decode(&responseData)
+	if err != nil {
+		return nil, err
+	}
+
+	return responseData, nil
+}
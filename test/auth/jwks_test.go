@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"testing"
+
+	"github.com/bxcodec/faker/v3"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/test/users"
+)
+
+func TestGivenTokenWhenGetJwksShouldReturnKeyThatVerifiesToken(t *testing.T) {
+	requestData := users.RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := users.RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks, err := GetJwksAndDecode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unverifiedToken, _, err := new(jwt.Parser).ParseUnverified(registeredUser.User.Token, &jwt.StandardClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if unverifiedToken.Method.Alg() == jwt.SigningMethodHS256.Alg() {
+		if len(jwks.Keys) != 0 {
+			t.Fatalf("got %d keys, want 0", len(jwks.Keys))
+		}
+		t.Skip("Tokens are signed with a shared secret, which must not be published")
+	}
+
+	kid, _ := unverifiedToken.Header["kid"].(string)
+
+	var publicKey interface{}
+	for _, key := range jwks.Keys {
+		if key.Kid != kid {
+			continue
+		}
+
+		if key.Use != "sig" {
+			t.Fatalf("got %s, want %s", key.Use, "sig")
+		}
+
+		if key.Alg != unverifiedToken.Method.Alg() {
+			t.Fatalf("got %s, want %s", key.Alg, unverifiedToken.Method.Alg())
+		}
+
+		switch key.Kty {
+		case "RSA":
+			n, err := base64.RawURLEncoding.DecodeString(key.N)
+			if err != nil {
+				t.Fatal(err)
+			}
+			e, err := base64.RawURLEncoding.DecodeString(key.E)
+			if err != nil {
+				t.Fatal(err)
+			}
+			publicKey = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "OKP":
+			x, err := base64.RawURLEncoding.DecodeString(key.X)
+			if err != nil {
+				t.Fatal(err)
+			}
+			publicKey = ed25519.PublicKey(x)
+		default:
+			t.Fatalf("unexpected key type %s", key.Kty)
+		}
+	}
+
+	if publicKey == nil {
+		t.Fatalf("no key with kid %s", kid)
+	}
+
+	_, err = jwt.ParseWithClaims(registeredUser.User.Token, &jwt.StandardClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return publicKey, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,21 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	usersv1 "github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/api/users/v1"
+	"google.golang.org/grpc"
+)
+
+func NewUsersClient() (usersv1.UsersServiceClient, *grpc.ClientConn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, "localhost:9090", grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return usersv1.NewUsersServiceClient(conn), conn, nil
+}
@@ -0,0 +1,250 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bxcodec/faker/v3"
+	usersv1 "github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/api/users/v1"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/test/profiles"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/test/users"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGivenTokenIsValidWhenValidateTokenShouldReturnUser(t *testing.T) {
+	client, conn, err := NewUsersClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	registeredUser, err := profiles.RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := client.ValidateToken(context.Background(), &usersv1.ValidateTokenRequest{Token: registeredUser.User.Token})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.User.Username != registeredUser.User.Username {
+		t.Fatalf("got %s, want %s", response.User.Username, registeredUser.User.Username)
+	}
+
+	if len(response.User.Id) == 0 {
+		t.Fatal("got an empty id")
+	}
+
+	if len(response.TokenId) == 0 {
+		t.Fatal("got an empty token id")
+	}
+}
+
+func TestGivenTokenIsInvalidWhenValidateTokenShouldReturnUnauthenticated(t *testing.T) {
+	client, conn, err := NewUsersClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	_, err = client.ValidateToken(context.Background(), &usersv1.ValidateTokenRequest{Token: "invalid"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("got %s, want %s", status.Code(err), codes.Unauthenticated)
+	}
+}
+
+func TestGivenTokenIsRevokedWhenValidateTokenShouldReturnUnauthenticated(t *testing.T) {
+	client, conn, err := NewUsersClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	registeredUser, err := profiles.RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = users.Logout(registeredUser.User.Token, users.LogoutRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.ValidateToken(context.Background(), &usersv1.ValidateTokenRequest{Token: registeredUser.User.Token})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("got %s, want %s", status.Code(err), codes.Unauthenticated)
+	}
+}
+
+func TestGivenUserExistsWhenGetUserShouldReturnUser(t *testing.T) {
+	client, conn, err := NewUsersClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	registeredUser, err := profiles.RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byUsername, err := client.GetUser(context.Background(), &usersv1.GetUserRequest{Lookup: &usersv1.GetUserRequest_Username{Username: registeredUser.User.Username}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if byUsername.User.Email != registeredUser.User.Email {
+		t.Fatalf("got %s, want %s", byUsername.User.Email, registeredUser.User.Email)
+	}
+
+	byId, err := client.GetUser(context.Background(), &usersv1.GetUserRequest{Lookup: &usersv1.GetUserRequest_Id{Id: byUsername.User.Id}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if byId.User.Username != registeredUser.User.Username {
+		t.Fatalf("got %s, want %s", byId.User.Username, registeredUser.User.Username)
+	}
+}
+
+func TestGivenUserDoesNotExistWhenGetUserShouldReturnNotFound(t *testing.T) {
+	client, conn, err := NewUsersClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	_, err = client.GetUser(context.Background(), &usersv1.GetUserRequest{Lookup: &usersv1.GetUserRequest_Username{Username: faker.Username()}})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("got %s, want %s", status.Code(err), codes.NotFound)
+	}
+}
+
+func TestGivenIdIsEmptyWhenGetUserShouldReturnInvalidArgument(t *testing.T) {
+	client, conn, err := NewUsersClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	_, err = client.GetUser(context.Background(), &usersv1.GetUserRequest{Lookup: &usersv1.GetUserRequest_Id{Id: ""}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("got %s, want %s", status.Code(err), codes.InvalidArgument)
+	}
+}
+
+func TestGivenIdContainsSlashWhenGetUserShouldReturnInvalidArgument(t *testing.T) {
+	client, conn, err := NewUsersClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	_, err = client.GetUser(context.Background(), &usersv1.GetUserRequest{Lookup: &usersv1.GetUserRequest_Id{Id: "usernames/" + faker.Username()}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("got %s, want %s", status.Code(err), codes.InvalidArgument)
+	}
+}
+
+func TestGivenSomeUsersExistWhenBatchGetUsersShouldReturnFoundAndMissingUsers(t *testing.T) {
+	client, conn, err := NewUsersClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	firstUser, err := profiles.RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secondUser, err := profiles.RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secondUserResponse, err := client.GetUser(context.Background(), &usersv1.GetUserRequest{Lookup: &usersv1.GetUserRequest_Username{Username: secondUser.User.Username}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	missingUsername := faker.Username()
+
+	response, err := client.BatchGetUsers(context.Background(), &usersv1.BatchGetUsersRequest{
+		Ids:       []string{secondUserResponse.User.Id, "missing-id"},
+		Usernames: []string{firstUser.User.Username, missingUsername},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	usernames := map[string]bool{}
+	for _, user := range response.Users {
+		usernames[user.Username] = true
+	}
+
+	if len(response.Users) != 2 || !usernames[firstUser.User.Username] || !usernames[secondUser.User.Username] {
+		t.Fatalf("got %v, want %s and %s", usernames, firstUser.User.Username, secondUser.User.Username)
+	}
+
+	if len(response.MissingIds) != 1 || response.MissingIds[0] != "missing-id" {
+		t.Fatalf("got %v, want [missing-id]", response.MissingIds)
+	}
+
+	if len(response.MissingUsernames) != 1 || response.MissingUsernames[0] != missingUsername {
+		t.Fatalf("got %v, want [%s]", response.MissingUsernames, missingUsername)
+	}
+}
+
+func TestGivenUserFollowsAnotherWhenIsFollowingShouldReturnTrue(t *testing.T) {
+	client, conn, err := NewUsersClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	follower, err := profiles.RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	followee, err := profiles.RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = profiles.FollowUserAndDecode(followee.User.Username, follower.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	followerResponse, err := client.ValidateToken(context.Background(), &usersv1.ValidateTokenRequest{Token: follower.User.Token})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	followeeResponse, err := client.ValidateToken(context.Background(), &usersv1.ValidateTokenRequest{Token: followee.User.Token})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := client.IsFollowing(context.Background(), &usersv1.IsFollowingRequest{FollowerId: followerResponse.User.Id, FolloweeId: followeeResponse.User.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !response.Following {
+		t.Fatalf("got %t, want %t", response.Following, true)
+	}
+
+	reverseResponse, err := client.IsFollowing(context.Background(), &usersv1.IsFollowingRequest{FollowerId: followeeResponse.User.Id, FolloweeId: followerResponse.User.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reverseResponse.Following {
+		t.Fatalf("got %t, want %t", reverseResponse.Following, false)
+	}
+}
@@ -0,0 +1,401 @@
+package oidc
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/bxcodec/faker/v3"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/test/users"
+)
+
+const (
+	baseUrl = "http://localhost:8080"
+)
+
+type DiscoveryResponse struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JwksUri                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	IdTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+}
+
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	IdToken     string `json:"id_token"`
+	Scope       string `json:"scope"`
+}
+
+type ErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type UserinfoResponse struct {
+	Subject           string `json:"sub"`
+	PreferredUsername string `json:"preferred_username"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+}
+
+type IdTokenClaims struct {
+	jwt.RegisteredClaims
+	AuthTime          int64  `json:"auth_time"`
+	Nonce             string `json:"nonce"`
+	PreferredUsername string `json:"preferred_username"`
+	Email             string `json:"email"`
+	EmailVerified     *bool  `json:"email_verified"`
+}
+
+// Client is the OpenID Connect client the app is configured with for the tests.
+type Client struct {
+	Id          string
+	Secret      string
+	RedirectUri string
+}
+
+func GetClient() Client {
+	return Client{
+		Id:          getEnvOrDefault("OIDC_TEST_CLIENT_ID", "conduit-test"),
+		Secret:      getEnvOrDefault("OIDC_TEST_CLIENT_SECRET", "dummy-client-secret"),
+		RedirectUri: getEnvOrDefault("OIDC_TEST_CLIENT_REDIRECT_URI", "http://localhost:3000/callback"),
+	}
+}
+
+func RegisterFakeUser() (*users.UserResponse, error) {
+	requestData := users.RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		return nil, err
+	}
+
+	return users.RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+}
+
+func GetDiscovery() (*http.Response, error) {
+	return http.Get(fmt.Sprintf("%s/.well-known/openid-configuration", baseUrl))
+}
+
+func GetDiscoveryAndDecode() (*DiscoveryResponse, error) {
+	response, err := GetDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	defer response.Body.Close()
+
+	responseData := &DiscoveryResponse{}
+	err = json.NewDecoder(response.Body).Decode(&responseData)
+	if err != nil {
+		return nil, err
+	}
+
+	return responseData, nil
+}
+
+// NewAuthorizationQuery returns the query of an authorization request of the client for the
+// openid scope and the extra scopes. The redirect_uri is omitted when the client has none.
+func NewAuthorizationQuery(client Client, state string, nonce string, scopes ...string) url.Values {
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", client.Id)
+	if len(client.RedirectUri) > 0 {
+		query.Set("redirect_uri", client.RedirectUri)
+	}
+	query.Set("scope", strings.Join(append([]string{"openid"}, scopes...), " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	return query
+}
+
+// Authorize sends the authorization request as the user of the token, without following the
+// redirect back to the client.
+func Authorize(query url.Values, tokenString string) (*http.Response, error) {
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/oauth/authorize?%s", baseUrl, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokenString) > 0 {
+		req.Header.Set("Authorization", fmt.Sprintf("Token %s", tokenString))
+	}
+
+	return client.Do(req)
+}
+
+// AuthorizeAndGetRedirectQuery returns the query of the URI the authorization redirects to, which
+// must be the redirect_uri of the query when it has one.
+func AuthorizeAndGetRedirectQuery(query url.Values, tokenString string) (url.Values, error) {
+	response, err := Authorize(query, tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusFound {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusFound)
+	}
+
+	location, err := url.Parse(response.Header.Get("Location"))
+	if err != nil {
+		return nil, err
+	}
+
+	redirectUri := fmt.Sprintf("%s://%s%s", location.Scheme, location.Host, location.Path)
+	if query.Has("redirect_uri") && redirectUri != query.Get("redirect_uri") {
+		return nil, fmt.Errorf("got %s, want %s", redirectUri, query.Get("redirect_uri"))
+	}
+
+	return location.Query(), nil
+}
+
+func AuthorizeAndGetCode(query url.Values, tokenString string) (*string, error) {
+	redirectQuery, err := AuthorizeAndGetRedirectQuery(query, tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	code := redirectQuery.Get("code")
+	if len(code) == 0 {
+		return nil, fmt.Errorf("got no code, error %s", redirectQuery.Get("error"))
+	}
+
+	if redirectQuery.Get("state") != query.Get("state") {
+		return nil, fmt.Errorf("got state %s, want %s", redirectQuery.Get("state"), query.Get("state"))
+	}
+
+	return &code, nil
+}
+
+// ExchangeCode redeems the code at the token endpoint, authenticating the client with
+// client_secret_basic. The redirect_uri is omitted when the client has none.
+func ExchangeCode(client Client, code string, codeVerifier string) (*http.Response, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	if len(client.RedirectUri) > 0 {
+		form.Set("redirect_uri", client.RedirectUri)
+	}
+	if len(codeVerifier) > 0 {
+		form.Set("code_verifier", codeVerifier)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/oauth/token", baseUrl), strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(client.Id), url.QueryEscape(client.Secret))
+
+	return http.DefaultClient.Do(req)
+}
+
+func ExchangeCodeAndDecode(client Client, code string, codeVerifier string) (*TokenResponse, error) {
+	response, err := ExchangeCode(client, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	defer response.Body.Close()
+
+	responseData := &TokenResponse{}
+	err = json.NewDecoder(response.Body).Decode(&responseData)
+	if err != nil {
+		return nil, err
+	}
+
+	return responseData, nil
+}
+
+func DecodeErrorResponse(response *http.Response) (*ErrorResponse, error) {
+	defer response.Body.Close()
+
+	responseData := &ErrorResponse{}
+	err := json.NewDecoder(response.Body).Decode(&responseData)
+	if err != nil {
+		return nil, err
+	}
+
+	return responseData, nil
+}
+
+func GetUserinfo(accessToken string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/oauth/userinfo", baseUrl), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+
+	return http.DefaultClient.Do(req)
+}
+
+func PostUserinfo(accessToken string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/oauth/userinfo", baseUrl), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+
+	return http.DefaultClient.Do(req)
+}
+
+// AccessTokenClaims are the claims of access tokens limiting what clients can do as the user.
+type AccessTokenClaims struct {
+	jwt.RegisteredClaims
+	Roles []string `json:"roles"`
+	Scope string   `json:"scope"`
+}
+
+// ParseAccessToken returns the claims of the access token without verifying it, as clients
+// can't verify access tokens.
+func ParseAccessToken(accessToken string) (*AccessTokenClaims, error) {
+	claims := &AccessTokenClaims{}
+
+	_, _, err := jwt.NewParser().ParseUnverified(accessToken, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func GetUserinfoAndDecode(accessToken string) (*UserinfoResponse, error) {
+	response, err := GetUserinfo(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	defer response.Body.Close()
+
+	responseData := &UserinfoResponse{}
+	err = json.NewDecoder(response.Body).Decode(&responseData)
+	if err != nil {
+		return nil, err
+	}
+
+	return responseData, nil
+}
+
+// ParseIdToken verifies the ID token the way client libraries do: with the client secret when
+// it's signed with HMAC, or with the key of the JWKS otherwise.
+func ParseIdToken(client Client, issuer string, idToken string) (*IdTokenClaims, error) {
+	claims := &IdTokenClaims{}
+
+	_, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			return []byte(client.Secret), nil
+		case *jwt.SigningMethodRSA:
+			return getJwksRsaPublicKey(token.Header["kid"])
+		default:
+			return nil, fmt.Errorf("unexpected signing method %s", token.Header["alg"])
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Issuer != issuer {
+		return nil, fmt.Errorf("got issuer %s, want %s", claims.Issuer, issuer)
+	}
+
+	if !claims.VerifyAudience(client.Id, true) {
+		return nil, fmt.Errorf("got audience %v, want %s", claims.Audience, client.Id)
+	}
+
+	return claims, nil
+}
+
+func getJwksRsaPublicKey(kid interface{}) (*rsa.PublicKey, error) {
+	response, err := http.Get(fmt.Sprintf("%s/.well-known/jwks.json", baseUrl))
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	jwks := struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}{}
+	err = json.NewDecoder(response.Body).Decode(&jwks)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range jwks.Keys {
+		if key.Kty != "RSA" || key.Kid != kid {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	}
+
+	return nil, fmt.Errorf("no RSA key %v in the JWKS", kid)
+}
+
+func CodeChallenge(codeVerifier string) string {
+	hash := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+func getEnvOrDefault(name string, defaultValue string) string {
+	value := os.Getenv(name)
+	if len(value) == 0 {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,558 @@
+package oidc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/bxcodec/faker/v3"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/test/users"
+)
+
+func TestWhenGetDiscoveryShouldReturnEndpoints(t *testing.T) {
+	discovery, err := GetDiscoveryAndDecode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if discovery.AuthorizationEndpoint != discovery.Issuer+"/oauth/authorize" {
+		t.Fatalf("got %s, want %s", discovery.AuthorizationEndpoint, discovery.Issuer+"/oauth/authorize")
+	}
+
+	if discovery.TokenEndpoint != discovery.Issuer+"/oauth/token" {
+		t.Fatalf("got %s, want %s", discovery.TokenEndpoint, discovery.Issuer+"/oauth/token")
+	}
+
+	if discovery.UserinfoEndpoint != discovery.Issuer+"/oauth/userinfo" {
+		t.Fatalf("got %s, want %s", discovery.UserinfoEndpoint, discovery.Issuer+"/oauth/userinfo")
+	}
+
+	if discovery.JwksUri != discovery.Issuer+"/.well-known/jwks.json" {
+		t.Fatalf("got %s, want %s", discovery.JwksUri, discovery.Issuer+"/.well-known/jwks.json")
+	}
+
+	if len(discovery.IdTokenSigningAlgValuesSupported) != 1 {
+		t.Fatalf("got %d, want %d", len(discovery.IdTokenSigningAlgValuesSupported), 1)
+	}
+}
+
+func TestGivenUserIsAuthenticatedWhenAuthorizationCodeFlowShouldReturnTokens(t *testing.T) {
+	client := GetClient()
+
+	discovery, err := GetDiscoveryAndDecode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := faker.UUIDDigit()
+	state := faker.UUIDDigit()
+
+	code, err := AuthorizeAndGetCode(NewAuthorizationQuery(client, state, nonce, "profile", "email"), user.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenResponse, err := ExchangeCodeAndDecode(client, *code, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tokenResponse.TokenType != "Bearer" {
+		t.Fatalf("got %s, want %s", tokenResponse.TokenType, "Bearer")
+	}
+
+	if tokenResponse.Scope != "openid profile email" {
+		t.Fatalf("got %s, want %s", tokenResponse.Scope, "openid profile email")
+	}
+
+	claims, err := ParseIdToken(client, discovery.Issuer, tokenResponse.IdToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claims.Nonce != nonce {
+		t.Fatalf("got %s, want %s", claims.Nonce, nonce)
+	}
+
+	if claims.PreferredUsername != user.User.Username {
+		t.Fatalf("got %s, want %s", claims.PreferredUsername, user.User.Username)
+	}
+
+	if claims.Email != user.User.Email {
+		t.Fatalf("got %s, want %s", claims.Email, user.User.Email)
+	}
+
+	if claims.EmailVerified == nil || *claims.EmailVerified {
+		t.Fatal("EmailVerified must be false")
+	}
+
+	if claims.AuthTime == 0 {
+		t.Fatal("AuthTime must be set")
+	}
+
+	userinfo, err := GetUserinfoAndDecode(tokenResponse.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if userinfo.Subject != claims.Subject {
+		t.Fatalf("got %s, want %s", userinfo.Subject, claims.Subject)
+	}
+
+	if userinfo.PreferredUsername != user.User.Username {
+		t.Fatalf("got %s, want %s", userinfo.PreferredUsername, user.User.Username)
+	}
+}
+
+func TestGivenOnlyOpenidScopeWhenExchangeCodeShouldNotReturnProfileClaims(t *testing.T) {
+	client := GetClient()
+
+	discovery, err := GetDiscoveryAndDecode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := AuthorizeAndGetCode(NewAuthorizationQuery(client, faker.UUIDDigit(), faker.UUIDDigit()), user.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenResponse, err := ExchangeCodeAndDecode(client, *code, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := ParseIdToken(client, discovery.Issuer, tokenResponse.IdToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(claims.Subject) == 0 {
+		t.Fatal("Subject must be set")
+	}
+
+	if len(claims.PreferredUsername) > 0 || len(claims.Email) > 0 || claims.EmailVerified != nil {
+		t.Fatal("profile and email claims must not be set")
+	}
+}
+
+func TestGivenUserIsNotAuthenticatedWhenAuthorizeShouldRedirectWithLoginRequired(t *testing.T) {
+	client := GetClient()
+
+	query := NewAuthorizationQuery(client, faker.UUIDDigit(), faker.UUIDDigit())
+	query.Set("prompt", "none")
+
+	redirectQuery, err := AuthorizeAndGetRedirectQuery(query, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if redirectQuery.Get("error") != "login_required" {
+		t.Fatalf("got %s, want %s", redirectQuery.Get("error"), "login_required")
+	}
+
+	if redirectQuery.Get("state") != query.Get("state") {
+		t.Fatalf("got %s, want %s", redirectQuery.Get("state"), query.Get("state"))
+	}
+}
+
+func TestGivenRedirectUriIsNotRegisteredWhenAuthorizeShouldReturnBadRequest(t *testing.T) {
+	client := GetClient()
+
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := NewAuthorizationQuery(client, faker.UUIDDigit(), faker.UUIDDigit())
+	query.Set("redirect_uri", "https://attacker.example.com/callback")
+
+	response, err := Authorize(query, user.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusBadRequest {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusBadRequest)
+	}
+
+	errorResponse, err := DecodeErrorResponse(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if errorResponse.Error != "invalid_request" {
+		t.Fatalf("got %s, want %s", errorResponse.Error, "invalid_request")
+	}
+}
+
+func TestGivenOpenidScopeIsMissingWhenAuthorizeShouldRedirectWithInvalidScope(t *testing.T) {
+	client := GetClient()
+
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := NewAuthorizationQuery(client, faker.UUIDDigit(), faker.UUIDDigit())
+	query.Set("scope", "profile")
+
+	redirectQuery, err := AuthorizeAndGetRedirectQuery(query, user.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if redirectQuery.Get("error") != "invalid_scope" {
+		t.Fatalf("got %s, want %s", redirectQuery.Get("error"), "invalid_scope")
+	}
+}
+
+func TestGivenRequestAcceptsJsonWhenAuthorizeShouldReturnRedirectUri(t *testing.T) {
+	client := GetClient()
+
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := NewAuthorizationQuery(client, faker.UUIDDigit(), faker.UUIDDigit())
+
+	req, err := http.NewRequest(http.MethodPost, baseUrl+"/oauth/authorize", strings.NewReader(query.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Token "+user.User.Token)
+
+	response, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	defer response.Body.Close()
+
+	responseData := struct {
+		RedirectUri string `json:"redirectUri"`
+	}{}
+	err = json.NewDecoder(response.Body).Decode(&responseData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redirectUri, err := url.Parse(responseData.RedirectUri)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ExchangeCodeAndDecode(client, redirectUri.Query().Get("code"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGivenCodeWasExchangedWhenExchangeCodeAgainShouldReturnInvalidGrant(t *testing.T) {
+	client := GetClient()
+
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := AuthorizeAndGetCode(NewAuthorizationQuery(client, faker.UUIDDigit(), faker.UUIDDigit()), user.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ExchangeCodeAndDecode(client, *code, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := ExchangeCode(client, *code, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusBadRequest {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusBadRequest)
+	}
+
+	errorResponse, err := DecodeErrorResponse(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if errorResponse.Error != "invalid_grant" {
+		t.Fatalf("got %s, want %s", errorResponse.Error, "invalid_grant")
+	}
+}
+
+func TestGivenClientSecretIsWrongWhenExchangeCodeShouldReturnUnauthorized(t *testing.T) {
+	client := GetClient()
+
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := AuthorizeAndGetCode(NewAuthorizationQuery(client, faker.UUIDDigit(), faker.UUIDDigit()), user.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.Secret = "wrong-secret"
+
+	response, err := ExchangeCode(client, *code, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+
+	errorResponse, err := DecodeErrorResponse(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if errorResponse.Error != "invalid_client" {
+		t.Fatalf("got %s, want %s", errorResponse.Error, "invalid_client")
+	}
+}
+
+func TestGivenCodeChallengeWhenExchangeCodeWithWrongVerifierShouldReturnInvalidGrant(t *testing.T) {
+	client := GetClient()
+
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codeVerifier := faker.Password() + faker.Password()
+
+	query := NewAuthorizationQuery(client, faker.UUIDDigit(), faker.UUIDDigit())
+	query.Set("code_challenge", CodeChallenge(codeVerifier))
+	query.Set("code_challenge_method", "S256")
+
+	code, err := AuthorizeAndGetCode(query, user.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := ExchangeCode(client, *code, "wrong"+codeVerifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusBadRequest {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusBadRequest)
+	}
+
+	errorResponse, err := DecodeErrorResponse(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if errorResponse.Error != "invalid_grant" {
+		t.Fatalf("got %s, want %s", errorResponse.Error, "invalid_grant")
+	}
+}
+
+func TestGivenCodeChallengeWhenExchangeCodeWithVerifierShouldReturnTokens(t *testing.T) {
+	client := GetClient()
+
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codeVerifier := faker.Password() + faker.Password()
+
+	query := NewAuthorizationQuery(client, faker.UUIDDigit(), faker.UUIDDigit())
+	query.Set("code_challenge", CodeChallenge(codeVerifier))
+	query.Set("code_challenge_method", "S256")
+
+	code, err := AuthorizeAndGetCode(query, user.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ExchangeCodeAndDecode(client, *code, codeVerifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGivenRedirectUriWasOmittedWhenExchangeCodeWithoutRedirectUriShouldReturnTokens(t *testing.T) {
+	client := GetClient()
+	client.RedirectUri = ""
+
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := AuthorizeAndGetCode(NewAuthorizationQuery(client, faker.UUIDDigit(), faker.UUIDDigit()), user.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ExchangeCodeAndDecode(client, *code, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGivenRedirectUriWasSentWhenExchangeCodeWithoutRedirectUriShouldReturnInvalidGrant(t *testing.T) {
+	client := GetClient()
+
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := AuthorizeAndGetCode(NewAuthorizationQuery(client, faker.UUIDDigit(), faker.UUIDDigit()), user.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientWithoutRedirectUri := client
+	clientWithoutRedirectUri.RedirectUri = ""
+
+	response, err := ExchangeCode(clientWithoutRedirectUri, *code, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusBadRequest {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusBadRequest)
+	}
+
+	errorResponse, err := DecodeErrorResponse(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if errorResponse.Error != "invalid_grant" {
+		t.Fatalf("got %s, want %s", errorResponse.Error, "invalid_grant")
+	}
+}
+
+func TestWhenExchangeCodeShouldReturnAccessTokenWithOnlyGrantedScopes(t *testing.T) {
+	client := GetClient()
+
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := AuthorizeAndGetCode(NewAuthorizationQuery(client, faker.UUIDDigit(), faker.UUIDDigit(), "profile"), user.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenResponse, err := ExchangeCodeAndDecode(client, *code, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accessTokenClaims, err := ParseAccessToken(tokenResponse.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if accessTokenClaims.Scope != "openid profile" {
+		t.Fatalf("got %s, want %s", accessTokenClaims.Scope, "openid profile")
+	}
+
+	if len(accessTokenClaims.Roles) != 0 {
+		t.Fatalf("got roles %v, want none", accessTokenClaims.Roles)
+	}
+
+	userinfo, err := GetUserinfoAndDecode(tokenResponse.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if userinfo.PreferredUsername != user.User.Username {
+		t.Fatalf("got %s, want %s", userinfo.PreferredUsername, user.User.Username)
+	}
+
+	if len(userinfo.Email) > 0 {
+		t.Fatal("email claim must not be set")
+	}
+
+	response, err := PostUserinfo(tokenResponse.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	response, err = users.GetCurrentUser(tokenResponse.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusForbidden {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusForbidden)
+	}
+
+	response, err = users.UpdateUser(tokenResponse.AccessToken, users.UpdateUserRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusForbidden {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusForbidden)
+	}
+}
+
+func TestGivenIdTokenWhenGetCurrentUserShouldReturnUnauthorized(t *testing.T) {
+	client := GetClient()
+
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := AuthorizeAndGetCode(NewAuthorizationQuery(client, faker.UUIDDigit(), faker.UUIDDigit(), "profile", "email"), user.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenResponse, err := ExchangeCodeAndDecode(client, *code, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := users.GetCurrentUser(tokenResponse.IdToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+}
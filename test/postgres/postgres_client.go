@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/postgres"
+)
+
+// Connect returns a pool to the migrated database of POSTGRES_TEST_URL, skipping the test when
+// it's not set.
+func Connect(t *testing.T) *pgxpool.Pool {
+	url := os.Getenv("POSTGRES_TEST_URL")
+	if len(url) == 0 {
+		t.Skip("Environment variable 'POSTGRES_TEST_URL' is not set")
+	}
+
+	pool, err := postgres.InitPostgres(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	err = postgres.Migrate(context.Background(), pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pool
+}
+
+// GetCanonicalKeys returns the keys stored for the user.
+func GetCanonicalKeys(pool *pgxpool.Pool, id string) (string, string, error) {
+	var usernameKey, emailKey string
+	err := pool.QueryRow(context.Background(), "SELECT username_key, email_key FROM users WHERE id = $1", id).Scan(&usernameKey, &emailKey)
+	return usernameKey, emailKey, err
+}
@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bxcodec/faker/v3"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/custom_errors"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/postgres"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/internal/users"
+)
+
+func newFakeUser(username string, email string) users.User {
+	return users.NewUser("", username, email, false, "hash", nil, nil, nil)
+}
+
+func TestWhenMigrateTwiceShouldSucceed(t *testing.T) {
+	pool := Connect(t)
+
+	err := postgres.Migrate(context.Background(), pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWhenCreateUserShouldStoreCanonicalKeysComputedByTheService(t *testing.T) {
+	pool := Connect(t)
+	userRepository := users.NewPostgresUserRepository(pool)
+
+	suffix := strings.ToLower(faker.Username())
+
+	// The ligature and the dotted capital I are changed by NFKC and Go's lowercasing in ways
+	// lower() doesn't in every collation.
+	user, err := userRepository.Create(context.Background(), newFakeUser(" ﬁİ"+suffix+" ", "ﬁİ"+suffix+"@Example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	usernameKey, emailKey, err := GetCanonicalKeys(pool, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if usernameKey != "fii"+suffix {
+		t.Fatalf("got %s, want %s", usernameKey, "fii"+suffix)
+	}
+
+	if emailKey != "fii"+suffix+"@example.com" {
+		t.Fatalf("got %s, want %s", emailKey, "fii"+suffix+"@example.com")
+	}
+
+	foundUser, err := userRepository.GetByUsername(context.Background(), "FIİ"+suffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if foundUser.Id != user.Id {
+		t.Fatalf("got %s, want %s", foundUser.Id, user.Id)
+	}
+}
+
+func TestGivenCanonicalUsernameIsTakenWhenCreateUserShouldReturnAlreadyExists(t *testing.T) {
+	pool := Connect(t)
+	userRepository := users.NewPostgresUserRepository(pool)
+
+	username := "Élodie" + faker.Username()
+
+	_, err := userRepository.Create(context.Background(), newFakeUser(username, faker.Email()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = userRepository.Create(context.Background(), newFakeUser("ÉLODIE"+username[len("Élodie"):], faker.Email()))
+	if _, ok := err.(*custom_errors.AlreadyExistsError); !ok {
+		t.Fatalf("got %v, want an AlreadyExistsError", err)
+	}
+}
+
+func TestGivenCanonicalEmailIsTakenWhenUpdateUserShouldReturnAlreadyExists(t *testing.T) {
+	pool := Connect(t)
+	userRepository := users.NewPostgresUserRepository(pool)
+
+	email := faker.Email()
+
+	_, err := userRepository.Create(context.Background(), newFakeUser(faker.Username(), email))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := userRepository.Create(context.Background(), newFakeUser(faker.Username(), faker.Email()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user.Email = " " + email + " "
+
+	_, err = userRepository.Update(context.Background(), *user)
+	if _, ok := err.(*custom_errors.AlreadyExistsError); !ok {
+		t.Fatalf("got %v, want an AlreadyExistsError", err)
+	}
+}
+
+func TestGivenUserDoesNotExistShouldReturnNotFound(t *testing.T) {
+	pool := Connect(t)
+	userRepository := users.NewPostgresUserRepository(pool)
+
+	_, err := userRepository.GetById(context.Background(), "unknown")
+	if _, ok := err.(*custom_errors.NotFoundError); !ok {
+		t.Fatalf("got %v, want a NotFoundError", err)
+	}
+
+	_, err = userRepository.Update(context.Background(), newFakeUser(faker.Username(), faker.Email()))
+	if _, ok := err.(*custom_errors.NotFoundError); !ok {
+		t.Fatalf("got %v, want a NotFoundError", err)
+	}
+
+	err = userRepository.Delete(context.Background(), "unknown")
+	if _, ok := err.(*custom_errors.NotFoundError); !ok {
+		t.Fatalf("got %v, want a NotFoundError", err)
+	}
+}
+
+func TestGivenKeysWereComputedInSqlWhenBackfillCanonicalKeysShouldRewriteThem(t *testing.T) {
+	pool := Connect(t)
+	userRepository := users.NewPostgresUserRepository(pool)
+
+	suffix := strings.ToLower(faker.Username())
+
+	user, err := userRepository.Create(context.Background(), newFakeUser("İ"+suffix, "İ"+suffix+"@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Like the keys migration 0002 computed for the users stored before it.
+	_, err = pool.Exec(context.Background(), "UPDATE users SET username_key = 'stale-' || id, email_key = 'stale-' || id WHERE id = $1", user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = userRepository.BackfillCanonicalKeys(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	usernameKey, emailKey, err := GetCanonicalKeys(pool, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if usernameKey != "i"+suffix {
+		t.Fatalf("got %s, want %s", usernameKey, "i"+suffix)
+	}
+
+	if emailKey != "i"+suffix+"@example.com" {
+		t.Fatalf("got %s, want %s", emailKey, "i"+suffix+"@example.com")
+	}
+}
@@ -0,0 +1,101 @@
+package profiles
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bxcodec/faker/v3"
+)
+
+func TestGivenUserExistsWhenFollowUserShouldReturnProfileFollowing(t *testing.T) {
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	follower, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := FollowUserAndDecode(user.User.Username, follower.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if profile.Profile.Username != user.User.Username {
+		t.Fatalf("got %s, want %s", profile.Profile.Username, user.User.Username)
+	}
+
+	if !profile.Profile.Following {
+		t.Fatalf("got %t, want %t", profile.Profile.Following, true)
+	}
+
+	// Following twice is a no-op.
+	profile, err = FollowUserAndDecode(user.User.Username, follower.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !profile.Profile.Following {
+		t.Fatalf("got %t, want %t", profile.Profile.Following, true)
+	}
+
+	// Following isn't mutual.
+	reverseProfile, err := GetProfileAndDecode(follower.User.Username, user.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reverseProfile.Profile.Following {
+		t.Fatalf("got %t, want %t", reverseProfile.Profile.Following, false)
+	}
+}
+
+func TestGivenUserIsCallerWhenFollowUserShouldReturnUnprocessableEntity(t *testing.T) {
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := FollowUser(user.User.Username, user.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestGivenUserDoesNotExistWhenFollowUserShouldReturnNotFound(t *testing.T) {
+	follower, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := FollowUser(faker.Username(), follower.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusNotFound {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestGivenAnonymousRequestWhenFollowUserShouldReturnUnauthorized(t *testing.T) {
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := FollowUser(user.User.Username, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,90 @@
+package profiles
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bxcodec/faker/v3"
+)
+
+func TestGivenAnonymousRequestWhenGetProfileShouldReturnProfileNotFollowing(t *testing.T) {
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := GetProfileAndDecode(user.User.Username, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if profile.Profile.Username != user.User.Username {
+		t.Fatalf("got %s, want %s", profile.Profile.Username, user.User.Username)
+	}
+
+	if profile.Profile.Following {
+		t.Fatalf("got %t, want %t", profile.Profile.Following, false)
+	}
+}
+
+func TestGivenCallerFollowsUserWhenGetProfileShouldReturnFollowing(t *testing.T) {
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	follower, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = FollowUserAndDecode(user.User.Username, follower.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := GetProfileAndDecode(user.User.Username, follower.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !profile.Profile.Following {
+		t.Fatalf("got %t, want %t", profile.Profile.Following, true)
+	}
+
+	anonymousProfile, err := GetProfileAndDecode(user.User.Username, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if anonymousProfile.Profile.Following {
+		t.Fatalf("got %t, want %t", anonymousProfile.Profile.Following, false)
+	}
+}
+
+func TestGivenUserDoesNotExistWhenGetProfileShouldReturnNotFound(t *testing.T) {
+	response, err := GetProfile(faker.Username(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusNotFound {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestGivenTokenIsInvalidWhenGetProfileShouldReturnUnauthorized(t *testing.T) {
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := GetProfile(user.User.Username, "invalid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,194 @@
+package profiles
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bxcodec/faker/v3"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGivenUserHasFollowersWhenListFollowersShouldReturnPagesNewestFirst(t *testing.T) {
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	followerUsernames := []string{}
+	for i := 0; i < 3; i++ {
+		follower, err := RegisterFakeUser()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = FollowUserAndDecode(user.User.Username, follower.User.Token)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		followerUsernames = append([]string{follower.User.Username}, followerUsernames...)
+	}
+
+	firstPage, err := ListFollowersAndDecode(user.User.Username, "", 2, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if firstPage.NextCursor == nil {
+		t.Fatal("got nil, want a cursor")
+	}
+
+	secondPage, err := ListFollowersAndDecode(user.User.Username, "", 2, *firstPage.NextCursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if secondPage.NextCursor != nil {
+		t.Fatalf("got %s, want nil", *secondPage.NextCursor)
+	}
+
+	usernames := []string{}
+	for _, profile := range append(firstPage.Profiles, secondPage.Profiles...) {
+		usernames = append(usernames, profile.Username)
+	}
+
+	if !cmp.Equal(usernames, followerUsernames) {
+		t.Fatalf(cmp.Diff(usernames, followerUsernames))
+	}
+
+	profile, err := GetProfileAndDecode(user.User.Username, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if profile.Profile.FollowersCount != 3 {
+		t.Fatalf("got %d, want %d", profile.Profile.FollowersCount, 3)
+	}
+
+	if profile.Profile.FollowingCount != 0 {
+		t.Fatalf("got %d, want %d", profile.Profile.FollowingCount, 0)
+	}
+}
+
+func TestGivenViewerFollowsAFollowerWhenListFollowersShouldReturnFollowing(t *testing.T) {
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	follower, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	viewer, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = FollowUserAndDecode(user.User.Username, follower.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = FollowUserAndDecode(follower.User.Username, viewer.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	followers, err := ListFollowersAndDecode(user.User.Username, viewer.User.Token, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(followers.Profiles) != 1 {
+		t.Fatalf("got %d, want %d", len(followers.Profiles), 1)
+	}
+
+	if !followers.Profiles[0].Following {
+		t.Fatalf("got %t, want %t", followers.Profiles[0].Following, true)
+	}
+}
+
+func TestGivenFollowerUnfollowedWhenListFollowersShouldNotReturnFollower(t *testing.T) {
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	follower, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = FollowUserAndDecode(user.User.Username, follower.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = UnfollowUserAndDecode(user.User.Username, follower.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	followers, err := ListFollowersAndDecode(user.User.Username, "", 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(followers.Profiles) != 0 {
+		t.Fatalf("got %d, want %d", len(followers.Profiles), 0)
+	}
+
+	profile, err := GetProfileAndDecode(user.User.Username, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if profile.Profile.FollowersCount != 0 {
+		t.Fatalf("got %d, want %d", profile.Profile.FollowersCount, 0)
+	}
+}
+
+func TestGivenCursorIsInvalidWhenListFollowersShouldReturnUnprocessableEntity(t *testing.T) {
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := ListFollowers(user.User.Username, "", 0, "invalid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestGivenLimitIsTooLargeWhenListFollowersShouldReturnUnprocessableEntity(t *testing.T) {
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := ListFollowers(user.User.Username, "", 1000, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestGivenUserDoesNotExistWhenListFollowersShouldReturnNotFound(t *testing.T) {
+	response, err := ListFollowers(faker.Username(), "", 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusNotFound {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNotFound)
+	}
+}
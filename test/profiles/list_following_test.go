@@ -0,0 +1,76 @@
+package profiles
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bxcodec/faker/v3"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGivenUserFollowsUsersWhenListFollowingShouldReturnPagesNewestFirst(t *testing.T) {
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	followeeUsernames := []string{}
+	for i := 0; i < 3; i++ {
+		followee, err := RegisterFakeUser()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = FollowUserAndDecode(followee.User.Username, user.User.Token)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		followeeUsernames = append([]string{followee.User.Username}, followeeUsernames...)
+	}
+
+	usernames := []string{}
+	cursor := ""
+	for {
+		page, err := ListFollowingAndDecode(user.User.Username, user.User.Token, 1, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, profile := range page.Profiles {
+			if !profile.Following {
+				t.Fatalf("got %t, want %t", profile.Following, true)
+			}
+			usernames = append(usernames, profile.Username)
+		}
+
+		if page.NextCursor == nil {
+			break
+		}
+		cursor = *page.NextCursor
+	}
+
+	if !cmp.Equal(usernames, followeeUsernames) {
+		t.Fatalf(cmp.Diff(usernames, followeeUsernames))
+	}
+
+	profile, err := GetProfileAndDecode(user.User.Username, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if profile.Profile.FollowingCount != 3 {
+		t.Fatalf("got %d, want %d", profile.Profile.FollowingCount, 3)
+	}
+}
+
+func TestGivenUserDoesNotExistWhenListFollowingShouldReturnNotFound(t *testing.T) {
+	response, err := ListFollowing(faker.Username(), "", 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusNotFound {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNotFound)
+	}
+}
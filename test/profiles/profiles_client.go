@@ -0,0 +1,172 @@
+package profiles
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/bxcodec/faker/v3"
+	"github.com/marcusmonteirodesouza/go-microservices-realworld-example-app-users-service/test/users"
+)
+
+type ProfileResponse struct {
+	Profile struct {
+		Username       string `json:"username"`
+		Bio            string `json:"bio"`
+		Image          string `json:"image"`
+		Following      bool   `json:"following"`
+		FollowersCount int    `json:"followersCount"`
+		FollowingCount int    `json:"followingCount"`
+	} `json:"profile"`
+}
+
+type ProfilesResponse struct {
+	Profiles []struct {
+		Username  string `json:"username"`
+		Bio       string `json:"bio"`
+		Image     string `json:"image"`
+		Following bool   `json:"following"`
+	} `json:"profiles"`
+	NextCursor *string `json:"nextCursor"`
+}
+
+func RegisterFakeUser() (*users.UserResponse, error) {
+	requestData := users.RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		return nil, err
+	}
+
+	return users.RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+}
+
+func GetProfile(username string, tokenString string) (*http.Response, error) {
+	return doProfileRequest("GET", fmt.Sprintf("http://localhost:8080/profiles/%s", username), tokenString)
+}
+
+func GetProfileAndDecode(username string, tokenString string) (*ProfileResponse, error) {
+	response, err := GetProfile(username, tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeProfileResponse(response)
+}
+
+func FollowUser(username string, tokenString string) (*http.Response, error) {
+	return doProfileRequest("POST", fmt.Sprintf("http://localhost:8080/profiles/%s/follow", username), tokenString)
+}
+
+func FollowUserAndDecode(username string, tokenString string) (*ProfileResponse, error) {
+	response, err := FollowUser(username, tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeProfileResponse(response)
+}
+
+func UnfollowUser(username string, tokenString string) (*http.Response, error) {
+	return doProfileRequest("DELETE", fmt.Sprintf("http://localhost:8080/profiles/%s/follow", username), tokenString)
+}
+
+func UnfollowUserAndDecode(username string, tokenString string) (*ProfileResponse, error) {
+	response, err := UnfollowUser(username, tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeProfileResponse(response)
+}
+
+func ListFollowers(username string, tokenString string, limit int, cursor string) (*http.Response, error) {
+	return doProfileRequest("GET", profilesListUrl(username, "followers", limit, cursor), tokenString)
+}
+
+func ListFollowersAndDecode(username string, tokenString string, limit int, cursor string) (*ProfilesResponse, error) {
+	response, err := ListFollowers(username, tokenString, limit, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeProfilesResponse(response)
+}
+
+func ListFollowing(username string, tokenString string, limit int, cursor string) (*http.Response, error) {
+	return doProfileRequest("GET", profilesListUrl(username, "following", limit, cursor), tokenString)
+}
+
+func ListFollowingAndDecode(username string, tokenString string, limit int, cursor string) (*ProfilesResponse, error) {
+	response, err := ListFollowing(username, tokenString, limit, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeProfilesResponse(response)
+}
+
+func profilesListUrl(username string, list string, limit int, cursor string) string {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", fmt.Sprint(limit))
+	}
+	if len(cursor) > 0 {
+		query.Set("cursor", cursor)
+	}
+
+	return fmt.Sprintf("http://localhost:8080/profiles/%s/%s?%s", username, list, query.Encode())
+}
+
+func doProfileRequest(method string, url string, tokenString string) (*http.Response, error) {
+	client := &http.Client{}
+
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokenString) > 0 {
+		req.Header.Set("Authorization", fmt.Sprintf("Token %s", tokenString))
+	}
+
+	response, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func decodeProfileResponse(response *http.Response) (*ProfileResponse, error) {
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	defer response.Body.Close()
+
+	responseData := &ProfileResponse{}
+	err := json.NewDecoder(response.Body).Decode(&responseData)
+	if err != nil {
+		return nil, err
+	}
+
+	return responseData, nil
+}
+
+func decodeProfilesResponse(response *http.Response) (*ProfilesResponse, error) {
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	defer response.Body.Close()
+
+	responseData := &ProfilesResponse{}
+	err := json.NewDecoder(response.Body).Decode(&responseData)
+	if err != nil {
+		return nil, err
+	}
+
+	return responseData, nil
+}
@@ -0,0 +1,78 @@
+package profiles
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGivenCallerFollowsUserWhenUnfollowUserShouldReturnProfileNotFollowing(t *testing.T) {
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	follower, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = FollowUserAndDecode(user.User.Username, follower.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := UnfollowUserAndDecode(user.User.Username, follower.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if profile.Profile.Following {
+		t.Fatalf("got %t, want %t", profile.Profile.Following, false)
+	}
+
+	profile, err = GetProfileAndDecode(user.User.Username, follower.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if profile.Profile.Following {
+		t.Fatalf("got %t, want %t", profile.Profile.Following, false)
+	}
+}
+
+func TestGivenCallerDoesNotFollowUserWhenUnfollowUserShouldReturnProfileNotFollowing(t *testing.T) {
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	follower, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := UnfollowUserAndDecode(user.User.Username, follower.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if profile.Profile.Following {
+		t.Fatalf("got %t, want %t", profile.Profile.Following, false)
+	}
+}
+
+func TestGivenAnonymousRequestWhenUnfollowUserShouldReturnUnauthorized(t *testing.T) {
+	user, err := RegisterFakeUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := UnfollowUser(user.User.Username, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,99 @@
+package users
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type CreateApiKeyRequest struct {
+	ApiKey CreateApiKeyRequestApiKey `json:"apiKey"`
+}
+
+type CreateApiKeyRequestApiKey struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+type ApiKeyResponseApiKey struct {
+	Id        string     `json:"id"`
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+	Key       string     `json:"key"`
+}
+
+type ApiKeyResponse struct {
+	ApiKey ApiKeyResponseApiKey `json:"apiKey"`
+}
+
+type ApiKeysResponse struct {
+	ApiKeys []ApiKeyResponseApiKey `json:"apiKeys"`
+}
+
+func NewCreateApiKeyRequest(name string, scopes []string, expiresAt *time.Time) CreateApiKeyRequest {
+	return CreateApiKeyRequest{
+		ApiKey: CreateApiKeyRequestApiKey{
+			Name:      name,
+			Scopes:    scopes,
+			ExpiresAt: expiresAt,
+		},
+	}
+}
+
+func CreateApiKey(tokenString string, request CreateApiKeyRequest) (*http.Response, error) {
+	return doAuthenticatedRequest("POST", "http://localhost:8080/user/api-keys", tokenString, request)
+}
+
+func CreateApiKeyAndDecode(tokenString string, request CreateApiKeyRequest) (*ApiKeyResponse, error) {
+	response, err := CreateApiKey(tokenString, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusCreated)
+	}
+
+	defer response.Body.Close()
+
+	apiKeyResponse := &ApiKeyResponse{}
+	err = json.NewDecoder(response.Body).Decode(apiKeyResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return apiKeyResponse, nil
+}
+
+func ListApiKeys(tokenString string) (*http.Response, error) {
+	return doAuthenticatedRequest("GET", "http://localhost:8080/user/api-keys", tokenString, nil)
+}
+
+func ListApiKeysAndDecode(tokenString string) (*ApiKeysResponse, error) {
+	response, err := ListApiKeys(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	defer response.Body.Close()
+
+	apiKeysResponse := &ApiKeysResponse{}
+	err = json.NewDecoder(response.Body).Decode(apiKeysResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return apiKeysResponse, nil
+}
+
+func DeleteApiKey(tokenString string, id string) (*http.Response, error) {
+	return doAuthenticatedRequest("DELETE", fmt.Sprintf("http://localhost:8080/user/api-keys/%s", id), tokenString, nil)
+}
@@ -0,0 +1,303 @@
+package users
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bxcodec/faker/v3"
+)
+
+func registerFakeUser(t *testing.T) *UserResponse {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return user
+}
+
+func TestWhenCreateApiKeyShouldAuthenticateWithIt(t *testing.T) {
+	user := registerFakeUser(t)
+
+	apiKey, err := CreateApiKeyAndDecode(user.User.Token, NewCreateApiKeyRequest("CI", nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(apiKey.ApiKey.Key, "ck_"+apiKey.ApiKey.Id+"_") {
+		t.Fatalf("got %s, want a key prefixed by its id %s", apiKey.ApiKey.Key, apiKey.ApiKey.Id)
+	}
+
+	if strings.Join(apiKey.ApiKey.Scopes, " ") != "read write" {
+		t.Fatalf("got %v, want %v", apiKey.ApiKey.Scopes, []string{"read", "write"})
+	}
+
+	if apiKey.ApiKey.ExpiresAt != nil {
+		t.Fatalf("got %v, want nil", apiKey.ApiKey.ExpiresAt)
+	}
+
+	currentUser, err := GetCurrentUserAndDecode(apiKey.ApiKey.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if currentUser.User.Username != user.User.Username {
+		t.Fatalf("got %s, want %s", currentUser.User.Username, user.User.Username)
+	}
+
+	response, err := ResendVerificationEmail(apiKey.ApiKey.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusAccepted {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusAccepted)
+	}
+}
+
+func TestWhenListApiKeysShouldNotReturnKeys(t *testing.T) {
+	user := registerFakeUser(t)
+
+	firstApiKey, err := CreateApiKeyAndDecode(user.User.Token, NewCreateApiKeyRequest("CI", nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secondApiKey, err := CreateApiKeyAndDecode(user.User.Token, NewCreateApiKeyRequest("Scripts", []string{"read"}, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apiKeys, err := ListApiKeysAndDecode(user.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(apiKeys.ApiKeys) != 2 {
+		t.Fatalf("got %d, want %d", len(apiKeys.ApiKeys), 2)
+	}
+
+	if apiKeys.ApiKeys[0].Id != firstApiKey.ApiKey.Id || apiKeys.ApiKeys[1].Id != secondApiKey.ApiKey.Id {
+		t.Fatalf("got %s and %s, want %s and %s", apiKeys.ApiKeys[0].Id, apiKeys.ApiKeys[1].Id, firstApiKey.ApiKey.Id, secondApiKey.ApiKey.Id)
+	}
+
+	if apiKeys.ApiKeys[1].Name != "Scripts" {
+		t.Fatalf("got %s, want %s", apiKeys.ApiKeys[1].Name, "Scripts")
+	}
+
+	for _, apiKey := range apiKeys.ApiKeys {
+		if len(apiKey.Key) > 0 {
+			t.Fatalf("got key %s, want none", apiKey.Key)
+		}
+	}
+}
+
+func TestWhenDeleteApiKeyShouldNotAuthenticateWithIt(t *testing.T) {
+	user := registerFakeUser(t)
+
+	apiKey, err := CreateApiKeyAndDecode(user.User.Token, NewCreateApiKeyRequest("CI", nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := DeleteApiKey(user.User.Token, apiKey.ApiKey.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNoContent)
+	}
+
+	response, err = GetCurrentUser(apiKey.ApiKey.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+
+	apiKeys, err := ListApiKeysAndDecode(user.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(apiKeys.ApiKeys) != 0 {
+		t.Fatalf("got %d, want %d", len(apiKeys.ApiKeys), 0)
+	}
+}
+
+func TestGivenApiKeyOfAnotherUserWhenDeleteApiKeyShouldReturnNotFound(t *testing.T) {
+	user := registerFakeUser(t)
+	otherUser := registerFakeUser(t)
+
+	apiKey, err := CreateApiKeyAndDecode(otherUser.User.Token, NewCreateApiKeyRequest("CI", nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := DeleteApiKey(user.User.Token, apiKey.ApiKey.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusNotFound {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNotFound)
+	}
+
+	_, err = GetCurrentUserAndDecode(apiKey.ApiKey.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGivenApiKeyHasReadScopeWhenWriteShouldReturnForbidden(t *testing.T) {
+	user := registerFakeUser(t)
+
+	apiKey, err := CreateApiKeyAndDecode(user.User.Token, NewCreateApiKeyRequest("Dashboards", []string{"read"}, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = GetCurrentUserAndDecode(apiKey.ApiKey.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := ResendVerificationEmail(apiKey.ApiKey.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusForbidden {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusForbidden)
+	}
+}
+
+func TestGivenApiKeyHasWriteScopeWhenReadShouldReturnForbidden(t *testing.T) {
+	user := registerFakeUser(t)
+
+	apiKey, err := CreateApiKeyAndDecode(user.User.Token, NewCreateApiKeyRequest("Write only", []string{"write"}, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := GetCurrentUser(apiKey.ApiKey.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusForbidden {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusForbidden)
+	}
+}
+
+func TestGivenApiKeyWhenManageApiKeysShouldReturnForbidden(t *testing.T) {
+	user := registerFakeUser(t)
+
+	apiKey, err := CreateApiKeyAndDecode(user.User.Token, NewCreateApiKeyRequest("CI", nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := CreateApiKey(apiKey.ApiKey.Key, NewCreateApiKeyRequest("Escalation", nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusForbidden {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusForbidden)
+	}
+
+	response, err = UpdateUser(apiKey.ApiKey.Key, UpdateUserRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusForbidden {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusForbidden)
+	}
+}
+
+func TestGivenApiKeyHasExpiredWhenAuthenticateShouldReturnUnauthorized(t *testing.T) {
+	user := registerFakeUser(t)
+
+	expiresAt := time.Now().Add(time.Second * 2)
+
+	apiKey, err := CreateApiKeyAndDecode(user.User.Token, NewCreateApiKeyRequest("CI", nil, &expiresAt))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if apiKey.ApiKey.ExpiresAt == nil || !apiKey.ApiKey.ExpiresAt.Equal(expiresAt) {
+		t.Fatalf("got %v, want %v", apiKey.ApiKey.ExpiresAt, expiresAt)
+	}
+
+	_, err = GetCurrentUserAndDecode(apiKey.ApiKey.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(time.Until(expiresAt))
+
+	response, err := GetCurrentUser(apiKey.ApiKey.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestGivenApiKeyIsTamperedWhenAuthenticateShouldReturnUnauthorized(t *testing.T) {
+	user := registerFakeUser(t)
+
+	apiKey, err := CreateApiKeyAndDecode(user.User.Token, NewCreateApiKeyRequest("CI", nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := GetCurrentUser("ck_" + apiKey.ApiKey.Id + "_" + strings.Repeat("A", 43))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestGivenInvalidApiKeyWhenCreateApiKeyShouldReturnUnprocessableEntity(t *testing.T) {
+	user := registerFakeUser(t)
+
+	expiredAt := time.Now().Add(-time.Hour)
+
+	requests := map[string]CreateApiKeyRequest{
+		"empty name":    NewCreateApiKeyRequest(" ", nil, nil),
+		"long name":     NewCreateApiKeyRequest(strings.Repeat("a", 65), nil, nil),
+		"unknown scope": NewCreateApiKeyRequest("CI", []string{"admin"}, nil),
+		"past expiry":   NewCreateApiKeyRequest("CI", nil, &expiredAt),
+	}
+
+	for name, request := range requests {
+		response, err := CreateApiKey(user.User.Token, request)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if response.StatusCode != http.StatusUnprocessableEntity {
+			t.Fatalf("%s: got %d, want %d", name, response.StatusCode, http.StatusUnprocessableEntity)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package users
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bxcodec/faker/v3"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGivenSomeUsersExistWhenBatchGetUsersShouldReturnFoundUsersInOrderAndMissingOnes(t *testing.T) {
+	usernames := []string{}
+	for i := 0; i < 12; i++ {
+		requestData := RegisterUserRequest{}
+
+		err := faker.FakeData(&requestData)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		usernames = append(usernames, registeredUser.User.Username)
+	}
+
+	missingUsername := faker.Username()
+
+	// Lookups are case-insensitive and duplicates are returned once.
+	requestedUsernames := append([]string{strings.ToUpper(usernames[0]), missingUsername}, usernames...)
+
+	response, err := BatchGetUsersAndDecode(BatchGetUsersRequest{
+		Ids:       []string{"missing-id"},
+		Usernames: requestedUsernames,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responseUsernames := []string{}
+	for _, user := range response.Users {
+		if len(user.Id) == 0 {
+			t.Fatalf("got an empty id for User %s", user.Username)
+		}
+
+		if len(user.Email) > 0 {
+			t.Fatalf("got the email of User %s", user.Username)
+		}
+		responseUsernames = append(responseUsernames, user.Username)
+	}
+
+	if !cmp.Equal(responseUsernames, usernames) {
+		t.Fatalf(cmp.Diff(responseUsernames, usernames))
+	}
+
+	if !cmp.Equal(response.MissingIds, []string{"missing-id"}) {
+		t.Fatalf(cmp.Diff(response.MissingIds, []string{"missing-id"}))
+	}
+
+	if !cmp.Equal(response.MissingUsernames, []string{missingUsername}) {
+		t.Fatalf(cmp.Diff(response.MissingUsernames, []string{missingUsername}))
+	}
+
+	byIds, err := BatchGetUsersAndDecode(BatchGetUsersRequest{
+		Ids: []string{response.Users[1].Id, response.Users[0].Id},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(byIds.Users) != 2 || byIds.Users[0].Username != usernames[1] || byIds.Users[1].Username != usernames[0] {
+		t.Fatalf("got %v, want %s and %s", byIds.Users, usernames[1], usernames[0])
+	}
+}
+
+func TestGivenTooManyUsernamesWhenBatchGetUsersShouldReturnUnprocessableEntity(t *testing.T) {
+	usernames := []string{}
+	for i := 0; i < 101; i++ {
+		usernames = append(usernames, fmt.Sprintf("user%d", i))
+	}
+
+	response, err := BatchGetUsers(BatchGetUsersRequest{
+		Usernames: usernames,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
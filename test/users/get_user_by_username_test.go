@@ -1,7 +1,9 @@
 package users
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/bxcodec/faker/v3"
@@ -63,3 +65,76 @@ func TestGivenUserDoesNotExistsWhenGetUserByUsernameShouldReturnNotFound(t *test
 		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNotFound)
 	}
 }
+
+func TestGivenUsernameWithDifferentCasingWhenGetUserByUsernameShouldReturnUserWithOriginalCasing(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := GetUserByUsernameAndDecode(strings.ToUpper(registeredUser.User.Username))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.User.Username != registeredUser.User.Username {
+		t.Fatalf("got %s, want %s", user.User.Username, registeredUser.User.Username)
+	}
+
+	if user.User.Email != registeredUser.User.Email {
+		t.Fatalf("got %s, want %s", user.User.Email, registeredUser.User.Email)
+	}
+}
+
+func TestGivenTokenIsValidWhenGetUserByUsernameShouldReturnUser(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := GetUserByUsernameWithAuthorization(registeredUser.User.Username, fmt.Sprintf("Token %s", registeredUser.User.Token))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+}
+
+func TestGivenTokenIsInvalidWhenGetUserByUsernameShouldReturnUnauthorized(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := GetUserByUsernameWithAuthorization(registeredUser.User.Username, "Token invalid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+}
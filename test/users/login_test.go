@@ -57,7 +57,7 @@ func TestGivenValidRequestWhenLoginShouldReturnUser(t *testing.T) {
 		t.Fatal("Environment variable 'JWT_SECONDS_TO_EXPIRE' must be set and not be empty")
 	}
 
-	parsedToken, err := jwt.ParseWithClaims(loggedUser.User.Token, &jwt.StandardClaims{}, func(token *jwt.Token) (interface{}, error) {
+	parsedToken, err := jwt.ParseWithClaims(loggedUser.User.Token, &TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
 		b := ([]byte(jwtSecretKey))
 		return b, nil
 	})
@@ -66,10 +66,14 @@ func TestGivenValidRequestWhenLoginShouldReturnUser(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	claims := parsedToken.Claims.(*jwt.StandardClaims)
+	claims := parsedToken.Claims.(*TokenClaims)
 
-	if claims.Subject != loggedUser.User.Username {
-		t.Fatalf("got %s, want %s", claims.Subject, requestData.User.Username)
+	if len(claims.Subject) == 0 || claims.Subject == loggedUser.User.Username {
+		t.Fatalf("Subject claim must be the User's id. got %s", claims.Subject)
+	}
+
+	if claims.Username != loggedUser.User.Username {
+		t.Fatalf("got %s, want %s", claims.Username, requestData.User.Username)
 	}
 
 	now := time.Now().Unix()
@@ -86,6 +90,33 @@ func TestGivenValidRequestWhenLoginShouldReturnUser(t *testing.T) {
 	}
 }
 
+func TestGivenEmailWithDifferentCasingWhenLoginShouldReturnUser(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loggedUser, err := LoginAndDecode(strings.ToUpper(registeredUser.User.Email), requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loggedUser.User.Username != registeredUser.User.Username {
+		t.Fatalf("got %s, want %s", loggedUser.User.Username, registeredUser.User.Username)
+	}
+
+	if loggedUser.User.Email != registeredUser.User.Email {
+		t.Fatalf("got %s, want %s", loggedUser.User.Email, registeredUser.User.Email)
+	}
+}
+
 func TestGivenEmailNotFoundWhenLoginShouldReturnUnauthorized(t *testing.T) {
 	requestData := RegisterUserRequest{}
 
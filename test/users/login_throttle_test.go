@@ -0,0 +1,120 @@
+package users
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bxcodec/faker/v3"
+)
+
+func TestGivenLoginFailedWhenLoginAgainImmediatelyShouldReturnTooManyRequests(t *testing.T) {
+	email := faker.Email()
+
+	response, err := Login(email, faker.Password())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+
+	response, err = Login(email, faker.Password())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusTooManyRequests)
+	}
+
+	retryAfter, err := strconv.Atoi(response.Header.Get("Retry-After"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if retryAfter < 1 {
+		t.Fatalf("got Retry-After %d, want at least 1", retryAfter)
+	}
+
+	defer response.Body.Close()
+
+	responseData := &ErrorResponse{}
+	err = json.NewDecoder(response.Body).Decode(&responseData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if responseData.Errors == nil || len(responseData.Errors.Body) != 1 {
+		t.Fatalf("got %v, want one error", responseData.Errors)
+	}
+
+	time.Sleep(time.Duration(retryAfter) * time.Second)
+
+	response, err = Login(email, faker.Password())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestGivenLoginSucceededWhenLoginFailsShouldNotBackOffFromPreviousFailures(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := Login(requestData.User.Email, faker.Password())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+
+	response, err = Login(requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode == http.StatusTooManyRequests {
+		retryAfter, err := strconv.Atoi(response.Header.Get("Retry-After"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		time.Sleep(time.Duration(retryAfter) * time.Second)
+
+		response, err = Login(requestData.User.Email, requestData.User.Password)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	response, err = Login(requestData.User.Email, faker.Password())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,154 @@
+package users
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bxcodec/faker/v3"
+)
+
+func TestGivenValidTokenWhenLogoutShouldRevokeTokens(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := Logout(registeredUser.User.Token, LogoutRequest{RefreshToken: &registeredUser.User.RefreshToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNoContent)
+	}
+
+	response, err = GetCurrentUser(registeredUser.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+
+	response, err = RefreshToken(registeredUser.User.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestGivenValidTokenWhenLogoutShouldNotRevokeOtherTokens(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loggedUser, err := LoginAndDecode(requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := Logout(registeredUser.User.Token, LogoutRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNoContent)
+	}
+
+	_, err = GetCurrentUserAndDecode(loggedUser.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGivenTokenIsInvalidWhenLogoutShouldReturnUnauthorized(t *testing.T) {
+	const invalidToken = " "
+
+	response, err := Logout(invalidToken, LogoutRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestGivenValidTokenWhenLogoutAllShouldRevokeAllTokens(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loggedUser, err := LoginAndDecode(requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Revocations have a one second resolution.
+	time.Sleep(time.Second)
+
+	response, err := LogoutAll(registeredUser.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNoContent)
+	}
+
+	for _, token := range []string{registeredUser.User.Token, loggedUser.User.Token} {
+		response, err = GetCurrentUser(token)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if response.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+		}
+	}
+
+	for _, refreshToken := range []string{registeredUser.User.RefreshToken, loggedUser.User.RefreshToken} {
+		response, err = RefreshToken(refreshToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if response.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+		}
+	}
+
+	_, err = LoginAndDecode(requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,377 @@
+package users
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	FakeOauthProvider = "fake"
+	fakeOidcKeyId     = "fake-key"
+)
+
+// FakeOidcIdentity is the user the fake issuer signs in.
+type FakeOidcIdentity struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+}
+
+type fakeOidcAuthorization struct {
+	identity      FakeOidcIdentity
+	clientId      string
+	redirectUri   string
+	nonce         string
+	codeChallenge string
+}
+
+// FakeOidcIssuer is an OpenID Connect issuer run by the tests, which the app is configured to
+// use as its "fake" provider. Instead of a login page, its authorizations are granted with
+// Authorize.
+type FakeOidcIssuer struct {
+	Issuer         string
+	ClientId       string
+	ClientSecret   string
+	privateKey     *rsa.PrivateKey
+	mu             sync.Mutex
+	authorizations map[string]fakeOidcAuthorization
+}
+
+var fakeOidcIssuer *FakeOidcIssuer
+var fakeOidcIssuerErr error
+var fakeOidcIssuerOnce sync.Once
+
+// GetFakeOidcIssuer starts the fake issuer on the port of OAUTH_FAKE_ISSUER, which must be the
+// issuer as the app reaches it.
+func GetFakeOidcIssuer() (*FakeOidcIssuer, error) {
+	fakeOidcIssuerOnce.Do(func() {
+		fakeOidcIssuer, fakeOidcIssuerErr = startFakeOidcIssuer()
+	})
+
+	return fakeOidcIssuer, fakeOidcIssuerErr
+}
+
+func startFakeOidcIssuer() (*FakeOidcIssuer, error) {
+	issuer := getEnvOrDefault("OAUTH_FAKE_ISSUER", "http://localhost:8091")
+
+	issuerUrl, err := url.Parse(issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &FakeOidcIssuer{
+		Issuer:         issuer,
+		ClientId:       getEnvOrDefault("OAUTH_FAKE_CLIENT_ID", "conduit"),
+		ClientSecret:   getEnvOrDefault("OAUTH_FAKE_CLIENT_SECRET", "dummy-client-secret"),
+		privateKey:     privateKey,
+		authorizations: map[string]fakeOidcAuthorization{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/jwks", s.jwks)
+	mux.HandleFunc("/token", s.token)
+
+	// The app may reach the tests from a container, so listen on all interfaces.
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", issuerUrl.Port()))
+	if err != nil {
+		return nil, err
+	}
+
+	go http.Serve(listener, mux)
+
+	return s, nil
+}
+
+// Authorize grants the authorization request of the URL for the identity, and returns the
+// callback URL the issuer would redirect the browser to.
+func (s *FakeOidcIssuer) Authorize(authorizationUrl string, identity FakeOidcIdentity) (*string, error) {
+	parsedAuthorizationUrl, err := url.Parse(authorizationUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	query := parsedAuthorizationUrl.Query()
+
+	if query.Get("code_challenge_method") != "S256" {
+		return nil, fmt.Errorf("unexpected code_challenge_method %s", query.Get("code_challenge_method"))
+	}
+
+	code, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.authorizations[*code] = fakeOidcAuthorization{
+		identity:      identity,
+		clientId:      query.Get("client_id"),
+		redirectUri:   query.Get("redirect_uri"),
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+	}
+	s.mu.Unlock()
+
+	callbackUrl, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil {
+		return nil, err
+	}
+
+	callbackQuery := callbackUrl.Query()
+	callbackQuery.Set("code", *code)
+	callbackQuery.Set("state", query.Get("state"))
+	callbackUrl.RawQuery = callbackQuery.Encode()
+
+	callbackUrlString := callbackUrl.String()
+	return &callbackUrlString, nil
+}
+
+func (s *FakeOidcIssuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJson(w, http.StatusOK, map[string]string{
+		"issuer":                 s.Issuer,
+		"authorization_endpoint": fmt.Sprintf("%s/authorize", s.Issuer),
+		"token_endpoint":         fmt.Sprintf("%s/token", s.Issuer),
+		"jwks_uri":               fmt.Sprintf("%s/jwks", s.Issuer),
+	})
+}
+
+func (s *FakeOidcIssuer) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJson(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": fakeOidcKeyId,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(s.privateKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.privateKey.E)).Bytes()),
+			},
+		},
+	})
+}
+
+func (s *FakeOidcIssuer) token(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	code := r.PostForm.Get("code")
+
+	s.mu.Lock()
+	authorization, ok := s.authorizations[code]
+	delete(s.authorizations, code)
+	s.mu.Unlock()
+
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" || r.PostForm.Get("redirect_uri") != authorization.redirectUri {
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	if r.PostForm.Get("client_id") != s.ClientId || r.PostForm.Get("client_secret") != s.ClientSecret || authorization.clientId != s.ClientId {
+		writeJson(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	codeChallenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(codeChallenge[:]) != authorization.codeChallenge {
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":                s.Issuer,
+		"aud":                s.ClientId,
+		"sub":                authorization.identity.Subject,
+		"iat":                now.Unix(),
+		"exp":                now.Add(time.Minute).Unix(),
+		"nonce":              authorization.nonce,
+		"email":              authorization.identity.Email,
+		"email_verified":     authorization.identity.EmailVerified,
+		"preferred_username": authorization.identity.PreferredUsername,
+	})
+	idToken.Header["kid"] = fakeOidcKeyId
+
+	signedIdToken, err := idToken.SignedString(s.privateKey)
+	if err != nil {
+		writeJson(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	accessToken, err := randomString()
+	if err != nil {
+		writeJson(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJson(w, http.StatusOK, map[string]string{
+		"access_token": *accessToken,
+		"token_type":   "Bearer",
+		"id_token":     signedIdToken,
+	})
+}
+
+// OauthBrowser is a client which keeps cookies and doesn't follow redirects, like a browser
+// whose redirects are inspected.
+type OauthBrowser struct {
+	client *http.Client
+}
+
+func NewOauthBrowser() (*OauthBrowser, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OauthBrowser{
+		client: &http.Client{
+			Jar: jar,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}, nil
+}
+
+func (b *OauthBrowser) StartOauthLogin(provider string) (*http.Response, error) {
+	return b.client.Get(fmt.Sprintf("http://localhost:8080/users/oauth/%s/start", provider))
+}
+
+// StartOauthLoginAndGetLocation returns the authorization URL the start redirects to.
+func (b *OauthBrowser) StartOauthLoginAndGetLocation(provider string) (*string, error) {
+	response, err := b.StartOauthLogin(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusFound {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusFound)
+	}
+
+	location := response.Header.Get("Location")
+	return &location, nil
+}
+
+func (b *OauthBrowser) Get(url string) (*http.Response, error) {
+	return b.client.Get(url)
+}
+
+// LoginWithFakeProvider goes through the whole social login of the identity in a new browser.
+func LoginWithFakeProvider(identity FakeOidcIdentity) (*http.Response, error) {
+	fakeOidcIssuer, err := GetFakeOidcIssuer()
+	if err != nil {
+		return nil, err
+	}
+
+	browser, err := NewOauthBrowser()
+	if err != nil {
+		return nil, err
+	}
+
+	authorizationUrl, err := browser.StartOauthLoginAndGetLocation(FakeOauthProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	callbackUrl, err := fakeOidcIssuer.Authorize(*authorizationUrl, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	return browser.Get(*callbackUrl)
+}
+
+func LoginWithFakeProviderAndDecode(identity FakeOidcIdentity) (*UserResponse, error) {
+	response, err := LoginWithFakeProvider(identity)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	defer response.Body.Close()
+
+	responseData := &UserResponse{}
+	err = json.NewDecoder(response.Body).Decode(&responseData)
+	if err != nil {
+		return nil, err
+	}
+
+	return responseData, nil
+}
+
+func LoginWithFakeProviderAndDecodeChallenge(identity FakeOidcIdentity) (*TwoFactorChallengeResponse, error) {
+	response, err := LoginWithFakeProvider(identity)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	defer response.Body.Close()
+
+	twoFactorChallengeResponse := &TwoFactorChallengeResponse{}
+	err = json.NewDecoder(response.Body).Decode(twoFactorChallengeResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(twoFactorChallengeResponse.Challenge.Token) == 0 {
+		return nil, fmt.Errorf("got no challenge token")
+	}
+
+	return twoFactorChallengeResponse, nil
+}
+
+func getEnvOrDefault(name string, defaultValue string) string {
+	value := os.Getenv(name)
+	if len(value) == 0 {
+		return defaultValue
+	}
+	return value
+}
+
+func randomString() (*string, error) {
+	randomBytes := make([]byte, 16)
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	randomString := base64.RawURLEncoding.EncodeToString(randomBytes)
+	return &randomString, nil
+}
+
+func writeJson(w http.ResponseWriter, statusCode int, value interface{}) {
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(value)
+}
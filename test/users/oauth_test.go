@@ -0,0 +1,326 @@
+package users
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bxcodec/faker/v3"
+)
+
+func newFakeOidcIdentity(t *testing.T) FakeOidcIdentity {
+	subject, err := randomString()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return FakeOidcIdentity{
+		Subject:           *subject,
+		Email:             faker.Email(),
+		EmailVerified:     true,
+		PreferredUsername: faker.Username(),
+	}
+}
+
+func TestGivenNewIdentityWhenLoginWithProviderShouldRegisterUser(t *testing.T) {
+	identity := newFakeOidcIdentity(t)
+
+	user, err := LoginWithFakeProviderAndDecode(identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.User.Email != identity.Email {
+		t.Fatalf("got %s, want %s", user.User.Email, identity.Email)
+	}
+
+	if user.User.Username != identity.PreferredUsername {
+		t.Fatalf("got %s, want %s", user.User.Username, identity.PreferredUsername)
+	}
+
+	if !user.User.EmailVerified {
+		t.Fatal("EmailVerified must be true")
+	}
+
+	if len(user.User.RefreshToken) == 0 {
+		t.Fatal("RefreshToken must be set")
+	}
+
+	currentUser, err := GetCurrentUserAndDecode(user.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if currentUser.User.Username != user.User.Username {
+		t.Fatalf("got %s, want %s", currentUser.User.Username, user.User.Username)
+	}
+}
+
+func TestGivenIdentityIsLinkedWhenLoginWithProviderShouldReturnLinkedUser(t *testing.T) {
+	identity := newFakeOidcIdentity(t)
+
+	user, err := LoginWithFakeProviderAndDecode(identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Providers let users change their email, but the subject stays the same.
+	identity.Email = faker.Email()
+
+	linkedUser, err := LoginWithFakeProviderAndDecode(identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if linkedUser.User.Username != user.User.Username {
+		t.Fatalf("got %s, want %s", linkedUser.User.Username, user.User.Username)
+	}
+}
+
+func TestGivenUserWithVerifiedEmailExistsWhenLoginWithProviderShouldLinkIdentity(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredAt := time.Now()
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := WaitForMailedToken(registeredUser.User.Email, EmailVerificationMailSubject, registeredAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := VerifyEmail(*token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNoContent)
+	}
+
+	identity := newFakeOidcIdentity(t)
+	identity.Email = strings.ToUpper(registeredUser.User.Email)
+
+	user, err := LoginWithFakeProviderAndDecode(identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.User.Username != registeredUser.User.Username {
+		t.Fatalf("got %s, want %s", user.User.Username, registeredUser.User.Username)
+	}
+
+	// The password keeps working.
+	_, err = LoginAndDecode(requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGivenUserWithUnverifiedEmailExistsWhenLoginWithProviderShouldReturnUnprocessableEntity(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identity := newFakeOidcIdentity(t)
+	identity.Email = registeredUser.User.Email
+
+	response, err := LoginWithFakeProvider(identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestGivenProviderEmailIsNotVerifiedWhenLoginWithProviderShouldReturnUnprocessableEntity(t *testing.T) {
+	identity := newFakeOidcIdentity(t)
+	identity.EmailVerified = false
+
+	response, err := LoginWithFakeProvider(identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestGivenUsernameIsTakenWhenLoginWithProviderShouldGenerateUniqueUsername(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identity := newFakeOidcIdentity(t)
+	identity.PreferredUsername = registeredUser.User.Username
+
+	user, err := LoginWithFakeProviderAndDecode(identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.User.Username == registeredUser.User.Username || !strings.HasPrefix(user.User.Username, registeredUser.User.Username) {
+		t.Fatalf("got %s, want a username derived from %s", user.User.Username, registeredUser.User.Username)
+	}
+}
+
+func TestGivenTwoFactorIsEnabledWhenLoginWithProviderShouldReturnChallenge(t *testing.T) {
+	identity := newFakeOidcIdentity(t)
+
+	user, err := LoginWithFakeProviderAndDecode(identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	totpEnrollment, err := EnrollTotpAndDecode(user.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := TotpCode(totpEnrollment.Totp.Secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ConfirmTotpAndDecode(user.User.Token, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenge, err := LoginWithFakeProviderAndDecodeChallenge(identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifiedUser, err := VerifyTwoFactorLoginAndDecode(VerifyTwoFactorLoginRequest{
+		Token: challenge.Challenge.Token,
+		Code:  nextTotpCode(t, totpEnrollment.Totp.Secret),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if verifiedUser.User.Username != user.User.Username {
+		t.Fatalf("got %s, want %s", verifiedUser.User.Username, user.User.Username)
+	}
+}
+
+func TestGivenProviderIsUnknownWhenStartLoginShouldReturnNotFound(t *testing.T) {
+	browser, err := NewOauthBrowser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := browser.StartOauthLogin("unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusNotFound {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestGivenCallbackIsOpenedInAnotherBrowserWhenLoginWithProviderShouldReturnUnprocessableEntity(t *testing.T) {
+	fakeOidcIssuer, err := GetFakeOidcIssuer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	browser, err := NewOauthBrowser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authorizationUrl, err := browser.StartOauthLoginAndGetLocation(FakeOauthProvider)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	callbackUrl, err := fakeOidcIssuer.Authorize(*authorizationUrl, newFakeOidcIdentity(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherBrowser, err := NewOauthBrowser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := otherBrowser.Get(*callbackUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestGivenCallbackWasUsedWhenCallbackAgainShouldReturnUnprocessableEntity(t *testing.T) {
+	fakeOidcIssuer, err := GetFakeOidcIssuer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	browser, err := NewOauthBrowser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authorizationUrl, err := browser.StartOauthLoginAndGetLocation(FakeOauthProvider)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	callbackUrl, err := fakeOidcIssuer.Authorize(*authorizationUrl, newFakeOidcIdentity(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := browser.Get(*callbackUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	response, err = browser.Get(*callbackUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
@@ -0,0 +1,369 @@
+package users
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bxcodec/faker/v3"
+)
+
+func registerUserWithPasskey(t *testing.T) (*UserResponse, *SoftAuthenticator) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator, err := NewSoftAuthenticator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	creationOptions, err := BeginPasskeyRegistrationAndDecode(registeredUser.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credential, err := authenticator.Create(*creationOptions, WebauthnOrigin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := FinishPasskeyRegistration(registeredUser.User.Token, FinishPasskeyRegistrationRequest{
+		Name:       "Laptop",
+		Credential: *credential,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusCreated {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusCreated)
+	}
+
+	return registeredUser, authenticator
+}
+
+func TestWhenRegisterPasskeyShouldListPasskey(t *testing.T) {
+	registeredUser, authenticator := registerUserWithPasskey(t)
+
+	passkeys, err := ListPasskeysAndDecode(registeredUser.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(passkeys.Passkeys) != 1 {
+		t.Fatalf("got %d passkeys, want 1", len(passkeys.Passkeys))
+	}
+
+	if passkeys.Passkeys[0].Id != authenticator.Id() || passkeys.Passkeys[0].Name != "Laptop" {
+		t.Fatalf("got passkey %+v", passkeys.Passkeys[0])
+	}
+
+	creationOptions, err := BeginPasskeyRegistrationAndDecode(registeredUser.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(creationOptions.PublicKey.ExcludeCredentials) != 1 || creationOptions.PublicKey.ExcludeCredentials[0].Id != authenticator.Id() {
+		t.Fatalf("got excluded credentials %+v", creationOptions.PublicKey.ExcludeCredentials)
+	}
+}
+
+func TestGivenOriginIsNotAllowedWhenRegisterPasskeyShouldReturnUnprocessableEntity(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator, err := NewSoftAuthenticator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	creationOptions, err := BeginPasskeyRegistrationAndDecode(registeredUser.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credential, err := authenticator.Create(*creationOptions, "https://evil.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := FinishPasskeyRegistration(registeredUser.User.Token, FinishPasskeyRegistrationRequest{Credential: *credential})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestGivenDiscoverablePasskeyWhenPasskeyLoginShouldReturnUser(t *testing.T) {
+	registeredUser, authenticator := registerUserWithPasskey(t)
+
+	requestOptions, err := BeginPasskeyLoginAndDecode("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(requestOptions.PublicKey.AllowCredentials) != 0 {
+		t.Fatalf("got allowed credentials %+v, want none", requestOptions.PublicKey.AllowCredentials)
+	}
+
+	credential, err := authenticator.Get(*requestOptions, WebauthnOrigin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := FinishPasskeyLoginAndDecode(FinishPasskeyLoginRequest{Credential: *credential})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.User.Username != registeredUser.User.Username {
+		t.Fatalf("got %s, want %s", user.User.Username, registeredUser.User.Username)
+	}
+
+	if len(user.User.Token) == 0 || len(user.User.RefreshToken) == 0 {
+		t.Fatal("got no token or refresh token")
+	}
+}
+
+func TestGivenEmailWhenPasskeyLoginShouldAllowUserPasskeys(t *testing.T) {
+	registeredUser, authenticator := registerUserWithPasskey(t)
+
+	requestOptions, err := BeginPasskeyLoginAndDecode(registeredUser.User.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(requestOptions.PublicKey.AllowCredentials) != 1 {
+		t.Fatalf("got allowed credentials %+v, want 1", requestOptions.PublicKey.AllowCredentials)
+	}
+
+	credential, err := authenticator.Get(*requestOptions, WebauthnOrigin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Authenticators may not return the user handle of non-discoverable credentials.
+	credential.Response.UserHandle = ""
+
+	response, err := FinishPasskeyLogin(FinishPasskeyLoginRequest{Credential: *credential})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+}
+
+func TestGivenChallengeWasUsedWhenPasskeyLoginShouldReturnUnprocessableEntity(t *testing.T) {
+	_, authenticator := registerUserWithPasskey(t)
+
+	requestOptions, err := BeginPasskeyLoginAndDecode("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credential, err := authenticator.Get(*requestOptions, WebauthnOrigin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := FinishPasskeyLogin(FinishPasskeyLoginRequest{Credential: *credential})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	credential, err = authenticator.Get(*requestOptions, WebauthnOrigin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err = FinishPasskeyLogin(FinishPasskeyLoginRequest{Credential: *credential})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestGivenSignatureIsFromAnotherKeyWhenPasskeyLoginShouldReturnUnauthorized(t *testing.T) {
+	_, authenticator := registerUserWithPasskey(t)
+
+	impostor, err := NewSoftAuthenticator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	impostor.CredentialId = authenticator.CredentialId
+	impostor.UserHandle = authenticator.UserHandle
+	impostor.SignCount = authenticator.SignCount
+
+	requestOptions, err := BeginPasskeyLoginAndDecode("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credential, err := impostor.Get(*requestOptions, WebauthnOrigin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := FinishPasskeyLogin(FinishPasskeyLoginRequest{Credential: *credential})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestGivenSignCountDidNotIncreaseWhenPasskeyLoginShouldReturnUnauthorized(t *testing.T) {
+	_, authenticator := registerUserWithPasskey(t)
+
+	requestOptions, err := BeginPasskeyLoginAndDecode("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credential, err := authenticator.Get(*requestOptions, WebauthnOrigin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := FinishPasskeyLogin(FinishPasskeyLoginRequest{Credential: *credential})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	// A clone of the authenticator, which signs with a count that was already seen.
+	authenticator.SignCount--
+
+	requestOptions, err = BeginPasskeyLoginAndDecode("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credential, err = authenticator.Get(*requestOptions, WebauthnOrigin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err = FinishPasskeyLogin(FinishPasskeyLoginRequest{Credential: *credential})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+// enableTotp enables TOTP for the user, and returns the secret.
+func enableTotp(t *testing.T, user *UserResponse) string {
+	totpEnrollment, err := EnrollTotpAndDecode(user.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := TotpCode(totpEnrollment.Totp.Secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ConfirmTotpAndDecode(user.User.Token, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return totpEnrollment.Totp.Secret
+}
+
+func TestGivenTotpIsEnabledAndUserIsNotVerifiedWhenPasskeyLoginShouldReturnChallenge(t *testing.T) {
+	registeredUser, authenticator := registerUserWithPasskey(t)
+	secret := enableTotp(t, registeredUser)
+
+	requestOptions, err := BeginPasskeyLoginAndDecode("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credential, err := authenticator.Get(*requestOptions, WebauthnOrigin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenge, err := FinishPasskeyLoginAndDecodeChallenge(FinishPasskeyLoginRequest{Credential: *credential})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := VerifyTwoFactorLoginAndDecode(VerifyTwoFactorLoginRequest{
+		Token: challenge.Challenge.Token,
+		Code:  nextTotpCode(t, secret),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.User.Username != registeredUser.User.Username {
+		t.Fatalf("got %s, want %s", user.User.Username, registeredUser.User.Username)
+	}
+}
+
+func TestGivenTotpIsEnabledAndUserIsVerifiedWhenPasskeyLoginShouldReturnUser(t *testing.T) {
+	registeredUser, authenticator := registerUserWithPasskey(t)
+	enableTotp(t, registeredUser)
+
+	requestOptions, err := BeginPasskeyLoginAndDecode("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator.UserVerified = true
+
+	credential, err := authenticator.Get(*requestOptions, WebauthnOrigin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := FinishPasskeyLoginAndDecode(FinishPasskeyLoginRequest{Credential: *credential})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(user.User.Token) == 0 || len(user.User.RefreshToken) == 0 {
+		t.Fatal("got no token or refresh token")
+	}
+}
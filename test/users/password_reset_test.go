@@ -0,0 +1,353 @@
+package users
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bxcodec/faker/v3"
+)
+
+func TestGivenUserExistsWhenConfirmPasswordResetShouldUpdatePasswordAndRevokeAllTokens(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Subjects are revoked with a precision of seconds.
+	time.Sleep(time.Second)
+
+	requestedAt := time.Now()
+
+	response, err := RequestPasswordReset(requestData.User.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusAccepted {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusAccepted)
+	}
+
+	token, err := WaitForMailedToken(registeredUser.User.Email, PasswordResetMailSubject, requestedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	password := faker.Password()
+
+	response, err = ConfirmPasswordReset(*token, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNoContent)
+	}
+
+	_, err = LoginAndDecode(requestData.User.Email, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err = Login(requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+
+	response, err = GetCurrentUser(registeredUser.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+
+	response, err = RefreshToken(registeredUser.User.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestGivenUsernameIsAnotherUsersIdWhenConfirmPasswordResetShouldNotRevokeTheirTokens(t *testing.T) {
+	victim := registerFakeUser(t)
+
+	victims, err := BatchGetUsersAndDecode(BatchGetUsersRequest{Usernames: []string{victim.User.Username}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requestData := RegisterUserRequest{}
+
+	err = faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attacker, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = UpdateUserAndDecode(attacker.User.Token, UpdateUserRequest{User: updateUserRequestUser{Username: &victims.Users[0].Id}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Subjects are revoked with a precision of seconds.
+	time.Sleep(time.Second)
+
+	requestedAt := time.Now()
+
+	response, err := RequestPasswordReset(requestData.User.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusAccepted {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusAccepted)
+	}
+
+	token, err := WaitForMailedToken(attacker.User.Email, PasswordResetMailSubject, requestedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err = ConfirmPasswordReset(*token, faker.Password())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNoContent)
+	}
+
+	response, err = GetCurrentUser(victim.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+}
+
+func TestGivenTokenWasUsedWhenConfirmPasswordResetShouldReturnUnprocessableEntity(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requestedAt := time.Now()
+
+	_, err = RequestPasswordReset(requestData.User.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := WaitForMailedToken(registeredUser.User.Email, PasswordResetMailSubject, requestedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := ConfirmPasswordReset(*token, faker.Password())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNoContent)
+	}
+
+	response, err = ConfirmPasswordReset(*token, faker.Password())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestGivenNewerTokenWasUsedWhenConfirmPasswordResetWithOlderTokenShouldReturnUnprocessableEntity(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requestedAt := time.Now()
+
+	_, err = RequestPasswordReset(requestData.User.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	olderToken, err := WaitForMailedToken(registeredUser.User.Email, PasswordResetMailSubject, requestedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requestedAt = time.Now()
+
+	_, err = RequestPasswordReset(requestData.User.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newerToken, err := WaitForMailedToken(registeredUser.User.Email, PasswordResetMailSubject, requestedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := ConfirmPasswordReset(*newerToken, faker.Password())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNoContent)
+	}
+
+	response, err = ConfirmPasswordReset(*olderToken, faker.Password())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestGivenPasswordIsInvalidWhenConfirmPasswordResetShouldReturnUnprocessableEntityAndKeepToken(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requestedAt := time.Now()
+
+	_, err = RequestPasswordReset(requestData.User.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := WaitForMailedToken(registeredUser.User.Email, PasswordResetMailSubject, requestedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := ConfirmPasswordReset(*token, "short")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+
+	response, err = ConfirmPasswordReset(*token, faker.Password())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNoContent)
+	}
+}
+
+func TestGivenTokenIsInvalidWhenConfirmPasswordResetShouldReturnUnprocessableEntity(t *testing.T) {
+	response, err := ConfirmPasswordReset(faker.Password(), faker.Password())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestGivenUserDoesNotExistWhenRequestPasswordResetShouldReturnAccepted(t *testing.T) {
+	response, err := RequestPasswordReset(faker.Email())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusAccepted {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusAccepted)
+	}
+}
+
+func TestGivenAcceptLanguageWhenRequestPasswordResetShouldMailLocalizedToken(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requestedAt := time.Now()
+
+	response, err := RequestPasswordResetWithAcceptLanguage(requestData.User.Email, "pt-BR,pt;q=0.9,en;q=0.8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusAccepted {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusAccepted)
+	}
+
+	token, err := WaitForMailedToken(registeredUser.User.Email, "Redefina sua senha", requestedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err = ConfirmPasswordReset(*token, faker.Password())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNoContent)
+	}
+}
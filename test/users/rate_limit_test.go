@@ -0,0 +1,54 @@
+package users
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/bxcodec/faker/v3"
+)
+
+func TestWhenGetCurrentUserShouldReturnRateLimitHeaders(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := GetCurrentUser(registeredUser.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	limit, err := strconv.Atoi(response.Header.Get("RateLimit-Limit"))
+	if err != nil {
+		t.Fatalf("RateLimit-Limit: %s", err)
+	}
+
+	remaining, err := strconv.Atoi(response.Header.Get("RateLimit-Remaining"))
+	if err != nil {
+		t.Fatalf("RateLimit-Remaining: %s", err)
+	}
+
+	if remaining >= limit {
+		t.Fatalf("got remaining %d, want less than limit %d", remaining, limit)
+	}
+
+	if _, err := strconv.Atoi(response.Header.Get("RateLimit-Reset")); err != nil {
+		t.Fatalf("RateLimit-Reset: %s", err)
+	}
+
+	if len(response.Header.Get("RateLimit-Policy")) == 0 {
+		t.Fatal("got no RateLimit-Policy header")
+	}
+}
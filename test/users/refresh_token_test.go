@@ -0,0 +1,105 @@
+package users
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bxcodec/faker/v3"
+)
+
+func TestGivenValidRefreshTokenWhenRefreshTokenShouldReturnUserWithNewTokens(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(registeredUser.User.RefreshToken) == 0 {
+		t.Fatal("RefreshToken must not be empty")
+	}
+
+	refreshedUser, err := RefreshTokenAndDecode(registeredUser.User.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if refreshedUser.User.Username != registeredUser.User.Username {
+		t.Fatalf("got %s, want %s", refreshedUser.User.Username, registeredUser.User.Username)
+	}
+
+	if refreshedUser.User.Email != registeredUser.User.Email {
+		t.Fatalf("got %s, want %s", refreshedUser.User.Email, registeredUser.User.Email)
+	}
+
+	if refreshedUser.User.RefreshToken == registeredUser.User.RefreshToken {
+		t.Fatal("RefreshToken must be rotated")
+	}
+
+	currentUser, err := GetCurrentUserAndDecode(refreshedUser.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if currentUser.User.Username != registeredUser.User.Username {
+		t.Fatalf("got %s, want %s", currentUser.User.Username, registeredUser.User.Username)
+	}
+
+	_, err = RefreshTokenAndDecode(refreshedUser.User.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGivenRefreshTokenIsInvalidWhenRefreshTokenShouldReturnUnauthorized(t *testing.T) {
+	response, err := RefreshToken(faker.Password())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestGivenRefreshTokenWasAlreadyUsedWhenRefreshTokenShouldRevokeTokenFamily(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loggedUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refreshedUser, err := RefreshTokenAndDecode(loggedUser.User.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := RefreshToken(loggedUser.User.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+
+	response, err = RefreshToken(refreshedUser.User.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+}
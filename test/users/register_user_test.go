@@ -2,9 +2,12 @@ package users
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -56,7 +59,7 @@ func TestGivenValidRequestWhenRegisterUserShouldReturnUser(t *testing.T) {
 		t.Fatal("Environment variable 'JWT_SECONDS_TO_EXPIRE' must be set and not be empty")
 	}
 
-	parsedToken, err := jwt.ParseWithClaims(responseData.User.Token, &jwt.StandardClaims{}, func(token *jwt.Token) (interface{}, error) {
+	parsedToken, err := jwt.ParseWithClaims(responseData.User.Token, &TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
 		b := ([]byte(jwtSecretKey))
 		return b, nil
 	})
@@ -65,10 +68,14 @@ func TestGivenValidRequestWhenRegisterUserShouldReturnUser(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	claims := parsedToken.Claims.(*jwt.StandardClaims)
+	claims := parsedToken.Claims.(*TokenClaims)
 
-	if claims.Subject != responseData.User.Username {
-		t.Fatalf("got %s, want %s", claims.Subject, requestData.User.Username)
+	if len(claims.Subject) == 0 || claims.Subject == responseData.User.Username {
+		t.Fatalf("Subject claim must be the User's id. got %s", claims.Subject)
+	}
+
+	if claims.Username != responseData.User.Username {
+		t.Fatalf("got %s, want %s", claims.Username, requestData.User.Username)
 	}
 
 	now := time.Now().Unix()
@@ -248,3 +255,201 @@ func TestGivenEmailIsTakenWhenRegisterUserShouldReturnUnprocessableEntity(t *tes
 		t.Fatalf("got %s, want %s", responseData.Errors.Body[0], "Email is taken")
 	}
 }
+
+func TestGivenConcurrentRegistrationsWithSameUsernameWhenRegisterUserShouldCreateExactlyOneUser(t *testing.T) {
+	const concurrentRegistrations = 10
+
+	existingUserRequestData := RegisterUserRequest{}
+	err := faker.FakeData(&existingUserRequestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statusCodes := make(chan int, concurrentRegistrations)
+	errs := make(chan error, concurrentRegistrations)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrentRegistrations; i++ {
+		anotherUserRequestData := RegisterUserRequest{}
+		err := faker.FakeData(&anotherUserRequestData)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			response, err := RegisterUser(existingUserRequestData.User.Username, anotherUserRequestData.User.Email, anotherUserRequestData.User.Password)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer response.Body.Close()
+
+			statusCodes <- response.StatusCode
+		}()
+	}
+
+	wg.Wait()
+	close(statusCodes)
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	created := 0
+	for statusCode := range statusCodes {
+		switch statusCode {
+		case http.StatusCreated:
+			created++
+		case http.StatusUnprocessableEntity:
+		default:
+			t.Fatalf("got %d, want %d or %d", statusCode, http.StatusCreated, http.StatusUnprocessableEntity)
+		}
+	}
+
+	if created != 1 {
+		t.Fatalf("got %d users created, want 1", created)
+	}
+}
+
+func TestGivenConcurrentRegistrationsWithSameEmailWhenRegisterUserShouldCreateExactlyOneUser(t *testing.T) {
+	const concurrentRegistrations = 10
+
+	existingUserRequestData := RegisterUserRequest{}
+	err := faker.FakeData(&existingUserRequestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statusCodes := make(chan int, concurrentRegistrations)
+	errs := make(chan error, concurrentRegistrations)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrentRegistrations; i++ {
+		anotherUserRequestData := RegisterUserRequest{}
+		err := faker.FakeData(&anotherUserRequestData)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			response, err := RegisterUser(anotherUserRequestData.User.Username, existingUserRequestData.User.Email, anotherUserRequestData.User.Password)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer response.Body.Close()
+
+			statusCodes <- response.StatusCode
+		}()
+	}
+
+	wg.Wait()
+	close(statusCodes)
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	created := 0
+	for statusCode := range statusCodes {
+		switch statusCode {
+		case http.StatusCreated:
+			created++
+		case http.StatusUnprocessableEntity:
+		default:
+			t.Fatalf("got %d, want %d or %d", statusCode, http.StatusCreated, http.StatusUnprocessableEntity)
+		}
+	}
+
+	if created != 1 {
+		t.Fatalf("got %d users created, want 1", created)
+	}
+}
+
+func TestGivenUsernameAlreadyExistsWithDifferentCasingWhenRegisterUserShouldReturnUnprocessableEntity(t *testing.T) {
+	existingUserRequestData := RegisterUserRequest{}
+	err := faker.FakeData(&existingUserRequestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = RegisterUserAndDecode(existingUserRequestData.User.Username, existingUserRequestData.User.Email, existingUserRequestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	anotherUserRequestData := RegisterUserRequest{}
+	err = faker.FakeData(&anotherUserRequestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := RegisterUser(fmt.Sprintf(" %s ", strings.ToUpper(existingUserRequestData.User.Username)), anotherUserRequestData.User.Email, anotherUserRequestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+
+	defer response.Body.Close()
+
+	responseData := &ErrorResponse{}
+	err = json.NewDecoder(response.Body).Decode(&responseData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if responseData.Errors.Body[0] != "User already exists" {
+		t.Fatalf("got %s, want %s", responseData.Errors.Body[0], "User already exists")
+	}
+}
+
+func TestGivenEmailIsTakenWithDifferentCasingWhenRegisterUserShouldReturnUnprocessableEntity(t *testing.T) {
+	existingUserRequestData := RegisterUserRequest{}
+	err := faker.FakeData(&existingUserRequestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = RegisterUserAndDecode(existingUserRequestData.User.Username, existingUserRequestData.User.Email, existingUserRequestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	anotherUserRequestData := RegisterUserRequest{}
+	err = faker.FakeData(&anotherUserRequestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := RegisterUser(anotherUserRequestData.User.Username, strings.ToUpper(existingUserRequestData.User.Email), anotherUserRequestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+
+	defer response.Body.Close()
+
+	responseData := &ErrorResponse{}
+	err = json.NewDecoder(response.Body).Decode(&responseData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if responseData.Errors.Body[0] != "Email is taken" {
+		t.Fatalf("got %s, want %s", responseData.Errors.Body[0], "Email is taken")
+	}
+}
@@ -0,0 +1,93 @@
+package users
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+type UpdateUserRolesRequest struct {
+	Roles []string `json:"roles"`
+}
+
+type UserRolesResponse struct {
+	User UserRolesResponseUser `json:"user"`
+}
+
+type UserRolesResponseUser struct {
+	Username string   `json:"username"`
+	Roles    []string `json:"roles"`
+}
+
+// GetAdmin returns a session of the admin the app is configured with for the tests, registering
+// and verifying it the first time.
+func GetAdmin() (*UserResponse, error) {
+	email := getEnvOrDefault("ADMIN_TEST_EMAIL", "admin@conduit.test")
+	password := getEnvOrDefault("ADMIN_TEST_PASSWORD", "dummy-admin-password")
+
+	registeredAt := time.Now()
+
+	response, err := RegisterUser(getEnvOrDefault("ADMIN_TEST_USERNAME", "conduit-admin"), email, password)
+	if err != nil {
+		return nil, err
+	}
+	response.Body.Close()
+
+	if response.StatusCode == http.StatusCreated {
+		token, err := WaitForMailedToken(email, EmailVerificationMailSubject, registeredAt)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err = VerifyEmail(*token)
+		if err != nil {
+			return nil, err
+		}
+
+		if response.StatusCode != http.StatusNoContent {
+			return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusNoContent)
+		}
+	}
+
+	return LoginAndDecode(email, password)
+}
+
+func UpdateUserRoles(tokenString string, username string, roles []string) (*http.Response, error) {
+	return doAuthenticatedRequest("PUT", fmt.Sprintf("http://localhost:8080/users/%s/roles", username), tokenString, UpdateUserRolesRequest{Roles: roles})
+}
+
+func UpdateUserRolesAndDecode(tokenString string, username string, roles []string) (*UserRolesResponse, error) {
+	response, err := UpdateUserRoles(tokenString, username, roles)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	defer response.Body.Close()
+
+	responseData := &UserRolesResponse{}
+	err = json.NewDecoder(response.Body).Decode(&responseData)
+	if err != nil {
+		return nil, err
+	}
+
+	return responseData, nil
+}
+
+// ParseTokenClaims returns the claims of the token without verifying it.
+func ParseTokenClaims(tokenString string) (*TokenClaims, error) {
+	claims := &TokenClaims{}
+
+	_, _, err := new(jwt.Parser).ParseUnverified(tokenString, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,203 @@
+package users
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bxcodec/faker/v3"
+)
+
+var (
+	adminOnce sync.Once
+	admin     *UserResponse
+	adminErr  error
+)
+
+func getAdmin(t *testing.T) *UserResponse {
+	adminOnce.Do(func() {
+		admin, adminErr = GetAdmin()
+	})
+
+	if adminErr != nil {
+		t.Fatal(adminErr)
+	}
+
+	return admin
+}
+
+func TestWhenRegisterUserShouldIssueTokenWithUserRoleAndScopes(t *testing.T) {
+	user := registerFakeUser(t)
+
+	claims, err := ParseTokenClaims(user.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(claims.Roles, []string{"user"}) {
+		t.Fatalf("got %v, want %v", claims.Roles, []string{"user"})
+	}
+
+	if claims.Scope != "read write" {
+		t.Fatalf("got %s, want %s", claims.Scope, "read write")
+	}
+}
+
+func TestGivenAdminEmailIsVerifiedWhenLoginShouldIssueTokenWithAdminRole(t *testing.T) {
+	admin := getAdmin(t)
+
+	claims, err := ParseTokenClaims(admin.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(claims.Roles, []string{"user", "admin"}) {
+		t.Fatalf("got %v, want %v", claims.Roles, []string{"user", "admin"})
+	}
+
+	if claims.Scope != "read write moderate admin" {
+		t.Fatalf("got %s, want %s", claims.Scope, "read write moderate admin")
+	}
+}
+
+func TestWhenAdminUpdatesUserRolesShouldIssueTokensWithNewRoles(t *testing.T) {
+	admin := getAdmin(t)
+
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Revocations cover the tokens issued in earlier seconds.
+	time.Sleep(time.Second)
+
+	userRoles, err := UpdateUserRolesAndDecode(admin.User.Token, user.User.Username, []string{"moderator"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(userRoles.User.Roles, []string{"user", "moderator"}) {
+		t.Fatalf("got %v, want %v", userRoles.User.Roles, []string{"user", "moderator"})
+	}
+
+	response, err := GetCurrentUser(user.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+
+	loggedUser, err := LoginAndDecode(requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := ParseTokenClaims(loggedUser.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(claims.Roles, []string{"user", "moderator"}) {
+		t.Fatalf("got %v, want %v", claims.Roles, []string{"user", "moderator"})
+	}
+
+	if claims.Scope != "read write moderate" {
+		t.Fatalf("got %s, want %s", claims.Scope, "read write moderate")
+	}
+}
+
+func TestGivenUserIsNotAdminWhenUpdateUserRolesShouldReturnForbidden(t *testing.T) {
+	user := registerFakeUser(t)
+
+	response, err := UpdateUserRoles(user.User.Token, user.User.Username, []string{"admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusForbidden {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusForbidden)
+	}
+
+	defer response.Body.Close()
+
+	responseData := &ErrorResponse{}
+	err = json.NewDecoder(response.Body).Decode(&responseData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if responseData.Errors == nil || len(responseData.Errors.Body) == 0 {
+		t.Fatal("Errors must be set")
+	}
+}
+
+func TestGivenApiKeyOfAdminWhenUpdateUserRolesShouldReturnForbidden(t *testing.T) {
+	admin := getAdmin(t)
+	user := registerFakeUser(t)
+
+	apiKey, err := CreateApiKeyAndDecode(admin.User.Token, NewCreateApiKeyRequest("Roles", nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := UpdateUserRoles(apiKey.ApiKey.Key, user.User.Username, []string{"moderator"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusForbidden {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusForbidden)
+	}
+}
+
+func TestGivenUnknownRoleWhenUpdateUserRolesShouldReturnUnprocessableEntity(t *testing.T) {
+	admin := getAdmin(t)
+	user := registerFakeUser(t)
+
+	response, err := UpdateUserRoles(admin.User.Token, user.User.Username, []string{"superuser"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestWhenAdminRevokesOwnAdminRoleShouldReturnUnprocessableEntity(t *testing.T) {
+	admin := getAdmin(t)
+
+	response, err := UpdateUserRoles(admin.User.Token, admin.User.Username, []string{"moderator"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestGivenUserDoesNotExistWhenUpdateUserRolesShouldReturnNotFound(t *testing.T) {
+	admin := getAdmin(t)
+
+	response, err := UpdateUserRoles(admin.User.Token, faker.Username(), []string{"moderator"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusNotFound {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNotFound)
+	}
+}
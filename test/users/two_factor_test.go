@@ -0,0 +1,299 @@
+package users
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bxcodec/faker/v3"
+)
+
+type totpUser struct {
+	User          *UserResponse
+	Password      string
+	Secret        string
+	RecoveryCodes []string
+}
+
+func registerUserWithTotp(t *testing.T) totpUser {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	totpEnrollment, err := EnrollTotpAndDecode(registeredUser.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := TotpCode(totpEnrollment.Totp.Secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recoveryCodes, err := ConfirmTotpAndDecode(registeredUser.User.Token, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return totpUser{
+		User:          registeredUser,
+		Password:      requestData.User.Password,
+		Secret:        totpEnrollment.Totp.Secret,
+		RecoveryCodes: recoveryCodes.RecoveryCodes,
+	}
+}
+
+// nextTotpCode returns the code of the next time step, as the code of the current one was used
+// to confirm the enrollment and can't be replayed.
+func nextTotpCode(t *testing.T, secret string) string {
+	code, err := TotpCode(secret, time.Now().Add(30*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return code
+}
+
+func TestWhenEnrollTotpShouldReturnSecretAndProvisioningUri(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	totpEnrollment, err := EnrollTotpAndDecode(registeredUser.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(totpEnrollment.Totp.Secret) == 0 {
+		t.Fatal("got no secret")
+	}
+
+	if !strings.HasPrefix(totpEnrollment.Totp.Uri, "otpauth://totp/") || !strings.Contains(totpEnrollment.Totp.Uri, "secret="+totpEnrollment.Totp.Secret) {
+		t.Fatalf("got provisioning URI %s", totpEnrollment.Totp.Uri)
+	}
+
+	// Logins don't ask for a second factor until the enrollment is confirmed.
+	_, err = LoginAndDecode(requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGivenCodeIsInvalidWhenConfirmTotpShouldReturnUnprocessableEntity(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = EnrollTotpAndDecode(registeredUser.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := ConfirmTotp(registeredUser.User.Token, "abcdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestGivenTotpIsEnabledWhenEnrollTotpShouldReturnUnprocessableEntity(t *testing.T) {
+	user := registerUserWithTotp(t)
+
+	response, err := EnrollTotp(user.User.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestGivenTotpIsEnabledWhenLoginShouldReturnChallengeAndVerifyWithCodeShouldReturnUser(t *testing.T) {
+	user := registerUserWithTotp(t)
+
+	if len(user.RecoveryCodes) == 0 {
+		t.Fatal("got no recovery codes")
+	}
+
+	challenge, err := LoginAndDecodeChallenge(user.User.User.Email, user.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loggedInUser, err := VerifyTwoFactorLoginAndDecode(VerifyTwoFactorLoginRequest{
+		Token: challenge.Challenge.Token,
+		Code:  nextTotpCode(t, user.Secret),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loggedInUser.User.Username != user.User.User.Username {
+		t.Fatalf("got %s, want %s", loggedInUser.User.Username, user.User.User.Username)
+	}
+
+	if len(loggedInUser.User.Token) == 0 || len(loggedInUser.User.RefreshToken) == 0 {
+		t.Fatal("got no token or refresh token")
+	}
+
+	// The challenge is used up.
+	response, err := VerifyTwoFactorLogin(VerifyTwoFactorLoginRequest{
+		Token: challenge.Challenge.Token,
+		Code:  nextTotpCode(t, user.Secret),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestGivenCodeWasUsedWhenVerifyTwoFactorLoginShouldReturnUnauthorized(t *testing.T) {
+	user := registerUserWithTotp(t)
+
+	code := nextTotpCode(t, user.Secret)
+
+	challenge, err := LoginAndDecodeChallenge(user.User.User.Email, user.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = VerifyTwoFactorLoginAndDecode(VerifyTwoFactorLoginRequest{
+		Token: challenge.Challenge.Token,
+		Code:  code,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenge, err = LoginAndDecodeChallenge(user.User.User.Email, user.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := VerifyTwoFactorLogin(VerifyTwoFactorLoginRequest{
+		Token: challenge.Challenge.Token,
+		Code:  code,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestGivenRecoveryCodeWhenVerifyTwoFactorLoginShouldReturnUserOnlyOnce(t *testing.T) {
+	user := registerUserWithTotp(t)
+
+	challenge, err := LoginAndDecodeChallenge(user.User.User.Email, user.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = VerifyTwoFactorLoginAndDecode(VerifyTwoFactorLoginRequest{
+		Token:        challenge.Challenge.Token,
+		RecoveryCode: strings.ToUpper(user.RecoveryCodes[0]),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenge, err = LoginAndDecodeChallenge(user.User.User.Email, user.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := VerifyTwoFactorLogin(VerifyTwoFactorLoginRequest{
+		Token:        challenge.Challenge.Token,
+		RecoveryCode: user.RecoveryCodes[0],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestGivenChallengeIsInvalidWhenVerifyTwoFactorLoginShouldReturnUnprocessableEntity(t *testing.T) {
+	response, err := VerifyTwoFactorLogin(VerifyTwoFactorLoginRequest{
+		Token: "invalid",
+		Code:  "123456",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestGivenPasswordIsIncorrectWhenDisableTotpShouldReturnUnprocessableEntity(t *testing.T) {
+	user := registerUserWithTotp(t)
+
+	response, err := DisableTotp(user.User.User.Token, user.Password+"wrong")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestGivenTotpIsEnabledWhenDisableTotpShouldLoginWithoutSecondFactor(t *testing.T) {
+	user := registerUserWithTotp(t)
+
+	response, err := DisableTotp(user.User.User.Token, user.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNoContent)
+	}
+
+	loggedInUser, err := LoginAndDecode(user.User.User.Email, user.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(loggedInUser.User.Token) == 0 {
+		t.Fatal("got no token")
+	}
+}
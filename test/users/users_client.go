@@ -2,9 +2,27 @@ package users
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
 	"net/http"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
 )
 
 type RegisterUserRequest struct {
@@ -57,23 +75,53 @@ type updateUserRequestUser struct {
 	Image    *string `json:"image" faker:"url"`
 }
 
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
 type UserResponse struct {
+	User struct {
+		Username      string `json:"username"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"emailVerified"`
+		Token         string `json:"token"`
+		RefreshToken  string `json:"refreshToken"`
+		Bio           string `json:"bio"`
+		Image         string `json:"image"`
+	} `json:"user"`
+}
+
+type GetUserResponse struct {
 	User struct {
 		Username string `json:"username"`
 		Email    string `json:"email"`
-		Token    string `json:"token"`
 		Bio      string `json:"bio"`
 		Image    string `json:"image"`
 	} `json:"user"`
 }
 
-type GetUserResponse struct {
-	User struct {
+type TokenClaims struct {
+	jwt.StandardClaims
+	Username string   `json:"username"`
+	Roles    []string `json:"roles"`
+	Scope    string   `json:"scope"`
+}
+
+type BatchGetUsersRequest struct {
+	Ids       []string `json:"ids,omitempty"`
+	Usernames []string `json:"usernames,omitempty"`
+}
+
+type BatchGetUsersResponse struct {
+	Users []struct {
+		Id       string `json:"id"`
 		Username string `json:"username"`
 		Email    string `json:"email"`
 		Bio      string `json:"bio"`
 		Image    string `json:"image"`
-	} `json:"user"`
+	} `json:"users"`
+	MissingIds       []string `json:"missingIds"`
+	MissingUsernames []string `json:"missingUsernames"`
 }
 
 type ErrorResponse struct {
@@ -165,6 +213,10 @@ func LoginAndDecode(email string, password string) (*UserResponse, error) {
 }
 
 func GetCurrentUser(tokenString string) (*http.Response, error) {
+	return GetCurrentUserWithAuthorization(fmt.Sprintf("Bearer %s", tokenString))
+}
+
+func GetCurrentUserWithAuthorization(authorization string) (*http.Response, error) {
 	client := &http.Client{}
 	const url = "http://localhost:8080/user"
 
@@ -173,7 +225,7 @@ func GetCurrentUser(tokenString string) (*http.Response, error) {
 		return nil, err
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", tokenString))
+	req.Header.Set("Authorization", authorization)
 
 	response, err := client.Do(req)
 	if err != nil {
@@ -216,6 +268,25 @@ func GetUserByUsername(username string) (*http.Response, error) {
 	return response, nil
 }
 
+func GetUserByUsernameWithAuthorization(username string, authorization string) (*http.Response, error) {
+	client := &http.Client{}
+	url := fmt.Sprintf("http://localhost:8080/users/%s", username)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", authorization)
+
+	response, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
 func GetUserByUsernameAndDecode(username string) (*GetUserResponse, error) {
 	response, err := GetUserByUsername(username)
 	if err != nil {
@@ -237,6 +308,43 @@ func GetUserByUsernameAndDecode(username string) (*GetUserResponse, error) {
 	return responseData, nil
 }
 
+func BatchGetUsers(request BatchGetUsersRequest) (*http.Response, error) {
+	const url = "http://localhost:8080/users:batchGet"
+
+	requestBytes, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := http.Post(url, "application/json", bytes.NewBuffer(requestBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func BatchGetUsersAndDecode(request BatchGetUsersRequest) (*BatchGetUsersResponse, error) {
+	response, err := BatchGetUsers(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	defer response.Body.Close()
+
+	responseData := &BatchGetUsersResponse{}
+	err = json.NewDecoder(response.Body).Decode(&responseData)
+	if err != nil {
+		return nil, err
+	}
+
+	return responseData, nil
+}
+
 func UpdateUser(tokenString string, request UpdateUserRequest) (*http.Response, error) {
 	client := &http.Client{}
 	const url = "http://localhost:8080/user"
@@ -277,3 +385,486 @@ func UpdateUserAndDecode(tokenString string, request UpdateUserRequest) (*UserRe
 
 	return responseData, nil
 }
+
+func RefreshToken(refreshToken string) (*http.Response, error) {
+	const url = "http://localhost:8080/users/token/refresh"
+
+	requestData := RefreshTokenRequest{RefreshToken: refreshToken}
+
+	requestBody, err := json.Marshal(requestData)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := http.Post(url, "application/json", bytes.NewBuffer(requestBody))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func RefreshTokenAndDecode(refreshToken string) (*UserResponse, error) {
+	response, err := RefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	defer response.Body.Close()
+
+	responseData := &UserResponse{}
+	err = json.NewDecoder(response.Body).Decode(&responseData)
+	if err != nil {
+		return nil, err
+	}
+
+	return responseData, nil
+}
+
+type LogoutRequest struct {
+	RefreshToken *string `json:"refreshToken,omitempty"`
+}
+
+func Logout(tokenString string, request LogoutRequest) (*http.Response, error) {
+	client := &http.Client{}
+	const url = "http://localhost:8080/user/logout"
+
+	requestBytes, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", tokenString))
+
+	response, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func LogoutAll(tokenString string) (*http.Response, error) {
+	client := &http.Client{}
+	const url = "http://localhost:8080/user/logout-all"
+
+	req, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", tokenString))
+
+	response, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+type RequestPasswordResetRequest struct {
+	Email string `json:"email"`
+}
+
+type ConfirmPasswordResetRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func RequestPasswordReset(email string) (*http.Response, error) {
+	return RequestPasswordResetWithAcceptLanguage(email, "")
+}
+
+func RequestPasswordResetWithAcceptLanguage(email string, acceptLanguage string) (*http.Response, error) {
+	client := &http.Client{}
+	const url = "http://localhost:8080/users/password-reset"
+
+	requestBody, err := json.Marshal(RequestPasswordResetRequest{Email: email})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("content-type", "application/json")
+
+	if len(acceptLanguage) > 0 {
+		req.Header.Set("Accept-Language", acceptLanguage)
+	}
+
+	response, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func ConfirmPasswordReset(token string, password string) (*http.Response, error) {
+	const url = "http://localhost:8080/users/password-reset/confirm"
+
+	requestBody, err := json.Marshal(ConfirmPasswordResetRequest{Token: token, Password: password})
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := http.Post(url, "application/json", bytes.NewBuffer(requestBody))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+var mailedTokenRegexp = regexp.MustCompile(`token(?:=|: )([A-Za-z0-9_-]+)`)
+
+const (
+	PasswordResetMailSubject     = "Reset your password"
+	EmailVerificationMailSubject = "Verify your email"
+)
+
+// WaitForMailedToken waits for a mail with the subject written to MAIL_FILE_DIR for the recipient
+// after sentAfter, and returns the token it carries.
+func WaitForMailedToken(recipient string, subject string, sentAfter time.Time) (*string, error) {
+	mailFileDir := os.Getenv("MAIL_FILE_DIR")
+	if len(mailFileDir) == 0 {
+		return nil, fmt.Errorf("Environment variable 'MAIL_FILE_DIR' must be set and not be empty")
+	}
+
+	suffix := fmt.Sprintf("_%s.eml", url.PathEscape(recipient))
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		mailFiles, err := filepath.Glob(filepath.Join(mailFileDir, "*"+suffix))
+		if err != nil {
+			return nil, err
+		}
+
+		var lastMailContent []byte
+		var lastSentAt int64
+		for _, mailFile := range mailFiles {
+			sentAt, err := strconv.ParseInt(strings.TrimSuffix(filepath.Base(mailFile), suffix), 10, 64)
+			if err != nil || sentAt <= sentAfter.UnixNano() || sentAt <= lastSentAt {
+				continue
+			}
+
+			content, err := os.ReadFile(mailFile)
+			if err != nil {
+				return nil, err
+			}
+
+			mailSubject, textBody, err := readMail(content)
+			if err != nil {
+				return nil, err
+			}
+
+			if mailSubject == subject {
+				lastMailContent = textBody
+				lastSentAt = sentAt
+			}
+		}
+
+		if lastMailContent == nil {
+			continue
+		}
+
+		match := mailedTokenRegexp.FindSubmatch(lastMailContent)
+		if match == nil {
+			return nil, fmt.Errorf("no token in mail to %s", recipient)
+		}
+
+		token := string(match[1])
+		return &token, nil
+	}
+
+	return nil, fmt.Errorf("no mail for %s", recipient)
+}
+
+// readMail returns the decoded subject and plain text body of a mail.
+func readMail(content []byte) (string, []byte, error) {
+	message, err := mail.ReadMessage(bytes.NewReader(content))
+	if err != nil {
+		return "", nil, err
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	if err != nil {
+		return "", nil, err
+	}
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil {
+		return "", nil, err
+	}
+
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		textBody, err := io.ReadAll(quotedprintable.NewReader(message.Body))
+		return subject, textBody, err
+	}
+
+	multipartReader := multipart.NewReader(message.Body, params["boundary"])
+	for {
+		part, err := multipartReader.NextPart()
+		if err != nil {
+			return "", nil, err
+		}
+
+		if strings.HasPrefix(part.Header.Get("Content-Type"), "text/plain") {
+			textBody, err := io.ReadAll(part)
+			return subject, textBody, err
+		}
+	}
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+func VerifyEmail(token string) (*http.Response, error) {
+	const url = "http://localhost:8080/users/verify-email"
+
+	requestBody, err := json.Marshal(VerifyEmailRequest{Token: token})
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := http.Post(url, "application/json", bytes.NewBuffer(requestBody))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func ResendVerificationEmail(tokenString string) (*http.Response, error) {
+	client := &http.Client{}
+	const url = "http://localhost:8080/user/verify-email/resend"
+
+	req, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", tokenString))
+
+	response, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+type TotpEnrollmentResponse struct {
+	Totp struct {
+		Secret string `json:"secret"`
+		Uri    string `json:"uri"`
+	} `json:"totp"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type TwoFactorChallengeResponse struct {
+	Challenge struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expiresAt"`
+	} `json:"challenge"`
+}
+
+type ConfirmTotpRequest struct {
+	Code string `json:"code"`
+}
+
+type DisableTotpRequest struct {
+	Password string `json:"password"`
+}
+
+type VerifyTwoFactorLoginRequest struct {
+	Token        string `json:"token"`
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recoveryCode,omitempty"`
+}
+
+func EnrollTotp(tokenString string) (*http.Response, error) {
+	return doAuthenticatedRequest("POST", "http://localhost:8080/user/2fa/totp", tokenString, nil)
+}
+
+func EnrollTotpAndDecode(tokenString string) (*TotpEnrollmentResponse, error) {
+	response, err := EnrollTotp(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	defer response.Body.Close()
+
+	totpEnrollmentResponse := &TotpEnrollmentResponse{}
+	err = json.NewDecoder(response.Body).Decode(totpEnrollmentResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return totpEnrollmentResponse, nil
+}
+
+func ConfirmTotp(tokenString string, code string) (*http.Response, error) {
+	return doAuthenticatedRequest("POST", "http://localhost:8080/user/2fa/totp/confirm", tokenString, ConfirmTotpRequest{Code: code})
+}
+
+func ConfirmTotpAndDecode(tokenString string, code string) (*RecoveryCodesResponse, error) {
+	response, err := ConfirmTotp(tokenString, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	defer response.Body.Close()
+
+	recoveryCodesResponse := &RecoveryCodesResponse{}
+	err = json.NewDecoder(response.Body).Decode(recoveryCodesResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodesResponse, nil
+}
+
+func DisableTotp(tokenString string, password string) (*http.Response, error) {
+	return doAuthenticatedRequest("DELETE", "http://localhost:8080/user/2fa/totp", tokenString, DisableTotpRequest{Password: password})
+}
+
+func LoginAndDecodeChallenge(email string, password string) (*TwoFactorChallengeResponse, error) {
+	response, err := Login(email, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	defer response.Body.Close()
+
+	twoFactorChallengeResponse := &TwoFactorChallengeResponse{}
+	err = json.NewDecoder(response.Body).Decode(twoFactorChallengeResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(twoFactorChallengeResponse.Challenge.Token) == 0 {
+		return nil, fmt.Errorf("got no challenge token")
+	}
+
+	return twoFactorChallengeResponse, nil
+}
+
+func VerifyTwoFactorLogin(request VerifyTwoFactorLoginRequest) (*http.Response, error) {
+	const url = "http://localhost:8080/users/login/2fa"
+
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := http.Post(url, "application/json", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func VerifyTwoFactorLoginAndDecode(request VerifyTwoFactorLoginRequest) (*UserResponse, error) {
+	response, err := VerifyTwoFactorLogin(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	defer response.Body.Close()
+
+	userResponse := &UserResponse{}
+	err = json.NewDecoder(response.Body).Decode(userResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return userResponse, nil
+}
+
+// TotpCode returns the RFC 6238 code of the secret at t, with the SHA-1, 6 digits and 30 seconds
+// parameters the service uses.
+func TotpCode(secret string, t time.Time) (string, error) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		return "", err
+	}
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(t.Unix()/30))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%06d", value%1000000), nil
+}
+
+func doAuthenticatedRequest(method string, url string, tokenString string, request interface{}) (*http.Response, error) {
+	client := &http.Client{}
+
+	var body io.Reader
+	if request != nil {
+		requestBody, err := json.Marshal(request)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewBuffer(requestBody)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", tokenString))
+	if request != nil {
+		req.Header.Set("content-type", "application/json")
+	}
+
+	response, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
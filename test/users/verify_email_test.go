@@ -0,0 +1,277 @@
+package users
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bxcodec/faker/v3"
+)
+
+func TestGivenUserRegisteredWhenVerifyEmailShouldMarkEmailVerified(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredAt := time.Now()
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if registeredUser.User.EmailVerified {
+		t.Fatal("EmailVerified must be false")
+	}
+
+	token, err := WaitForMailedToken(registeredUser.User.Email, EmailVerificationMailSubject, registeredAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := VerifyEmail(*token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNoContent)
+	}
+
+	user, err := GetCurrentUserAndDecode(registeredUser.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !user.User.EmailVerified {
+		t.Fatal("EmailVerified must be true")
+	}
+
+	response, err = VerifyEmail(*token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestGivenEmailVerifiedWhenUpdateEmailShouldRequireNewVerification(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredAt := time.Now()
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := WaitForMailedToken(registeredUser.User.Email, EmailVerificationMailSubject, registeredAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := VerifyEmail(*token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNoContent)
+	}
+
+	sameEmail := strings.ToUpper(registeredUser.User.Email)
+
+	user, err := UpdateUserAndDecode(registeredUser.User.Token, UpdateUserRequest{
+		User: updateUserRequestUser{
+			Email: &sameEmail,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !user.User.EmailVerified {
+		t.Fatal("EmailVerified must be true")
+	}
+
+	email := faker.Email()
+	updatedAt := time.Now()
+
+	user, err = UpdateUserAndDecode(registeredUser.User.Token, UpdateUserRequest{
+		User: updateUserRequestUser{
+			Email: &email,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.User.EmailVerified {
+		t.Fatal("EmailVerified must be false")
+	}
+
+	token, err = WaitForMailedToken(email, EmailVerificationMailSubject, updatedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err = VerifyEmail(*token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNoContent)
+	}
+
+	user, err = GetCurrentUserAndDecode(registeredUser.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !user.User.EmailVerified {
+		t.Fatal("EmailVerified must be true")
+	}
+}
+
+func TestGivenEmailChangedWhenVerifyEmailWithPreviousTokenShouldReturnUnprocessableEntity(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredAt := time.Now()
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := WaitForMailedToken(registeredUser.User.Email, EmailVerificationMailSubject, registeredAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	email := faker.Email()
+
+	_, err = UpdateUserAndDecode(registeredUser.User.Token, UpdateUserRequest{
+		User: updateUserRequestUser{
+			Email: &email,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := VerifyEmail(*token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestGivenPasswordResetTokenWhenVerifyEmailShouldReturnUnprocessableEntity(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requestedAt := time.Now()
+
+	_, err = RequestPasswordReset(requestData.User.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := WaitForMailedToken(registeredUser.User.Email, PasswordResetMailSubject, requestedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := VerifyEmail(*token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestGivenTokenIsInvalidWhenVerifyEmailShouldReturnUnprocessableEntity(t *testing.T) {
+	response, err := VerifyEmail(faker.Password())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestGivenEmailNotVerifiedWhenResendVerificationEmailShouldMailNewToken(t *testing.T) {
+	requestData := RegisterUserRequest{}
+
+	err := faker.FakeData(&requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := RegisterUserAndDecode(requestData.User.Username, requestData.User.Email, requestData.User.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = WaitForMailedToken(registeredUser.User.Email, EmailVerificationMailSubject, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resentAt := time.Now()
+
+	response, err := ResendVerificationEmail(registeredUser.User.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusAccepted {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusAccepted)
+	}
+
+	token, err := WaitForMailedToken(registeredUser.User.Email, EmailVerificationMailSubject, resentAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err = VerifyEmail(*token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("got %d, want %d", response.StatusCode, http.StatusNoContent)
+	}
+}
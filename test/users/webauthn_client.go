@@ -0,0 +1,404 @@
+package users
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const (
+	WebauthnRpId   = "localhost"
+	WebauthnOrigin = "http://localhost:3000"
+)
+
+type PasskeyCreationOptionsResponse struct {
+	PublicKey struct {
+		Challenge string `json:"challenge"`
+		Rp        struct {
+			Id   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"rp"`
+		User struct {
+			Id   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"user"`
+		ExcludeCredentials []PasskeyCredentialDescriptor `json:"excludeCredentials"`
+	} `json:"publicKey"`
+}
+
+type PasskeyRequestOptionsResponse struct {
+	PublicKey struct {
+		Challenge        string                        `json:"challenge"`
+		RpId             string                        `json:"rpId"`
+		AllowCredentials []PasskeyCredentialDescriptor `json:"allowCredentials"`
+	} `json:"publicKey"`
+}
+
+type PasskeyCredentialDescriptor struct {
+	Type string `json:"type"`
+	Id   string `json:"id"`
+}
+
+type PasskeyRegistrationCredential struct {
+	Id       string `json:"id"`
+	RawId    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJson    string `json:"clientDataJSON"`
+		AttestationObject string `json:"attestationObject"`
+	} `json:"response"`
+}
+
+type PasskeyAuthenticationCredential struct {
+	Id       string `json:"id"`
+	RawId    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJson    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response"`
+}
+
+type FinishPasskeyRegistrationRequest struct {
+	Name       string                        `json:"name"`
+	Credential PasskeyRegistrationCredential `json:"credential"`
+}
+
+type FinishPasskeyLoginRequest struct {
+	Credential PasskeyAuthenticationCredential `json:"credential"`
+}
+
+type PasskeyResponse struct {
+	Passkey Passkey `json:"passkey"`
+}
+
+type PasskeysResponse struct {
+	Passkeys []Passkey `json:"passkeys"`
+}
+
+type Passkey struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func BeginPasskeyRegistrationAndDecode(tokenString string) (*PasskeyCreationOptionsResponse, error) {
+	response, err := doAuthenticatedRequest("POST", "http://localhost:8080/user/passkeys/registration/begin", tokenString, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	defer response.Body.Close()
+
+	creationOptions := &PasskeyCreationOptionsResponse{}
+	err = json.NewDecoder(response.Body).Decode(creationOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return creationOptions, nil
+}
+
+func FinishPasskeyRegistration(tokenString string, request FinishPasskeyRegistrationRequest) (*http.Response, error) {
+	return doAuthenticatedRequest("POST", "http://localhost:8080/user/passkeys/registration/finish", tokenString, request)
+}
+
+func ListPasskeysAndDecode(tokenString string) (*PasskeysResponse, error) {
+	response, err := doAuthenticatedRequest("GET", "http://localhost:8080/user/passkeys", tokenString, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	defer response.Body.Close()
+
+	passkeys := &PasskeysResponse{}
+	err = json.NewDecoder(response.Body).Decode(passkeys)
+	if err != nil {
+		return nil, err
+	}
+
+	return passkeys, nil
+}
+
+func BeginPasskeyLoginAndDecode(email string) (*PasskeyRequestOptionsResponse, error) {
+	const url = "http://localhost:8080/users/login/passkey/begin"
+
+	requestBody, err := json.Marshal(map[string]string{"email": email})
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := http.Post(url, "application/json", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	defer response.Body.Close()
+
+	requestOptions := &PasskeyRequestOptionsResponse{}
+	err = json.NewDecoder(response.Body).Decode(requestOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return requestOptions, nil
+}
+
+func FinishPasskeyLogin(request FinishPasskeyLoginRequest) (*http.Response, error) {
+	const url = "http://localhost:8080/users/login/passkey/finish"
+
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := http.Post(url, "application/json", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func FinishPasskeyLoginAndDecode(request FinishPasskeyLoginRequest) (*UserResponse, error) {
+	response, err := FinishPasskeyLogin(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	defer response.Body.Close()
+
+	userResponse := &UserResponse{}
+	err = json.NewDecoder(response.Body).Decode(userResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return userResponse, nil
+}
+
+func FinishPasskeyLoginAndDecodeChallenge(request FinishPasskeyLoginRequest) (*TwoFactorChallengeResponse, error) {
+	response, err := FinishPasskeyLogin(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got %d, want %d", response.StatusCode, http.StatusOK)
+	}
+
+	defer response.Body.Close()
+
+	twoFactorChallengeResponse := &TwoFactorChallengeResponse{}
+	err = json.NewDecoder(response.Body).Decode(twoFactorChallengeResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(twoFactorChallengeResponse.Challenge.Token) == 0 {
+		return nil, fmt.Errorf("got no challenge token")
+	}
+
+	return twoFactorChallengeResponse, nil
+}
+
+// SoftAuthenticator is a software passkey authenticator with an ES256 key, standing in for the
+// browser and the authenticator in WebAuthn ceremonies. Its assertions only test the presence of
+// the user, unless UserVerified is set.
+type SoftAuthenticator struct {
+	CredentialId []byte
+	PrivateKey   *ecdsa.PrivateKey
+	UserHandle   string
+	SignCount    uint32
+	UserVerified bool
+}
+
+func NewSoftAuthenticator() (*SoftAuthenticator, error) {
+	credentialId := make([]byte, 16)
+	_, err := rand.Read(credentialId)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SoftAuthenticator{
+		CredentialId: credentialId,
+		PrivateKey:   privateKey,
+	}, nil
+}
+
+func (a *SoftAuthenticator) Id() string {
+	return base64.RawURLEncoding.EncodeToString(a.CredentialId)
+}
+
+func (a *SoftAuthenticator) Create(options PasskeyCreationOptionsResponse, origin string) (*PasskeyRegistrationCredential, error) {
+	a.UserHandle = options.PublicKey.User.Id
+
+	clientDataJson, err := json.Marshal(map[string]interface{}{
+		"type":      "webauthn.create",
+		"challenge": options.PublicKey.Challenge,
+		"origin":    origin,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	a.PrivateKey.X.FillBytes(x)
+	a.PrivateKey.Y.FillBytes(y)
+
+	coseKey := cborMap([][2][]byte{
+		{cborInt(1), cborInt(2)},
+		{cborInt(3), cborInt(-7)},
+		{cborInt(-1), cborInt(1)},
+		{cborInt(-2), cborBytes(x)},
+		{cborInt(-3), cborBytes(y)},
+	})
+
+	authData := a.authenticatorData(options.PublicKey.Rp.Id, 0x41)
+	authData = append(authData, make([]byte, 16)...)
+	authData = append(authData, byte(len(a.CredentialId)>>8), byte(len(a.CredentialId)))
+	authData = append(authData, a.CredentialId...)
+	authData = append(authData, coseKey...)
+
+	attestationObject := cborMap([][2][]byte{
+		{cborText("fmt"), cborText("none")},
+		{cborText("attStmt"), cborMap(nil)},
+		{cborText("authData"), cborBytes(authData)},
+	})
+
+	credential := &PasskeyRegistrationCredential{
+		Id:    a.Id(),
+		RawId: a.Id(),
+		Type:  "public-key",
+	}
+	credential.Response.ClientDataJson = base64.RawURLEncoding.EncodeToString(clientDataJson)
+	credential.Response.AttestationObject = base64.RawURLEncoding.EncodeToString(attestationObject)
+
+	return credential, nil
+}
+
+func (a *SoftAuthenticator) Get(options PasskeyRequestOptionsResponse, origin string) (*PasskeyAuthenticationCredential, error) {
+	clientDataJson, err := json.Marshal(map[string]interface{}{
+		"type":      "webauthn.get",
+		"challenge": options.PublicKey.Challenge,
+		"origin":    origin,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	flags := byte(0x01)
+	if a.UserVerified {
+		flags |= 0x04
+	}
+
+	authData := a.authenticatorData(options.PublicKey.RpId, flags)
+
+	clientDataHash := sha256.Sum256(clientDataJson)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+
+	signature, err := ecdsa.SignASN1(rand.Reader, a.PrivateKey, digest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	credential := &PasskeyAuthenticationCredential{
+		Id:    a.Id(),
+		RawId: a.Id(),
+		Type:  "public-key",
+	}
+	credential.Response.ClientDataJson = base64.RawURLEncoding.EncodeToString(clientDataJson)
+	credential.Response.AuthenticatorData = base64.RawURLEncoding.EncodeToString(authData)
+	credential.Response.Signature = base64.RawURLEncoding.EncodeToString(signature)
+	credential.Response.UserHandle = a.UserHandle
+
+	return credential, nil
+}
+
+// authenticatorData increments the sign count, as authenticators do on every signature.
+func (a *SoftAuthenticator) authenticatorData(rpId string, flags byte) []byte {
+	a.SignCount++
+
+	rpIdHash := sha256.Sum256([]byte(rpId))
+
+	authData := append([]byte{}, rpIdHash[:]...)
+	authData = append(authData, flags)
+	signCount := make([]byte, 4)
+	binary.BigEndian.PutUint32(signCount, a.SignCount)
+	authData = append(authData, signCount...)
+
+	return authData
+}
+
+func cborHead(majorType byte, argument uint64) []byte {
+	switch {
+	case argument < 24:
+		return []byte{majorType<<5 | byte(argument)}
+	case argument <= 0xff:
+		return []byte{majorType<<5 | 24, byte(argument)}
+	case argument <= 0xffff:
+		head := make([]byte, 3)
+		head[0] = majorType<<5 | 25
+		binary.BigEndian.PutUint16(head[1:], uint16(argument))
+		return head
+	default:
+		head := make([]byte, 5)
+		head[0] = majorType<<5 | 26
+		binary.BigEndian.PutUint32(head[1:], uint32(argument))
+		return head
+	}
+}
+
+func cborInt(value int64) []byte {
+	if value < 0 {
+		return cborHead(1, uint64(-1-value))
+	}
+	return cborHead(0, uint64(value))
+}
+
+func cborBytes(value []byte) []byte {
+	return append(cborHead(2, uint64(len(value))), value...)
+}
+
+func cborText(value string) []byte {
+	return append(cborHead(3, uint64(len(value))), value...)
+}
+
+func cborMap(entries [][2][]byte) []byte {
+	encoded := cborHead(5, uint64(len(entries)))
+	for _, entry := range entries {
+		encoded = append(encoded, entry[0]...)
+		encoded = append(encoded, entry[1]...)
+	}
+	return encoded
+}